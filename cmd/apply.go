@@ -2,10 +2,14 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/spf13/cobra"
+	"github.com/tuannvm/mcpenetes/internal/client"
 	"github.com/tuannvm/mcpenetes/internal/config"
 	"github.com/tuannvm/mcpenetes/internal/core"
 	"github.com/tuannvm/mcpenetes/internal/log"
@@ -24,10 +28,47 @@ var applyCmd = &cobra.Command{
 4. Backing up existing configuration files before overwriting
 5. Writing the new converted configuration for each client
 
+Claude Code keeps servers per scope. Use --scope to pick which one is written:
+  user     ~/.claude.json "mcpServers" (default)
+  project  ~/.claude.json "projects"[<project>]."mcpServers"
+  repo     <project>/.mcp.json, shared with the repository
+
+Detected clients whose config directory is the only sign of an install (no config
+file, executable on PATH, desktop entry or running process) are skipped, since the
+directory may be left over from an uninstalled app. Use --include-low-confidence
+to apply to them anyway.
+
+Some clients, like Claude Desktop, rewrite their config when they exit, undoing
+changes made while they run. On Linux, apply looks for their processes first and
+--if-running decides what happens:
+  warn  write anyway and remind you to restart the client (default)
+  wait  wait for the client to exit, up to --wait-timeout
+  skip  leave the client's config untouched
+
 This command requires confirmation before proceeding.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("Preparing to apply MCP configuration...")
 
+		scope, _ := cmd.Flags().GetString("scope")
+		project, _ := cmd.Flags().GetString("project")
+		switch client.ScopeEnum(scope) {
+		case "", client.ScopeUser, client.ScopeProject, client.ScopeRepo:
+		default:
+			log.Fatal("Invalid --scope '%s': expected user, project or repo", scope)
+		}
+		ifRunning, _ := cmd.Flags().GetString("if-running")
+		runningPolicy, err := core.ParseRunningPolicy(ifRunning)
+		if err != nil {
+			log.Fatal("Invalid --if-running: %v", err)
+		}
+		if scope != "" && project == "" {
+			cwd, err := os.Getwd()
+			if err != nil {
+				log.Fatal("Error determining current directory for --project: %v", err)
+			}
+			project = cwd
+		}
+
 		// 1. Load configurations
 		cfg, err := config.LoadConfig()
 		if err != nil {
@@ -44,25 +85,15 @@ This command requires confirmation before proceeding.`,
 			log.Fatal("No MCP servers found in mcp.json. Please add a server configuration first.")
 		}
 
-		// Check if clients are defined in config
-		if len(cfg.Clients) == 0 {
-			log.Info("No clients defined in config.yaml. Detecting installed clients...")
-
-			// Auto-detect installed clients
-			detectedClients, err := util.DetectMCPClients()
-			if err != nil {
-				log.Warn("Error detecting clients: %v", err)
-			}
-
-			if len(detectedClients) == 0 {
-				log.Warn("No MCP-compatible clients detected on this system.")
-				return
-			}
-
-			// Use the detected clients
-			cfg.Clients = detectedClients
-			log.Success("Detected %d client(s) on your system!", len(detectedClients))
+		// Use the clients from config.yaml, or detect installed ones if none are configured.
+		// Clients disabled in config.yaml are left out either way.
+		includeLow, _ := cmd.Flags().GetBool("include-low-confidence")
+		resolvedClients, skippedClients, err := util.ResolveClients(cfg, includeLow)
+		if err != nil {
+			log.Warn("Error detecting clients: %v", err)
 		}
+		cfg.Clients = resolvedClients
+		printDetectionReasons(resolvedClients, skippedClients)
 
 		if len(cfg.Clients) == 0 {
 			log.Warn("No clients found to apply configuration to.")
@@ -99,8 +130,8 @@ This command requires confirmation before proceeding.`,
 			selectedClientMap = cfg.Clients // Use all clients
 		} else {
 			// Only include the single selected client
-			if client, ok := cfg.Clients[selectedClient]; ok {
-				selectedClientMap[selectedClient] = client
+			if clientConf, ok := cfg.Clients[selectedClient]; ok {
+				selectedClientMap[selectedClient] = clientConf
 			}
 		}
 
@@ -109,6 +140,23 @@ This command requires confirmation before proceeding.`,
 			return
 		}
 
+		// Apply the requested scope to Claude Code clients only
+		if scope != "" {
+			scoped := false
+			for clientName, clientConf := range selectedClientMap {
+				if client.ConfigFormatEnum(clientConf.Type) != client.FormatClaudeCode {
+					continue
+				}
+				clientConf.Scope = scope
+				clientConf.Project = project
+				selectedClientMap[clientName] = clientConf
+				scoped = true
+			}
+			if !scoped {
+				log.Warn("--scope only applies to Claude Code, which is not among the selected clients.")
+			}
+		}
+
 		// Generate client list for display
 		clientList := ""
 		for clientName := range selectedClientMap {
@@ -141,17 +189,36 @@ This command requires confirmation before proceeding.`,
 
 		// Create Manager
 		manager := core.NewManager(cfg, mcpCfg)
+		manager.RunningPolicy = runningPolicy
+		manager.WaitTimeout, _ = cmd.Flags().GetDuration("wait-timeout")
+		manager.OnWait = func(clientName string, running []string) {
+			log.Info("  %s is running (%s); waiting up to %s for it to exit...", clientName, strings.Join(running, ", "), manager.WaitTimeout)
+		}
 
 		// Process all clients and all servers
 		log.Info("Processing clients and servers...")
 		clientSuccessCount := 0
 		clientFailureCount := 0
+		clientSkippedCount := 0
+		warningCount := 0
 
 		// For each selected client
 		for clientName, clientConf := range selectedClientMap {
 			log.Printf(log.InfoColor, "- Processing client: %s\n", clientName)
 
 			res := manager.ApplyToClient(clientName, clientConf)
+			for _, w := range res.Warnings {
+				log.Warn("  %s", w)
+			}
+			warningCount += len(res.Warnings)
+			if res.Skipped {
+				log.Warn("  Skipped %s: it is running (%s). Close it and apply again.", clientName, strings.Join(res.Running, ", "))
+				clientSkippedCount++
+				continue
+			}
+			if len(res.Running) > 0 && !res.Waited && res.Success {
+				log.Warn("  %s is running (%s) and may revert the changes when it exits. Restart it now to load them.", clientName, strings.Join(res.Running, ", "))
+			}
 			if res.Success {
 				log.Success("  Successfully applied configuration to %s", clientName)
 				if res.BackupPath != "" {
@@ -169,6 +236,12 @@ This command requires confirmation before proceeding.`,
 
 		log.Info("\nApply operation finished.")
 		log.Success("Successfully processed %d clients.", clientSuccessCount)
+		if clientSkippedCount > 0 {
+			log.Warn("Skipped %d running client(s).", clientSkippedCount)
+		}
+		if warningCount > 0 {
+			log.Warn("%d setting(s) could not be represented by their clients; see warnings above.", warningCount)
+		}
 		if clientFailureCount > 0 {
 			log.Error("Failed to apply to %d clients.", clientFailureCount)
 			os.Exit(1) // Exit with error if any client failed
@@ -176,6 +249,25 @@ This command requires confirmation before proceeding.`,
 	},
 }
 
+// printDetectionReasons explains why each detected client was picked, and why low-confidence
+// ones were skipped. Clients from config.yaml carry no evidence and are not listed.
+func printDetectionReasons(picked, skipped map[string]config.Client) {
+	for _, name := range slices.Sorted(maps.Keys(picked)) {
+		if c := picked[name]; c.Confidence != "" {
+			log.Info("  %s (%s confidence): %s", name, c.Confidence, strings.Join(c.Evidence, "; "))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(skipped)) {
+		c := skipped[name]
+		log.Warn("  Skipping %s (low confidence): %s. Use --include-low-confidence to apply to it anyway.", name, strings.Join(c.Evidence, "; "))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().String("scope", "", "Claude Code scope to write: user, project or repo")
+	applyCmd.Flags().String("project", "", "Project directory for the project and repo scopes (defaults to the current directory)")
+	applyCmd.Flags().String("if-running", string(core.RunningWarn), "What to do when a client is running: warn, wait or skip")
+	applyCmd.Flags().Duration("wait-timeout", core.DefaultWaitTimeout, "How long --if-running=wait waits for a client to exit")
+	applyCmd.Flags().Bool("include-low-confidence", false, "Also apply to detected clients that may not be installed (only their config directory exists)")
 }
@@ -0,0 +1,514 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/translator"
+	"github.com/tuannvm/mcpenetes/internal/util"
+)
+
+// clientsCmd groups the commands that inspect and manage client targets
+var clientsCmd = &cobra.Command{
+	Use:   "clients",
+	Short: "Inspect and manage MCP client targets",
+	Long: `Parent command for the MCP clients mcpenetes detects and writes configuration to.
+
+Clients listed in config.yaml are used as-is. When none are listed, apply falls
+back to detecting installed clients; "clients sync" saves them to config.yaml.`,
+}
+
+// clientsListCmd lists configured and detected clients
+var clientsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured and detected clients",
+	Long: `Lists the clients in config.yaml and those detected on this system, with their
+config format, config path and the number of servers currently in their config.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+
+		detected, err := util.DetectMCPClients()
+		if err != nil {
+			log.Warn("Error detecting clients: %v", err)
+		}
+
+		names := make(map[string]bool)
+		for name := range cfg.Clients {
+			names[name] = true
+		}
+		for name := range detected {
+			names[name] = true
+		}
+		if len(names) == 0 {
+			log.Warn("No clients configured or detected.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CLIENT\tSOURCE\tFORMAT\tSERVERS\tPATH")
+		for _, name := range slices.Sorted(maps.Keys(names)) {
+			clientConf, source := describeClient(name, cfg.Clients, detected)
+			servers := "-"
+			if clientConf.ConfigPath != "" {
+				if found, err := translator.ReadClientServers(name, clientConf); err == nil {
+					servers = fmt.Sprintf("%d", len(found))
+				} else {
+					servers = "?"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, source, clientConf.Type, servers, clientConf.ConfigPath)
+		}
+		w.Flush()
+	},
+}
+
+// describeClient merges a client's config.yaml entry with its detection and says where it comes from.
+func describeClient(name string, configured, detected map[string]config.Client) (config.Client, string) {
+	entry, isConfigured := configured[name]
+	found, isDetected := detected[name]
+
+	clientConf := entry
+	if clientConf.ConfigPath == "" {
+		clientConf.ConfigPath = found.ConfigPath
+		clientConf.Key = found.Key
+	}
+	if clientConf.Type == "" {
+		clientConf.Type = found.Type
+	}
+
+	var source string
+	switch {
+	case entry.Disabled:
+		source = "disabled"
+	case isConfigured && entry.ConfigPath != "":
+		source = "configured"
+	case isDetected:
+		source = fmt.Sprintf("detected (%s)", found.Confidence)
+	default:
+		source = "configured (no path)"
+	}
+	return clientConf, source
+}
+
+// clientsShowCmd prints the servers in a client's config file
+var clientsShowCmd = &cobra.Command{
+	Use:   "show <client>",
+	Short: "Show the servers currently in a client's config",
+	Long: `Prints the MCP servers found in a client's config file, and whether each of them
+is managed by mcpenetes (present in mcp.json).`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+		mcpCfg, err := config.LoadMCPConfig()
+		if err != nil {
+			log.Fatal("Error loading mcp.json: %v", err)
+		}
+		detected, err := util.DetectMCPClients()
+		if err != nil {
+			log.Warn("Error detecting clients: %v", err)
+		}
+
+		clientConf, source := describeClient(name, cfg.Clients, detected)
+		if clientConf.ConfigPath == "" {
+			log.Fatal("Client '%s' is not configured or detected", name)
+		}
+
+		servers, err := translator.ReadClientServers(name, clientConf)
+		if err != nil {
+			log.Fatal("Error reading %s: %v", clientConf.ConfigPath, err)
+		}
+
+		log.Info("%s (%s, %s)", name, source, clientConf.Type)
+		log.Detail("Config: %s", clientConf.ConfigPath)
+		if found, ok := detected[name]; ok {
+			log.Detail("Defined by: %s", found.Catalog)
+		}
+		for _, e := range detected[name].Evidence {
+			log.Detail("  - %s", e)
+		}
+		fmt.Println()
+
+		if len(servers) == 0 {
+			log.Warn("No MCP servers found in the client's config.")
+			return
+		}
+		for _, id := range slices.Sorted(maps.Keys(servers)) {
+			server := servers[id]
+			target := server.URL
+			if target == "" {
+				target = strings.TrimSpace(server.Command + " " + strings.Join(server.Args, " "))
+			}
+			managed := "not in mcp.json"
+			if _, ok := mcpCfg.MCPServers[id]; ok {
+				managed = "managed"
+			}
+			status := ""
+			if server.Disabled {
+				status = ", disabled"
+			}
+			fmt.Printf("  %s: %s (%s%s)\n", id, target, managed, status)
+		}
+	},
+}
+
+// clientsAddCmd adds a client to config.yaml
+var clientsAddCmd = &cobra.Command{
+	Use:   "add <client>",
+	Short: "Add a client to config.yaml",
+	Long: `Adds a client entry to config.yaml. For known clients the format and, if the client
+is detected, the path default to the detected ones.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+		if existing, ok := cfg.Clients[name]; ok && existing.ConfigPath != "" {
+			log.Fatal("Client '%s' already exists in config.yaml; use 'clients edit' to change it", name)
+		}
+
+		clientConf := cfg.Clients[name]
+		detected, _ := util.DetectMCPClients()
+		if found, ok := detected[name]; ok {
+			clientConf.ConfigPath, clientConf.Type, clientConf.Key = found.ConfigPath, found.Type, found.Key
+		}
+		if def, ok := client.FindDefinition(name); ok && clientConf.Type == "" {
+			clientConf.Type, clientConf.Key = string(def.ConfigFormat), def.ConfigKey
+		}
+		clientConf = applyClientFlags(cmd, clientConf)
+
+		if clientConf.ConfigPath == "" {
+			log.Fatal("Client '%s' was not detected; pass --path", name)
+		}
+		if err := validateClientEntry(clientConf); err != nil {
+			log.Fatal("%v", err)
+		}
+
+		setClient(cfg, name, clientConf)
+		if err := config.SaveConfig(cfg); err != nil {
+			log.Fatal("Error saving config.yaml: %v", err)
+		}
+		log.Success("Added client '%s' (%s, %s)", name, clientConf.Type, clientConf.ConfigPath)
+	},
+}
+
+// clientsEditCmd changes a client entry in config.yaml
+var clientsEditCmd = &cobra.Command{
+	Use:   "edit <client>",
+	Short: "Change a client entry in config.yaml",
+	Long: `Changes the fields of a client entry given as flags. Editing a detected client that
+isn't in config.yaml yet creates an entry for it, e.g. to disable it:
+
+  mcpenetes clients edit cursor#26a77d --disabled`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+
+		clientConf, ok := cfg.Clients[name]
+		if !ok {
+			detected, _ := util.DetectMCPClients()
+			if _, ok := detected[name]; !ok {
+				log.Fatal("Client '%s' is not configured or detected", name)
+			}
+		}
+		clientConf = applyClientFlags(cmd, clientConf)
+		if err := validateClientEntry(clientConf); err != nil {
+			log.Fatal("%v", err)
+		}
+
+		setClient(cfg, name, clientConf)
+		if err := config.SaveConfig(cfg); err != nil {
+			log.Fatal("Error saving config.yaml: %v", err)
+		}
+		log.Success("Updated client '%s'", name)
+	},
+}
+
+// clientsRemoveCmd removes a client entry from config.yaml
+var clientsRemoveCmd = &cobra.Command{
+	Use:     "remove <client>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a client entry from config.yaml",
+	Long: `Removes a client entry from config.yaml. The client's own config file is left as it is.
+A removed client that is still installed is picked up again by detection while
+config.yaml lists no clients; use "clients edit <client> --disabled" to exclude it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[0]
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+		if _, ok := cfg.Clients[name]; !ok {
+			log.Fatal("Client '%s' not found in config.yaml", name)
+		}
+
+		delete(cfg.Clients, name)
+		if err := config.SaveConfig(cfg); err != nil {
+			log.Fatal("Error saving config.yaml: %v", err)
+		}
+		log.Success("Removed client '%s'", name)
+	},
+}
+
+// clientsSyncCmd saves detected clients to config.yaml
+var clientsSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Save newly detected clients to config.yaml",
+	Long: `Detects installed clients and adds those missing from config.yaml, so that apply
+uses a fixed list of clients. Disabled clients and existing entries are left as
+they are. Low-confidence detections are skipped unless --include-low-confidence
+is given.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		includeLow, _ := cmd.Flags().GetBool("include-low-confidence")
+
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config.yaml: %v", err)
+		}
+		detected, err := util.DetectMCPClients()
+		if err != nil {
+			log.Warn("Error detecting clients: %v", err)
+		}
+
+		added := 0
+		for _, name := range slices.Sorted(maps.Keys(detected)) {
+			found := detected[name]
+			existing, ok := cfg.Clients[name]
+			if ok && (existing.ConfigPath != "" || existing.Disabled) {
+				continue
+			}
+			if found.Confidence == string(client.ConfidenceLow) && !includeLow {
+				log.Detail("  Skipping %s (low confidence): %s", name, strings.Join(found.Evidence, "; "))
+				continue
+			}
+
+			// Keep settings of a toggle-only entry, such as a scope
+			existing.ConfigPath, existing.Type, existing.Key = found.ConfigPath, found.Type, found.Key
+			setClient(cfg, name, existing)
+			log.Info("  Added %s (%s)", name, found.ConfigPath)
+			added++
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(cfg.Clients)) {
+			if _, ok := detected[name]; !ok && cfg.Clients[name].ConfigPath != "" {
+				log.Detail("  %s is configured but no longer detected", name)
+			}
+		}
+
+		if added == 0 {
+			log.Success("config.yaml is up to date.")
+			return
+		}
+		if err := config.SaveConfig(cfg); err != nil {
+			log.Fatal("Error saving config.yaml: %v", err)
+		}
+		log.Success("Added %d client(s) to config.yaml.", added)
+	},
+}
+
+// applyClientFlags sets the fields of a client entry given as flags.
+func applyClientFlags(cmd *cobra.Command, clientConf config.Client) config.Client {
+	flags := cmd.Flags()
+	if flags.Changed("path") {
+		clientConf.ConfigPath, _ = flags.GetString("path")
+	}
+	if flags.Changed("format") {
+		clientConf.Type, _ = flags.GetString("format")
+	}
+	if flags.Changed("key") {
+		clientConf.Key, _ = flags.GetString("key")
+	}
+	if flags.Changed("scope") {
+		clientConf.Scope, _ = flags.GetString("scope")
+	}
+	if flags.Changed("project") {
+		clientConf.Project, _ = flags.GetString("project")
+	}
+	if flags.Changed("disabled") {
+		clientConf.Disabled, _ = flags.GetBool("disabled")
+	}
+	return clientConf
+}
+
+// validateClientEntry rejects formats and scopes the translator doesn't know.
+func validateClientEntry(clientConf config.Client) error {
+	if clientConf.Type != "" {
+		if err := client.ValidateFormat(client.ConfigFormatEnum(clientConf.Type)); err != nil {
+			return err
+		}
+	}
+	switch client.ScopeEnum(clientConf.Scope) {
+	case "", client.ScopeUser, client.ScopeProject, client.ScopeRepo:
+		return nil
+	}
+	return fmt.Errorf("invalid scope '%s': expected user, project or repo", clientConf.Scope)
+}
+
+// setClient stores a client entry, creating the clients map if needed.
+func setClient(cfg *config.Config, name string, clientConf config.Client) {
+	if cfg.Clients == nil {
+		cfg.Clients = make(map[string]config.Client)
+	}
+	cfg.Clients[name] = clientConf
+}
+
+// clientsValidateCmd checks a client definitions file
+var clientsValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate clients.yaml",
+	Long: `Checks a client definitions file (by default ~/.config/mcpetes/clients.yaml) and
+reports every problem with its line number: unknown fields, config formats or base
+directories, malformed paths and duplicate IDs.
+
+Entries whose ID matches a built-in client override only the fields they set, and
+"disabled: true" hides a built-in client.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var path string
+		if len(args) > 0 {
+			path = args[0]
+		} else {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatal("Error determining home directory: %v", err)
+			}
+			path = client.UserRegistryPath(homeDir)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal("Error reading %s: %v", path, err)
+		}
+
+		err = client.ValidateDefinitions(path, data)
+		var defErrs client.DefinitionErrors
+		if errors.As(err, &defErrs) {
+			for _, defErr := range defErrs {
+				fmt.Println(defErr)
+			}
+			log.Fatal("%d problem(s) found in %s", len(defErrs), path)
+		}
+		if err != nil {
+			log.Fatal("Error validating %s: %v", path, err)
+		}
+
+		log.Success("%s is valid", path)
+	},
+}
+
+// clientsUpdateCmd installs a newer client catalog
+var clientsUpdateCmd = &cobra.Command{
+	Use:   "update --from <file|url>",
+	Short: "Update the built-in client catalog",
+	Long: `Downloads (over https only) or reads a client catalog and installs it in the config
+directory (~/.config/mcpetes/catalog.yaml), so newly supported clients and corrected
+paths can be picked up without a new mcpenetes release.
+
+A catalog uses the clients.yaml schema under a "version" and a "clients" list, and is
+used in place of the built-in catalog while its version is newer. clients.yaml is
+still applied on top of it.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+
+		data, err := readCatalogSource(from)
+		if err != nil {
+			log.Fatal("Error reading catalog: %v", err)
+		}
+
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			log.Fatal("Error determining home directory: %v", err)
+		}
+
+		current, _ := client.ActiveCatalog()
+		defs, version, err := client.InstallCatalog(homeDir, from, data)
+		var defErrs client.DefinitionErrors
+		if errors.As(err, &defErrs) {
+			for _, defErr := range defErrs {
+				fmt.Println(defErr)
+			}
+			log.Fatal("%d problem(s) found in %s", len(defErrs), from)
+		}
+		if err != nil {
+			log.Fatal("Error updating catalog: %v", err)
+		}
+
+		log.Success("Updated client catalog from %s to %s (%d clients)", current, version, len(defs))
+		log.Detail("Saved to %s", client.CatalogPath(homeDir))
+	},
+}
+
+// readCatalogSource reads a catalog from an https URL or a local file. Plain HTTP is
+// refused: the catalog says which files mcpenetes writes servers to.
+func readCatalogSource(from string) ([]byte, error) {
+	if strings.HasPrefix(from, "http://") {
+		return nil, fmt.Errorf("refusing to download a catalog over plain HTTP; use an https URL, or download %s and pass the file", from)
+	}
+	if !strings.HasPrefix(from, "https://") {
+		path, err := util.ExpandPath(from)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(path)
+	}
+
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	resp, err := httpClient.Get(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", from, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: received status code %d", from, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func init() {
+	rootCmd.AddCommand(clientsCmd)
+	clientsCmd.AddCommand(clientsListCmd, clientsShowCmd, clientsAddCmd, clientsEditCmd, clientsRemoveCmd, clientsSyncCmd, clientsValidateCmd, clientsUpdateCmd)
+
+	for _, c := range []*cobra.Command{clientsAddCmd, clientsEditCmd} {
+		c.Flags().String("path", "", "Path of the client's config file (or directory, for directory formats)")
+		c.Flags().String("format", "", "Config format, e.g. simple-json, vscode-mcp, claude-code")
+		c.Flags().String("key", "", "JSON key to write servers under, overriding the format's default")
+		c.Flags().String("scope", "", "Claude Code scope: user, project or repo")
+		c.Flags().String("project", "", "Project directory for the project and repo scopes")
+		c.Flags().Bool("disabled", false, "Exclude the client from apply")
+	}
+	clientsSyncCmd.Flags().Bool("include-low-confidence", false, "Also add detected clients that may not be installed (only their config directory exists)")
+	clientsUpdateCmd.Flags().String("from", "", "Catalog file or https URL to install")
+	_ = clientsUpdateCmd.MarkFlagRequired("from")
+}
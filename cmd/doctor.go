@@ -13,11 +13,16 @@ import (
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check system health and prerequisites",
-	Long:  `Runs a series of checks to ensure mcpenetes and its dependencies are configured correctly.`,
+	Long: `Runs a series of checks to ensure mcpenetes and its dependencies are configured correctly.
+
+For each detected client it prints why the client was picked: its config file, an
+executable on PATH, a desktop entry or a running process. Clients found only by
+their config directory are skipped unless --include-low-confidence is given.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("Running system health checks...\n")
 
-		results := doctor.RunChecks()
+		includeLow, _ := cmd.Flags().GetBool("include-low-confidence")
+		results := doctor.RunChecks(includeLow)
 		hasError := false
 
 		for _, res := range results {
@@ -44,4 +49,5 @@ var doctorCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().Bool("include-low-confidence", false, "Also check detected clients that may not be installed (only their config directory exists)")
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+	"github.com/tuannvm/mcpenetes/internal/search"
+)
+
+// infoCmd shows what the registries know about a server
+var infoCmd = &cobra.Command{
+	Use:   "info <server-id>",
+	Short: "Show a server's registry details, including every tool it exposes",
+	Long: `Looks a server up by ID or name in the configured registries and prints its details:
+description, repository, license, attributes, environment variables and tools.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config: %v", err)
+		}
+
+		idx, err := search.LoadIndex()
+		if err != nil {
+			log.Fatal("Error loading the index: %v", err)
+		}
+
+		server := idx.Lookup(cfg.Registries, args[0])
+		if server == nil {
+			log.Fatal("Server '%s' was not found in any registry. Try 'mcpenetes registry sync'.", args[0])
+		}
+		printServerDetails(*server)
+	},
+}
+
+// printServerDetails prints a server's registry metadata.
+func printServerDetails(server registry.ServerData) {
+	log.Info("%s", server.Name)
+	if server.ID != "" && server.ID != server.Name {
+		log.Detail("ID: %s", server.ID)
+	}
+	if server.Description != "" {
+		log.Detail("%s", server.Description)
+	}
+	if server.RepositoryURL != "" {
+		log.Detail("Repository: %s", server.RepositoryURL)
+	}
+	if server.Version != "" {
+		log.Detail("Version: %s", server.Version)
+	}
+	if server.License != "" {
+		log.Detail("License: %s", server.License)
+	}
+	if server.RemoteCapable() {
+		log.Detail("Remote-capable: yes")
+	}
+	if len(server.Attributes) > 0 {
+		log.Detail("Attributes: %s", strings.Join(server.Attributes, ", "))
+	}
+
+	if vars := server.EnvSchema.Variables(); len(vars) > 0 {
+		log.Detail("Environment variables:")
+		for _, v := range vars {
+			line := v.Name
+			if v.Required {
+				line += " (required)"
+			}
+			if v.Description != "" {
+				line += ": " + v.Description
+			}
+			log.Detail("  - %s", line)
+		}
+	}
+
+	if len(server.Tools) == 0 {
+		log.Detail("Tools: none listed by the registry")
+	} else {
+		log.Detail("Tools (%d):", len(server.Tools))
+	}
+	for _, tool := range server.Tools {
+		if tool.Description != "" {
+			log.Detail("  - %s: %s", tool.Name, firstLine(tool.Description))
+		} else {
+			log.Detail("  - %s", tool.Name)
+		}
+	}
+	fmt.Println()
+}
+
+// firstLine shortens a multi-line description to its first line.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+	"github.com/tuannvm/mcpenetes/internal/search"
+)
+
+// registryCmd groups the commands working on the configured registries
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Work with the configured MCP registries",
+	Long: `Work with the configured MCP registries. A registry is an HTTP API, or a local catalog:
+a file:// URL or path to a single index file, or to a directory of server.json manifests.`,
+}
+
+// registrySyncCmd copies the registries' catalogs into the local index
+var registrySyncCmd = &cobra.Command{
+	Use:   "sync [registry-name...]",
+	Short: "Sync the registries' catalogs into the local search index",
+	Long: `Copies the catalogs of the configured registries (or only the named ones) into a local
+index, ~/.config/mcpetes/index.json. Once a registry is synced, 'mcpenetes search', 'info' and
+the UI read it from the index, instantly and without network access.
+
+Registries that can list the servers changed since a time, like the official MCP Registry,
+are synced incrementally after the first sync; the others are listed in full each time.
+An interrupted sync resumes where it stopped. --full lists every registry in full.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		full, _ := cmd.Flags().GetBool("full")
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config: %v", err)
+		}
+		idx, err := search.LoadIndex()
+		if err != nil {
+			log.Fatal("Error loading the index: %v", err)
+		}
+
+		for _, name := range args {
+			if !slices.ContainsFunc(cfg.Registries, func(reg config.Registry) bool { return reg.Name == name }) {
+				log.Fatal("Registry '%s' is not configured", name)
+			}
+		}
+		if len(args) == 0 {
+			idx.Prune(cfg.Registries)
+		}
+
+		failed := 0
+		for _, reg := range cfg.Registries {
+			if len(args) > 0 && !slices.Contains(args, reg.Name) {
+				continue
+			}
+			log.Info("Syncing %s (%s)...", reg.Name, reg.URL)
+			stats, err := idx.Sync(reg, full)
+			// Saved either way: a failed sync keeps its progress to resume from
+			if saveErr := idx.Save(); saveErr != nil {
+				log.Fatal("Error saving the index: %v", saveErr)
+			}
+			if err != nil {
+				log.Error("Failed to sync %s: %v", reg.Name, err)
+				failed++
+				continue
+			}
+
+			kind := "full listing"
+			if stats.Incremental {
+				kind = "changes since the last sync"
+			}
+			if stats.Resumed {
+				kind += ", resumed"
+			}
+			log.Success("Synced %s: %d added, %d updated, %d removed (%s, %d page(s))",
+				reg.Name, stats.Added, stats.Updated, stats.Removed, kind, stats.Pages)
+		}
+
+		if failed > 0 {
+			log.Fatal("%d registry sync(s) failed; run 'mcpenetes registry sync' again to resume", failed)
+		}
+		log.Detail("Index: %s", idx.Path())
+	},
+}
+
+// registryLintCmd checks registry catalogs, typically a local one before it is shared
+var registryLintCmd = &cobra.Command{
+	Use:   "lint [registry-name|path|url...]",
+	Short: "Check registry catalogs for servers that can't be searched or installed",
+	Long: `Reads every page of the named registries, or of the given paths and URLs, and checks each
+server the way search and install use it: it needs a unique ID, its packages and remotes
+have to build a command, and so on. Without arguments, every configured registry is checked.
+
+Registries can be local: a file:// URL or a path (absolute, or starting with ~/, ./ or ../)
+to a single index file in any registry format, or to a directory of server.json manifests.
+Manifests that can't be read are reported by file. Warnings don't fail the lint.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			log.Fatal("Error loading config: %v", err)
+		}
+
+		registries := cfg.Registries
+		if len(args) > 0 {
+			registries = nil
+			for _, arg := range args {
+				i := slices.IndexFunc(cfg.Registries, func(reg config.Registry) bool { return reg.Name == arg })
+				if i >= 0 {
+					registries = append(registries, cfg.Registries[i])
+				} else {
+					registries = append(registries, config.Registry{Name: arg, URL: arg})
+				}
+			}
+		}
+		if len(registries) == 0 {
+			log.Warn("No registries configured. Pass a registry path or URL to lint.")
+			return
+		}
+
+		errors, warnings := 0, 0
+		for _, reg := range registries {
+			log.Info("Linting %s...", reg.URL)
+			problems, err := registry.Lint(reg)
+			if err != nil {
+				log.Error("Failed to read %s: %v", reg.URL, err)
+				errors++
+				continue
+			}
+			for _, p := range problems {
+				if p.Warning {
+					log.Warn("%s", p)
+					warnings++
+				} else {
+					log.Error("%s", p)
+					errors++
+				}
+			}
+		}
+
+		if errors > 0 {
+			log.Fatal("%d error(s), %d warning(s)", errors, warnings)
+		}
+		log.Success("No errors, %d warning(s)", warnings)
+	},
+}
+
+// registryServeCmd serves a curated catalog to other mcpenetes users
+var registryServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve mcp.json files as a registry other mcpenetes installs can search",
+	Long: `Serves the servers of one or more mcp.json files (by default your own) as a registry, in
+the official MCP Registry API's format: add http://<addr>/v0/servers to the registries in
+config.yaml. Installing a server from it writes the exact command, args and env of the
+mcp.json entry. Listings are paged, carry ETags, and support 'registry sync' incrementally.
+
+Metadata files (YAML or JSON) describe the servers by their mcp.json key:
+
+  servers:
+    github:
+      description: GitHub issues and pull requests
+      repository: https://github.com/github/github-mcp-server
+      license: MIT
+      tools: [{name: create_issue, description: Open an issue}]
+      env:
+        GITHUB_TOKEN: {description: A personal access token, required: true, secret: true}
+
+Env values that are secret, by their name, a ${secret:...} reference or the metadata, are
+never served; installers are asked for them instead. The files are read again when they
+change. Disabled servers are left out. With --sign-key, every response is signed (see
+'mcpenetes registry sign').`,
+	Run: func(cmd *cobra.Command, args []string) {
+		addr, _ := cmd.Flags().GetString("addr")
+		mcpFiles, _ := cmd.Flags().GetStringArray("mcp")
+		metadataFiles, _ := cmd.Flags().GetStringArray("metadata")
+		var err error
+		if len(mcpFiles) == 0 {
+			path, err := config.MCPConfigPath()
+			if err != nil {
+				log.Fatal("Error finding mcp.json: %v", err)
+			}
+			mcpFiles = []string{path}
+		}
+
+		catalog := &registry.Catalog{MCPFiles: mcpFiles, MetadataFiles: metadataFiles}
+		if keyFile, _ := cmd.Flags().GetString("sign-key"); keyFile != "" {
+			if catalog.SigningKey, err = readPrivateKey(keyFile); err != nil {
+				log.Fatal("Error reading the signing key: %v", err)
+			}
+		}
+		servers, _, err := catalog.Servers()
+		if err != nil {
+			log.Fatal("Error reading the catalog: %v", err)
+		}
+
+		log.Success("Serving %d server(s) at http://%s/v0/servers", len(servers), addr)
+		for _, path := range append(mcpFiles, metadataFiles...) {
+			log.Detail("  from %s", path)
+		}
+		if err := http.ListenAndServe(addr, catalog.Handler()); err != nil {
+			log.Fatal("Server error: %v", err)
+		}
+	},
+}
+
+// registrySignCmd signs registry payloads for registries that require signatures
+var registrySignCmd = &cobra.Command{
+	Use:   "sign --key <private-key-file> <file|directory>...",
+	Short: "Sign registry files with an ed25519 key, or generate a key",
+	Long: `Signs registry files for publishers: each file gets a detached ed25519 signature next to
+it, named like it with .sig (index.json.sig). For a directory, every .json manifest in it is
+signed. Publish the .sig files with the files; 'registry serve --sign-key' signs its
+responses itself, in the ` + registry.SignatureHeader + ` header.
+
+Generate a key pair with --generate-key <private-key-file>. Keep the private key secret;
+the public key it prints goes in the registry's entry in config.yaml:
+
+  registries:
+    - name: team
+      url: https://mcp.example.com/index.json
+      public_keys: [<public key>]
+      require_signature: true
+
+A payload with a bad signature is always rejected; require_signature rejects unsigned ones too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if keyFile, _ := cmd.Flags().GetString("generate-key"); keyFile != "" {
+			pub, priv, err := registry.GenerateKey()
+			if err != nil {
+				log.Fatal("Error generating a key: %v", err)
+			}
+			// O_EXCL: never overwrite a key that may already be in use
+			f, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				log.Fatal("Error writing the private key: %v", err)
+			}
+			_, err = fmt.Fprintln(f, priv)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				log.Fatal("Error writing the private key: %v", err)
+			}
+			log.Success("Wrote the private key to %s", keyFile)
+			log.Info("Public key, for the registry's public_keys: %s", pub)
+			return
+		}
+
+		keyFile, _ := cmd.Flags().GetString("key")
+		if keyFile == "" || len(args) == 0 {
+			log.Fatal("Pass --key <private-key-file> and the files to sign, or --generate-key <private-key-file>")
+		}
+		key, err := readPrivateKey(keyFile)
+		if err != nil {
+			log.Fatal("Error reading the private key: %v", err)
+		}
+
+		signed := 0
+		for _, arg := range args {
+			files, err := filesToSign(arg)
+			if err != nil {
+				log.Fatal("Error reading %s: %v", arg, err)
+			}
+			for _, file := range files {
+				payload, err := os.ReadFile(file)
+				if err != nil {
+					log.Fatal("Error reading %s: %v", file, err)
+				}
+				if err := os.WriteFile(file+registry.SignatureExt, []byte(registry.Sign(key, payload)+"\n"), 0644); err != nil {
+					log.Fatal("Error writing the signature of %s: %v", file, err)
+				}
+				log.Detail("Signed %s", file)
+				signed++
+			}
+		}
+		log.Success("Signed %d file(s) with the key whose public key is %s", signed,
+			base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)))
+	},
+}
+
+// readPrivateKey reads a key file written by 'registry sign --generate-key'.
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return registry.ParsePrivateKey(string(data))
+}
+
+// filesToSign returns path itself, or the .json files below it if it is a directory.
+func filesToSign(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(p) == ".json" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// printIndexStatus says which registries are searched from the index, and how old their copy is.
+func printIndexStatus(idx *search.Index, registries []config.Registry) {
+	for _, reg := range registries {
+		if state := idx.Synced(reg); state != nil {
+			log.Detail("  %s: local index, synced %s ago", reg.Name, since(state.SyncedAt))
+		}
+	}
+}
+
+// since formats the time elapsed since t, to the minute.
+func since(t time.Time) string {
+	d := time.Since(t).Round(time.Minute)
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return d.String()[:len(d.String())-2]
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registrySyncCmd)
+	registryCmd.AddCommand(registryLintCmd)
+	registryCmd.AddCommand(registryServeCmd)
+	registryCmd.AddCommand(registrySignCmd)
+
+	registrySyncCmd.Flags().Bool("full", false, "List every registry in full instead of only what changed")
+	registryServeCmd.Flags().String("addr", "127.0.0.1:8765", "Address to listen on; use :8765 to serve other machines")
+	registryServeCmd.Flags().StringArray("mcp", nil, "mcp.json file to serve (repeatable; default: your own mcp.json)")
+	registryServeCmd.Flags().StringArray("metadata", nil, "Metadata file describing the servers, YAML or JSON (repeatable)")
+	registryServeCmd.Flags().String("sign-key", "", "Private key file to sign every response with (see 'registry sign')")
+	registrySignCmd.Flags().String("key", "", "Private key file written by --generate-key")
+	registrySignCmd.Flags().String("generate-key", "", "Generate a key pair, writing the private key to this file")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/tuannvm/mcpenetes/internal/config"
 	"github.com/tuannvm/mcpenetes/internal/core"
 	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/util"
 )
 
 // restoreCmd represents the restore command
@@ -31,6 +32,12 @@ from the most recent backup found in the backup directory specified in config.ya
 			log.Fatal("Error loading mcp.json: %v", err)
 		}
 
+		resolvedClients, _, err := util.ResolveClients(cfg, true)
+		if err != nil {
+			log.Warn("Error detecting clients: %v", err)
+		}
+		cfg.Clients = resolvedClients
+
 		manager := core.NewManager(cfg, mcpCfg)
 
 		// 2. Perform restore
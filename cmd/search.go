@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -16,25 +18,30 @@ import (
 	"github.com/tuannvm/mcpenetes/internal/search"
 )
 
-// ServerInfo represents information about an MCP server
-type ServerInfo struct {
-	Name          string
-	Description   string
-	RepositoryURL string
-}
-
 // searchCmd represents the search command
 var searchCmd = &cobra.Command{
 	Use:   "search [server-id]",
 	Short: "Interactive fuzzy search for MCP versions and apply them",
 	Long: `Provides an interactive fuzzy search interface to find and select MCP versions from configured registries.
+Servers are ranked by how well their name, tool names and description match the query (--query,
+or asked for), tolerating typos. A server listed by several registries with the same repository
+is shown once, with the registries listing it. --limit caps the number of results.
 If a server ID is provided as an argument, it will directly use that server without prompting.
-After selection, the server is added to the local mcp.json configuration file.
-Note: Since the registry may not provide specific execution commands, a default 'npx' configuration is added.
-You should review 'mcp.json' to ensure the command and arguments are correct.
+After selection, the server is added to the local mcp.json configuration file, with a command
+chosen from the registry's packages and the server's repository.
+
+If the registry describes the environment variables the server reads, you are prompted for
+them (or pass them with --env KEY=VALUE). Secret-looking values, such as tokens and API keys,
+are saved to the OS keychain, or a file only you can read, and mcp.json references them.
+
+Results can be narrowed by the metadata registries provide with --tool, --license,
+--remote-capable and --attribute. The selected server's details, including its tools,
+are shown before it is added; 'mcpenetes info <server-id>' shows them on their own.
 
 By default, search results are cached to improve performance. Use the --refresh flag to force a refresh
-of the cache and fetch the latest data from the registries.`,
+of the cache and fetch the latest data from the registries. Registries synced with
+'mcpenetes registry sync' are searched in the local index instead, offline; --refresh
+fetches them too.`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// Allow 0 or 1 argument
 		if len(args) > 1 {
@@ -48,10 +55,22 @@ of the cache and fetch the latest data from the registries.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Get the refresh flag value
 		forceRefresh, _ := cmd.Flags().GetBool("refresh")
+		filter := searchFilter(cmd)
+		query, _ := cmd.Flags().GetString("query")
+		limit, _ := cmd.Flags().GetInt("limit")
+		envFlags, _ := cmd.Flags().GetStringArray("env")
+		givenEnv, err := parseEnvFlags(envFlags)
+		if err != nil {
+			log.Fatal("Invalid --env: %v", err)
+		}
 		cfg, err := config.LoadConfig()
 		if err != nil {
 			log.Fatal("Error loading config: %v", err)
 		}
+		idx, err := search.LoadIndex()
+		if err != nil {
+			log.Fatal("Error loading the index: %v", err)
+		}
 
 		var serverID string
 		var selectedServer *registry.ServerData
@@ -60,8 +79,8 @@ of the cache and fetch the latest data from the registries.`,
 		if len(args) == 1 {
 			serverID = args[0]
 			log.Info("Using provided server ID: %s", serverID)
-			// We don't have server data if passed directly, unless we fetch?
-			// For simplicity, we just use the ID.
+			// The registry entry, if any, says how to run it and which env variables it reads
+			selectedServer = idx.Lookup(cfg.Registries, serverID)
 		} else {
 			// Interactive selection mode
 			log.Info("Starting interactive search...")
@@ -71,72 +90,68 @@ of the cache and fetch the latest data from the registries.`,
 				return
 			}
 
+			if query == "" {
+				prompt := &survey.Input{Message: "Search for (leave empty to list every server):"}
+				if err := survey.AskOne(prompt, &query); err != nil {
+					log.Fatal("Error reading the query: %v", err)
+				}
+			}
+
+			if !forceRefresh {
+				printIndexStatus(idx, cfg.Registries)
+			}
+
 			// Start spinner
 			s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 			s.Suffix = " Fetching available MCPs..."
 			s.Start()
 
-			var serverInfos []ServerInfo
-			var displayOptions []string
-			serverMap := make(map[string]registry.ServerData)
+			// Synced registries are searched in the local index, the others fetched
+			results, errs := idx.Find(cfg.Registries, query, filter, limit, forceRefresh)
 
-			for _, reg := range cfg.Registries {
-				servers, err := registry.FetchMCPServersWithCache(reg.URL, forceRefresh)
-				if err != nil {
-					log.Warn("Error fetching from registry %s: %v", reg.URL, err)
-					continue
-				}
+			s.Stop()
+			for _, err := range errs {
+				log.Warn("Error fetching from %v", err)
+			}
 
-				for _, server := range servers {
-					info := ServerInfo{
-						Name:          server.Name,
-						Description:   server.Description,
-						RepositoryURL: server.RepositoryURL,
-					}
-					serverInfos = append(serverInfos, info)
-
-					// Store original server data for later use
-					serverMap[server.Name] = server
-
-					// Create display option string
-					displayText := server.Name
-					if server.Description != "" {
-						displayText = fmt.Sprintf("%s: %s", server.Name, server.Description)
-					}
-					displayOptions = append(displayOptions, displayText)
+			if len(results) == 0 {
+				switch {
+				case query != "":
+					log.Warn("No MCP servers match '%s'", query)
+				case !filter.Empty():
+					log.Warn("No MCP servers match the filters")
+				default:
+					log.Warn("No MCP servers found in any registry")
 				}
+				return
 			}
 
-			s.Stop()
-
-			if len(serverInfos) == 0 {
-				log.Warn("No MCP servers found in any registry")
-				return
+			displayOptions := make([]string, len(results))
+			for i, result := range results {
+				displayText := result.Server.Name
+				if result.Server.Description != "" {
+					displayText = fmt.Sprintf("%s: %s", result.Server.Name, result.Server.Description)
+				}
+				if len(result.Registries) > 1 {
+					displayText += fmt.Sprintf(" [%s]", strings.Join(result.Registries, ", "))
+				}
+				displayOptions[i] = displayText
 			}
 
-			var selectedOption string
+			var selected int
 			prompt := &survey.Select{
-				Message: "Select MCP server:",
+				Message: fmt.Sprintf("Select MCP server (%d found):", len(results)),
 				Options: displayOptions,
 			}
 
-			err = survey.AskOne(prompt, &selectedOption)
+			err = survey.AskOne(prompt, &selected)
 			if err != nil {
 				log.Fatal("Error during selection: %v", err)
 				return
 			}
 
-			// Find the index/ID
-			for i, opt := range displayOptions {
-				if opt == selectedOption {
-					serverID = serverInfos[i].Name
-					// Get full data
-					if data, ok := serverMap[serverID]; ok {
-						selectedServer = &data
-					}
-					break
-				}
-			}
+			selectedServer = &results[selected].Server
+			serverID = selectedServer.Name
 		}
 
 		if serverID == "" {
@@ -144,6 +159,9 @@ of the cache and fetch the latest data from the registries.`,
 		}
 
 		log.Info("Selected MCP: %s", serverID)
+		if selectedServer != nil {
+			printServerDetails(*selectedServer)
+		}
 
 		// Ask to open repo if available
 		if selectedServer != nil && selectedServer.RepositoryURL != "" {
@@ -166,17 +184,131 @@ of the cache and fetch the latest data from the registries.`,
 
 		// Add to mcp.json (actual configuration)
 		log.Info("Adding configuration for %s to mcp.json...", serverID)
-		err = search.AddServerToMCPConfig(serverID, selectedServer, nil) // Pass nil for config override
+		var schema *registry.EnvSchema
+		if selectedServer != nil {
+			schema = selectedServer.EnvSchema
+		}
+		env, err := promptEnv(schema, givenEnv)
+		if err != nil {
+			log.Fatal("Error reading environment variables: %v", err)
+		}
+
+		result, err := search.AddServerToMCPConfig(serverID, selectedServer, nil, env) // Pass nil for config override
 		if err != nil {
 			log.Error("Failed to add to mcp.json: %v", err)
 		} else {
 			log.Success("Successfully added %s to mcp.json.", serverID)
-			log.Info("Note: A default 'npx' command was configured. Please check 'mcp.json' if you need to adjust arguments or environment variables.")
+			printResolution(result.Resolution)
+			for key, store := range result.Secrets {
+				log.Detail("%s was saved to the %s; mcp.json only references it", key, store)
+			}
+			if result.Resolution.Guessed {
+				log.Warn("Nothing ties this command to the server, so it is a guess. Check 'mcp.json' before running 'apply'.")
+			}
 			log.Info("Run 'mcpenetes apply' to install this server to your clients.")
 		}
 	},
 }
 
+// searchFilter builds the metadata filter from the command's flags.
+func searchFilter(cmd *cobra.Command) registry.Filter {
+	var filter registry.Filter
+	filter.Tools, _ = cmd.Flags().GetStringArray("tool")
+	filter.License, _ = cmd.Flags().GetString("license")
+	filter.RemoteCapable, _ = cmd.Flags().GetBool("remote-capable")
+	filter.Attributes, _ = cmd.Flags().GetStringArray("attribute")
+	return filter
+}
+
+// parseEnvFlags reads KEY=VALUE pairs given with --env.
+func parseEnvFlags(flags []string) (map[string]string, error) {
+	env := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%q is not KEY=VALUE", flag)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// promptEnv asks for the environment variables in the registry's schema that weren't
+// given with --env, checking each answer against the schema. Secrets are read without echo.
+func promptEnv(schema *registry.EnvSchema, given map[string]string) (map[string]string, error) {
+	env := make(map[string]string, len(given))
+	for key, value := range given {
+		env[key] = value
+	}
+
+	vars := schema.Variables()
+	if len(vars) > 0 {
+		log.Info("This server reads %d environment variable(s):", len(vars))
+	}
+	for _, v := range vars {
+		if value, ok := given[v.Name]; ok {
+			if err := v.Validate(value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		message := v.Name
+		if !v.Required {
+			message += " (optional)"
+		}
+		var prompt survey.Prompt
+		switch {
+		case v.Secret:
+			prompt = &survey.Password{Message: message + ":", Help: v.Description}
+		case len(v.Enum) > 0:
+			options := v.Enum
+			if !v.Required {
+				options = append([]string{""}, options...)
+			}
+			sel := &survey.Select{Message: message + ":", Options: options, Help: v.Description}
+			// Select fails on a default that isn't one of its options
+			if v.Default != "" && slices.Contains(options, v.Default) {
+				sel.Default = v.Default
+			}
+			prompt = sel
+		default:
+			prompt = &survey.Input{Message: message + ":", Default: v.Default, Help: v.Description}
+		}
+		if v.Description != "" {
+			log.Detail("  %s: %s", v.Name, v.Description)
+		}
+
+		var value string
+		validate := func(ans interface{}) error {
+			if option, ok := ans.(survey.OptionAnswer); ok {
+				return v.Validate(option.Value)
+			}
+			return v.Validate(fmt.Sprint(ans))
+		}
+		if err := survey.AskOne(prompt, &value, survey.WithValidator(validate)); err != nil {
+			return nil, err
+		}
+		env[v.Name] = value
+	}
+	return env, nil
+}
+
+// printResolution explains which command was configured and why.
+func printResolution(res *registry.Resolution) {
+	if res.Runner == registry.RunnerURL {
+		log.Info("Connects to %s", res.Server.URL)
+	} else {
+		log.Info("Runs with %s: %s", res.Runner, strings.Join(append([]string{res.Server.Command}, res.Server.Args...), " "))
+	}
+	if res.Version != "" {
+		log.Detail("Version: %s", res.Version)
+	}
+	for _, reason := range res.Reasons {
+		log.Detail("  - %s", reason)
+	}
+}
+
 // openBrowser opens the specified URL in the default browser
 func openBrowser(url string) error {
 	var err error
@@ -200,4 +332,11 @@ func init() {
 	
 	// Add a flag to force cache refresh
 	searchCmd.Flags().BoolP("refresh", "r", false, "Force a refresh of the cache and fetch the latest data from registries")
+	searchCmd.Flags().StringP("query", "q", "", "Rank servers by how well their name, tools and description match this (typos are tolerated)")
+	searchCmd.Flags().IntP("limit", "l", 50, "Show at most this many results (0 for all)")
+	searchCmd.Flags().StringArray("tool", nil, "Only list servers exposing a tool whose name contains this (repeatable)")
+	searchCmd.Flags().String("license", "", "Only list servers under this license (SPDX identifier, e.g. MIT)")
+	searchCmd.Flags().Bool("remote-capable", false, "Only list servers that can be used remotely")
+	searchCmd.Flags().StringArray("attribute", nil, "Only list servers with this registry attribute, e.g. hosting:remote-capable (repeatable)")
+	searchCmd.Flags().StringArray("env", nil, "Set an environment variable of the server (KEY=VALUE, repeatable) instead of being prompted for it")
 }
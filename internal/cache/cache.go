@@ -41,13 +41,18 @@ func init() {
 }
 
 // getCachePath generates a unique and safe file path for a given registry URL.
+// Local registries, given as file:// URLs or absolute paths, are accepted too.
 // It's defined as a variable to allow mocking in tests.
 var getCachePath = func(registryURL string) (string, error) {
 	// Basic URL validation
 	parsedURL, err := url.Parse(registryURL)
-	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+	if err != nil {
 		return "", fmt.Errorf("invalid registry URL for cache key: %w", err)
 	}
+	local := parsedURL.Scheme == "file" || filepath.IsAbs(registryURL)
+	if !local && (parsedURL.Scheme == "" || parsedURL.Host == "") {
+		return "", fmt.Errorf("invalid registry URL for cache key: %q has no scheme and host", registryURL)
+	}
 
 	// Create a safe filename from the URL (e.g., hash or escape)
 	// Using a hash of the URL ensures it's unique and avoids filesystem issues
@@ -43,6 +43,18 @@ func TestGetCachePath(t *testing.T) {
 			wantErr:        false,
 			expectedBaseFn: expectedCacheFilename("https://example.com/api?version=latest"), // Use helper
 		},
+		{
+			name:           "File URL",
+			registryURL:    "file:///srv/catalog/index.json",
+			wantErr:        false,
+			expectedBaseFn: expectedCacheFilename("file:///srv/catalog/index.json"),
+		},
+		{
+			name:           "Absolute path",
+			registryURL:    "/srv/catalog/servers",
+			wantErr:        false,
+			expectedBaseFn: expectedCacheFilename("/srv/catalog/servers"),
+		},
 		{
 			name:           "Invalid URL format (no scheme/host)", // Adjusted description
 			registryURL:    "nodomain",                            // Changed to a more realistic invalid format case
@@ -391,3 +403,23 @@ func TestWriteCache(t *testing.T) {
 	}
 	// Final cleanup is handled by the defer at the start of TestWriteCache
 }
+
+// Test that a server cache entry is only read back under the trust settings it was written with
+func TestServerCacheTrust(t *testing.T) {
+	originalGetCachePath := getCachePath
+	defer func() { getCachePath = originalGetCachePath }()
+	path := filepath.Join(t.TempDir(), "servers.json")
+	getCachePath = func(string) (string, error) { return path, nil }
+
+	servers := []ServerInfo{{ID: "acme/files", Name: "acme/files"}}
+	if err := WriteServerCache("https://example.com/servers", "", servers); err != nil {
+		t.Fatalf("WriteServerCache failed: %v", err)
+	}
+	if got, miss, err := ReadServerCache("https://example.com/servers", ""); err != nil || miss || !reflect.DeepEqual(got, servers) {
+		t.Errorf("ReadServerCache = %v, miss %v, %v", got, miss, err)
+	}
+	// Cached before the registry was given keys
+	if _, miss, err := ReadServerCache("https://example.com/servers", "keys"); err != nil || !miss {
+		t.Errorf("ReadServerCache with other trust settings: miss %v, %v", miss, err)
+	}
+}
@@ -9,20 +9,26 @@ import (
 
 // ServerInfo represents information about an MCP server to be cached
 type ServerInfo struct {
+	ID            string `json:"id,omitempty"`
 	Name          string `json:"name"`
 	Description   string `json:"description"`
 	RepositoryURL string `json:"repositoryUrl"`
+	// Metadata holds registry-specific details (e.g. packages to install from), stored as-is
+	Metadata json.RawMessage `json:"metadata,omitempty"`
 }
 
 // ServerCacheEntry represents the structure of server data stored in a cache file
 type ServerCacheEntry struct {
-	Timestamp time.Time    `json:"timestamp"`
-	Servers   []ServerInfo `json:"servers"`
+	Timestamp time.Time `json:"timestamp"`
+	// Trust identifies the registry's signature settings the servers were checked under
+	Trust   string       `json:"trust,omitempty"`
+	Servers []ServerInfo `json:"servers"`
 }
 
 // ReadServerCache reads the cached server information for a registry URL if the cache is valid.
+// An entry written under other trust settings (see WriteServerCache) is a miss.
 // Returns the list of servers, a boolean indicating if it was a cache miss (or expired/invalid), and any error encountered.
-func ReadServerCache(registryURL string) (servers []ServerInfo, cacheMiss bool, err error) {
+func ReadServerCache(registryURL, trust string) (servers []ServerInfo, cacheMiss bool, err error) {
 	cachePath, err := getCachePath(registryURL + "-servers") // Append suffix to differentiate from version cache
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to get server cache path: %w", err)
@@ -47,11 +53,17 @@ func ReadServerCache(registryURL string) (servers []ServerInfo, cacheMiss bool,
 		return nil, true, nil // Cache expired, treat as miss
 	}
 
+	// Fetched before the registry's keys changed, so not checked against them
+	if entry.Trust != trust {
+		return nil, true, nil
+	}
+
 	return entry.Servers, false, nil // Cache hit and valid
 }
 
 // WriteServerCache writes the fetched server information to the cache file for a registry URL.
-func WriteServerCache(registryURL string, servers []ServerInfo) error {
+// trust identifies the registry's signature settings the servers were checked under.
+func WriteServerCache(registryURL, trust string, servers []ServerInfo) error {
 	cachePath, err := getCachePath(registryURL + "-servers") // Append suffix to differentiate
 	if err != nil {
 		return fmt.Errorf("failed to get server cache path for writing: %w", err)
@@ -59,6 +71,7 @@ func WriteServerCache(registryURL string, servers []ServerInfo) error {
 
 	entry := ServerCacheEntry{
 		Timestamp: time.Now(),
+		Trust:     trust,
 		Servers:   servers,
 	}
 
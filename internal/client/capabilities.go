@@ -0,0 +1,59 @@
+package client
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Capabilities describes which server settings a client understands and what it calls them.
+// The translator drops settings a client can't represent and reports them as warnings.
+type Capabilities struct {
+	URL         bool `yaml:"url" json:"url"`                   // Remote (SSE/HTTP) servers
+	Env         bool `yaml:"env" json:"env"`                   // Per-server environment variables
+	Disabled    bool `yaml:"disabled" json:"disabled"`         // "disabled" flag
+	AutoApprove bool `yaml:"auto_approve" json:"auto_approve"` // Tools that run without confirmation
+	ToolFilter  bool `yaml:"tool_filter" json:"tool_filter"`   // Tools to deny or hide, written as "disabledTools"
+	// ServerNameChars is a regexp character class of the characters allowed in server names
+	// (e.g. "a-zA-Z0-9_-"). Empty means any name is accepted.
+	ServerNameChars string `yaml:"server_name_chars,omitempty" json:"server_name_chars,omitempty"`
+	// MaxTools is the number of tools the client exposes across all servers. 0 means no limit.
+	MaxTools int `yaml:"max_tools,omitempty" json:"max_tools,omitempty"`
+	// Fields renames mcp.json keys to the client's own (e.g. "autoApprove": "alwaysAllow").
+	Fields map[string]string `yaml:"fields,omitempty" json:"fields,omitempty"`
+}
+
+// DefaultCapabilities is used for clients that don't declare their own: every setting is
+// written as-is, matching the standard mcpServers format.
+func DefaultCapabilities() Capabilities {
+	return Capabilities{
+		URL:         true,
+		Env:         true,
+		Disabled:    true,
+		AutoApprove: true,
+		ToolFilter:  true,
+	}
+}
+
+// UnmarshalYAML starts from DefaultCapabilities, so clients.yaml only needs to list
+// the settings that differ.
+func (c *Capabilities) UnmarshalYAML(value *yaml.Node) error {
+	type plain Capabilities
+	*c = DefaultCapabilities()
+	return value.Decode((*plain)(c))
+}
+
+// FieldName returns the client's name for an mcp.json key.
+func (c Capabilities) FieldName(key string) string {
+	if name, ok := c.Fields[key]; ok && name != "" {
+		return name
+	}
+	return key
+}
+
+// LookupCapabilities returns the capabilities of a client by target ID. Profile and install
+// targets (e.g. "vscode:work", "cursor#26a77d") resolve to their base client. Unknown clients get defaults.
+func LookupCapabilities(clientID string) Capabilities {
+	if def, ok := FindDefinition(clientID); ok && def.Capabilities != nil {
+		return *def.Capabilities
+	}
+	return DefaultCapabilities()
+}
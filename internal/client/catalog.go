@@ -0,0 +1,149 @@
+package client
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CatalogFile is the name of the updated client catalog in the config directory
+const CatalogFile = "catalog.yaml"
+
+const (
+	builtinCatalogSource = "built-in catalog"
+	updatedCatalogSource = "updated catalog"
+)
+
+//go:embed catalog.yaml
+var builtinCatalog []byte
+
+// Registry holds the built-in client definitions, loaded from the embedded catalog.yaml,
+// and RegistryVersion is that catalog's version.
+var Registry, RegistryVersion = mustParseBuiltinCatalog()
+
+func mustParseBuiltinCatalog() ([]ClientDefinition, string) {
+	defs, version, err := ParseCatalog(builtinCatalogSource, builtinCatalog)
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in client catalog: %v", err))
+	}
+	return defs, version
+}
+
+// CatalogPath returns where "mcpenetes clients update" stores a newer catalog.
+func CatalogPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "mcpetes", CatalogFile)
+}
+
+// ParseCatalog parses a complete client catalog: a mapping with a "version" and a
+// "clients" list in which every client sets its configformat. It returns DefinitionErrors
+// listing every problem.
+func ParseCatalog(file string, data []byte) ([]ClientDefinition, string, error) {
+	source := updatedCatalogSource
+	if file == builtinCatalogSource {
+		source = builtinCatalogSource
+	}
+	defs, version, err := parseDefinitions(file, source, data, nil)
+	if err == nil && version == "" {
+		err = DefinitionErrors{{File: file, Message: "catalog has no version"}}
+	}
+	return defs, version, err
+}
+
+// ActiveCatalog returns the version of the catalog detection uses and the file it was
+// read from, or "" for the built-in catalog.
+func ActiveCatalog() (version, path string) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return RegistryVersion, ""
+	}
+	_, version, updated := loadCatalog(homeDir)
+	if updated {
+		return version, CatalogPath(homeDir)
+	}
+	return version, ""
+}
+
+// loadCatalog returns the updated catalog from the config directory if it is valid and
+// newer than the built-in one, which a later release may have overtaken. Otherwise it
+// returns the built-in definitions and updated is false.
+func loadCatalog(homeDir string) (defs []ClientDefinition, version string, updated bool) {
+	if data, err := os.ReadFile(CatalogPath(homeDir)); err == nil {
+		defs, version, err := ParseCatalog(CatalogPath(homeDir), data)
+		if err == nil && CompareVersions(version, RegistryVersion) > 0 {
+			return defs, version, true
+		}
+	}
+	return append([]ClientDefinition(nil), Registry...), RegistryVersion, false
+}
+
+// CompareVersions compares dotted catalog versions such as "2026.10.18" part by part,
+// numerically where both parts are numbers. It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0" // missing parts count as zero, so "1.2" equals "1.2.0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xErr := strconv.Atoi(x)
+		yn, yErr := strconv.Atoi(y)
+		switch {
+		case xErr == nil && yErr == nil && xn != yn:
+			if xn < yn {
+				return -1
+			}
+			return 1
+		case (xErr != nil || yErr != nil) && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// InstallCatalog validates a catalog read from source and saves it as the updated catalog
+// in the config directory. It must be newer than the built-in catalog, which would
+// otherwise be used in its place. It returns the installed definitions and version.
+func InstallCatalog(homeDir, source string, data []byte) ([]ClientDefinition, string, error) {
+	defs, version, err := ParseCatalog(source, data)
+	if err != nil {
+		return nil, "", err
+	}
+	if CompareVersions(version, RegistryVersion) <= 0 {
+		return nil, "", fmt.Errorf("catalog version %s is not newer than the built-in catalog %s", version, RegistryVersion)
+	}
+
+	path := CatalogPath(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// Write to a temporary file and rename it, so detection never reads half a catalog
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), CatalogFile+".tmp*")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	tmpPath := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return defs, version, nil
+}
@@ -0,0 +1,446 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UserRegistryFile is the path to the user-defined registry file
+const UserRegistryFile = "clients.yaml"
+
+// UserRegistryPath returns the location of the user's clients.yaml.
+func UserRegistryPath(homeDir string) string {
+	return filepath.Join(homeDir, ".config", "mcpetes", UserRegistryFile)
+}
+
+// DefinitionError is a problem found in a client definitions file.
+type DefinitionError struct {
+	File    string
+	Line    int // 0 if the problem isn't tied to a line
+	Message string
+}
+
+func (e DefinitionError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// DefinitionErrors is every problem found in a client definitions file, one per line.
+type DefinitionErrors []DefinitionError
+
+func (e DefinitionErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Definitions returns the built-in client definitions with the user's clients.yaml applied.
+// Problems in the user file are ignored here; DetectClients and ValidateDefinitions report them.
+func Definitions() []ClientDefinition {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return append([]ClientDefinition(nil), Registry...)
+	}
+	defs, _ := loadDefinitions(homeDir)
+	return defs
+}
+
+// loadedDefinitions caches the definitions loadDefinitions read, until their files change.
+var loadedDefinitions struct {
+	sync.Mutex
+	key  string
+	defs []ClientDefinition
+	err  error
+}
+
+// loadDefinitions applies ~/.config/mcpetes/clients.yaml to the active catalog
+// (see ActiveCatalog). A missing file is not an error. The files are read once, and
+// again only when they change, since detection looks definitions up for every target.
+func loadDefinitions(homeDir string) ([]ClientDefinition, error) {
+	key := definitionsKey(homeDir)
+	loadedDefinitions.Lock()
+	defer loadedDefinitions.Unlock()
+	if loadedDefinitions.defs == nil || loadedDefinitions.key != key {
+		defs, err := readDefinitions(homeDir)
+		loadedDefinitions.key, loadedDefinitions.defs, loadedDefinitions.err = key, defs, err
+	}
+	return slices.Clone(loadedDefinitions.defs), loadedDefinitions.err
+}
+
+// definitionsKey identifies the version of the definition files under homeDir by their
+// size and modification time.
+func definitionsKey(homeDir string) string {
+	key := homeDir
+	for _, path := range []string{CatalogPath(homeDir), UserRegistryPath(homeDir)} {
+		if info, err := os.Stat(path); err == nil {
+			key += fmt.Sprintf("\n%d %d", info.Size(), info.ModTime().UnixNano())
+		} else {
+			key += "\n-"
+		}
+	}
+	return key
+}
+
+// readDefinitions reads the definitions loadDefinitions returns.
+func readDefinitions(homeDir string) ([]ClientDefinition, error) {
+	catalog, _, _ := loadCatalog(homeDir)
+	path := UserRegistryPath(homeDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return catalog, nil
+		}
+		return catalog, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defs, _, err := parseDefinitions(path, UserRegistryFile, data, catalog)
+	return defs, err
+}
+
+// ValidateDefinitions checks a client definitions file against the active catalog
+// and returns DefinitionErrors listing every problem, or nil if the file is valid.
+func ValidateDefinitions(file string, data []byte) error {
+	catalog := Registry
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		catalog, _, _ = loadCatalog(homeDir)
+	}
+	_, _, err := parseDefinitions(file, UserRegistryFile, data, catalog)
+	return err
+}
+
+// parseDefinitions applies a definitions document to base. An entry whose ID is already
+// defined overrides only the fields it sets, "disabled: true" hides a definition, and other
+// entries are appended. Invalid entries are skipped and reported, so one typo doesn't
+// hide every other client.
+//
+// The document is either a list of definitions or a catalog, a mapping with a "version"
+// and the list under "clients". Definitions it sets are labelled with source and the
+// version, which is also returned.
+func parseDefinitions(file, source string, data []byte, base []ClientDefinition) ([]ClientDefinition, string, error) {
+	defs := append([]ClientDefinition(nil), base...)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return defs, "", yamlErrors(file, err)
+	}
+	if len(doc.Content) == 0 {
+		return defs, "", nil
+	}
+	root := doc.Content[0]
+
+	var version string
+	if root.Kind == yaml.MappingNode {
+		var errs DefinitionErrors
+		fail := func(node *yaml.Node, format string, args ...any) {
+			errs = append(errs, DefinitionError{File: file, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+		}
+		checkFields(root, catalogFields, "catalog", fail)
+		if node := mappingValue(root, "version"); node != nil {
+			if node.Kind != yaml.ScalarNode || node.Value == "" {
+				fail(node, "version must be a string such as \"2026.10.18\"")
+			}
+			version = node.Value
+		}
+		list := mappingValue(root, "clients")
+		if list == nil {
+			fail(root, "catalog has no clients list")
+		}
+		if len(errs) > 0 {
+			return defs, version, errs
+		}
+		root = list
+	}
+	if root.Kind != yaml.SequenceNode {
+		return defs, version, DefinitionErrors{{File: file, Line: root.Line, Message: "expected a list of client definitions"}}
+	}
+
+	label := source
+	if version != "" {
+		label += " " + version
+	}
+
+	var errs DefinitionErrors
+	firstLine := make(map[string]int)
+	for _, item := range root.Content {
+		id, entryErrs := validateDefinitionNode(file, item)
+		if line, dup := firstLine[id]; dup {
+			entryErrs = append(entryErrs, DefinitionError{File: file, Line: item.Line, Message: fmt.Sprintf("duplicate id %q, first defined on line %d", id, line)})
+		} else if id != "" {
+			firstLine[id] = item.Line
+		}
+		if len(entryErrs) > 0 {
+			errs = append(errs, entryErrs...)
+			continue
+		}
+
+		index := -1
+		for i := range defs {
+			if defs[i].ID == id {
+				index = i
+				break
+			}
+		}
+
+		var def ClientDefinition
+		if index >= 0 {
+			def = cloneDefinition(defs[index])
+		}
+		if err := item.Decode(&def); err != nil {
+			errs = append(errs, yamlErrors(file, err)...)
+			continue
+		}
+		// An override's capabilities start from the built-in ones rather than the defaults
+		if capsNode := mappingValue(item, "capabilities"); capsNode != nil && index >= 0 && defs[index].Capabilities != nil {
+			type plain Capabilities
+			caps := cloneCapabilities(defs[index].Capabilities)
+			if err := capsNode.Decode((*plain)(caps)); err != nil {
+				errs = append(errs, yamlErrors(file, err)...)
+				continue
+			}
+			def.Capabilities = caps
+		}
+
+		if index < 0 && def.ConfigFormat == "" && !def.Disabled {
+			errs = append(errs, DefinitionError{File: file, Line: item.Line, Message: fmt.Sprintf("client %q is not built in, so it needs a configformat", id)})
+			continue
+		}
+		if def.Name == "" {
+			def.Name = def.ID
+		}
+		def.Catalog = label
+
+		if index >= 0 {
+			defs[index] = def
+		} else {
+			defs = append(defs, def)
+		}
+	}
+
+	enabled := defs[:0]
+	for _, def := range defs {
+		if !def.Disabled {
+			enabled = append(enabled, def)
+		}
+	}
+
+	if len(errs) > 0 {
+		return enabled, version, errs
+	}
+	return enabled, version, nil
+}
+
+var (
+	definitionFields = fieldSet("id", "name", "configformat", "configkey", "paths", "profilesdir", "capabilities", "binaries", "extensions", "desktopfiles", "processes", "disabled")
+	pathFields       = fieldSet("base", "path", "glob", "variant")
+	catalogFields    = fieldSet("version", "clients")
+	capabilityFields = fieldSet("url", "env", "disabled", "auto_approve", "tool_filter", "server_name_chars", "max_tools", "fields")
+
+	knownOS      = fieldSet("windows", "darwin", "linux")
+	knownFormats = map[ConfigFormatEnum]bool{
+		FormatClaudeDesktop: true, FormatVSCode: true, FormatVSCodeMCP: true, FormatClaudeCode: true,
+		FormatSimpleJSON: true, FormatYAML: true, FormatTOML: true, FormatContinue: true,
+		FormatJetBrainsXML: true, FormatDirectoryJSON: true, FormatContinueBlocks: true,
+	}
+	knownBases = map[BaseDirEnum]bool{
+		BaseHome: true, BaseAppData: true, BaseUserProfile: true, BaseXDGConfig: true,
+		BaseXDGData: true, BaseFlatpak: true, BaseSnap: true,
+	}
+
+	// Client IDs and variants become part of target IDs, so they can't contain separators
+	idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+)
+
+// ValidateFormat returns an error if format isn't a supported config format.
+func ValidateFormat(format ConfigFormatEnum) error {
+	if !knownFormats[format] {
+		return fmt.Errorf("unknown config format %q", format)
+	}
+	return nil
+}
+
+func fieldSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// validateDefinitionNode checks the structure of one clients.yaml entry and returns its ID.
+// Value types are checked when the entry is decoded.
+func validateDefinitionNode(file string, item *yaml.Node) (string, DefinitionErrors) {
+	var errs DefinitionErrors
+	fail := func(node *yaml.Node, format string, args ...any) {
+		errs = append(errs, DefinitionError{File: file, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if item.Kind != yaml.MappingNode {
+		fail(item, "expected a client definition with at least an id")
+		return "", errs
+	}
+	checkFields(item, definitionFields, "client", fail)
+
+	idNode := mappingValue(item, "id")
+	id := ""
+	switch {
+	case idNode == nil:
+		fail(item, "missing id")
+	case !idPattern.MatchString(idNode.Value):
+		fail(idNode, "invalid id %q: use lowercase letters, digits, '.', '_' and '-'", idNode.Value)
+	default:
+		id = idNode.Value
+	}
+
+	if node := mappingValue(item, "configformat"); node != nil && !knownFormats[ConfigFormatEnum(node.Value)] {
+		fail(node, "unknown configformat %q", node.Value)
+	}
+
+	if pathsNode := mappingValue(item, "paths"); pathsNode != nil {
+		if pathsNode.Kind != yaml.MappingNode {
+			fail(pathsNode, "paths must map an OS (windows, darwin, linux) to a list of paths")
+		} else {
+			for i := 0; i+1 < len(pathsNode.Content); i += 2 {
+				osNode, list := pathsNode.Content[i], pathsNode.Content[i+1]
+				if !knownOS[osNode.Value] {
+					fail(osNode, "unknown OS %q: expected windows, darwin or linux", osNode.Value)
+				}
+				if list.Kind != yaml.SequenceNode {
+					fail(list, "paths for %s must be a list", osNode.Value)
+					continue
+				}
+				for _, pathNode := range list.Content {
+					validatePathNode(pathNode, fail)
+				}
+			}
+		}
+	}
+
+	if capsNode := mappingValue(item, "capabilities"); capsNode != nil {
+		if capsNode.Kind != yaml.MappingNode {
+			fail(capsNode, "capabilities must be a mapping")
+		} else {
+			checkFields(capsNode, capabilityFields, "capability", fail)
+			if node := mappingValue(capsNode, "server_name_chars"); node != nil {
+				if _, err := regexp.Compile("[" + node.Value + "]"); err != nil || node.Value == "" {
+					fail(node, "server_name_chars %q is not a valid character class", node.Value)
+				}
+			}
+		}
+	}
+
+	return id, errs
+}
+
+// validatePathNode checks one entry of a paths list.
+func validatePathNode(node *yaml.Node, fail func(*yaml.Node, string, ...any)) {
+	if node.Kind != yaml.MappingNode {
+		fail(node, "expected a path with a base and a path")
+		return
+	}
+	checkFields(node, pathFields, "path", fail)
+
+	if base := mappingValue(node, "base"); base == nil {
+		fail(node, "missing base")
+	} else if !knownBases[BaseDirEnum(base.Value)] {
+		fail(base, "unknown base %q: expected home, appdata, userprofile, xdg-config, xdg-data, flatpak or snap", base.Value)
+	}
+
+	path := mappingValue(node, "path")
+	switch {
+	case path == nil || path.Value == "":
+		fail(node, "missing path")
+	case filepath.IsAbs(path.Value) || strings.HasPrefix(path.Value, "/") || strings.HasPrefix(path.Value, "~"):
+		fail(path, "path %q must be relative to its base", path.Value)
+	case hasParentSegment(path.Value):
+		fail(path, "path %q must not leave its base directory", path.Value)
+	default:
+		if glob := mappingValue(node, "glob"); glob != nil && glob.Value == "true" {
+			if _, err := filepath.Match(filepath.Dir(path.Value), ""); err != nil {
+				fail(path, "invalid glob pattern %q: %v", path.Value, err)
+			}
+		}
+	}
+
+	if variant := mappingValue(node, "variant"); variant != nil && !idPattern.MatchString(variant.Value) {
+		fail(variant, "invalid variant %q: use lowercase letters, digits, '.', '_' and '-'", variant.Value)
+	}
+}
+
+// checkFields reports keys of a mapping node that aren't in known.
+func checkFields(node *yaml.Node, known map[string]bool, kind string, fail func(*yaml.Node, string, ...any)) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; !known[key.Value] {
+			fail(key, "unknown %s field %q", kind, key.Value)
+		}
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func hasParentSegment(path string) bool {
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors converts a YAML parse or decode error into line-numbered DefinitionErrors.
+func yamlErrors(file string, err error) DefinitionErrors {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	errs := make(DefinitionErrors, 0, len(messages))
+	for _, msg := range messages {
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			errs = append(errs, DefinitionError{File: file, Line: line, Message: m[2]})
+			continue
+		}
+		errs = append(errs, DefinitionError{File: file, Message: strings.TrimPrefix(msg, "yaml: ")})
+	}
+	return errs
+}
+
+// cloneDefinition copies the maps and pointers of def, so decoding an override into the
+// copy leaves the built-in definition untouched.
+func cloneDefinition(def ClientDefinition) ClientDefinition {
+	def.Paths = maps.Clone(def.Paths)
+	if def.Capabilities != nil {
+		def.Capabilities = cloneCapabilities(def.Capabilities)
+	}
+	return def
+}
+
+func cloneCapabilities(caps *Capabilities) *Capabilities {
+	clone := *caps
+	clone.Fields = maps.Clone(caps.Fields)
+	return &clone
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EvidenceEnum is a kind of sign that a client is installed
+type EvidenceEnum string
+
+const (
+	EvidenceConfigFile  EvidenceEnum = "config-file"  // The config file exists
+	EvidenceConfigDir   EvidenceEnum = "config-dir"   // Only the config file's directory exists
+	EvidenceBinary      EvidenceEnum = "binary"       // One of the client's executables is on PATH
+	EvidenceExtension   EvidenceEnum = "extension"    // The client's editor extension is installed
+	EvidenceDesktopFile EvidenceEnum = "desktop-file" // A .desktop entry is installed (Linux)
+	EvidenceProcess     EvidenceEnum = "process"      // The client is running (Linux)
+)
+
+// ConfidenceEnum rates how sure detection is that a client is actually installed
+type ConfidenceEnum string
+
+const (
+	ConfidenceHigh   ConfidenceEnum = "high"   // Config file plus an install sign
+	ConfidenceMedium ConfidenceEnum = "medium" // Config file or an install sign
+	ConfidenceLow    ConfidenceEnum = "low"    // Only a directory, e.g. left behind by an uninstalled app
+)
+
+// Evidence is one sign that a client is installed, with where it was found.
+type Evidence struct {
+	Kind   EvidenceEnum `json:"kind"`
+	Detail string       `json:"detail"`
+}
+
+func (e Evidence) String() string {
+	switch e.Kind {
+	case EvidenceConfigFile:
+		return fmt.Sprintf("config file %s exists", e.Detail)
+	case EvidenceConfigDir:
+		return fmt.Sprintf("config directory %s exists", e.Detail)
+	case EvidenceBinary:
+		return fmt.Sprintf("executable found at %s", e.Detail)
+	case EvidenceExtension:
+		return fmt.Sprintf("extension installed at %s", e.Detail)
+	case EvidenceDesktopFile:
+		return fmt.Sprintf("desktop entry %s installed", e.Detail)
+	case EvidenceProcess:
+		return fmt.Sprintf("process '%s' is running", e.Detail)
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Detail)
+}
+
+// installProbe looks for install signs outside the config file. System-wide state
+// (running processes, desktop entry directories) is read once per detection run.
+type installProbe struct {
+	desktopDirs   []string
+	extensionDirs []string
+	processes     map[string]bool
+}
+
+func newInstallProbe(homeDir string) *installProbe {
+	return &installProbe{
+		desktopDirs:   desktopDirs(homeDir),
+		extensionDirs: extensionDirs(homeDir),
+		processes:     runningProcesses(),
+	}
+}
+
+// installEvidence returns the signs, other than config files, that def is installed.
+func (p *installProbe) installEvidence(def ClientDefinition) []Evidence {
+	var evidence []Evidence
+
+	for _, name := range def.Binaries {
+		if path, err := exec.LookPath(name); err == nil {
+			evidence = append(evidence, Evidence{Kind: EvidenceBinary, Detail: path})
+			break
+		}
+	}
+
+extension:
+	for _, id := range def.Extensions {
+		for _, dir := range p.extensionDirs {
+			if path := findExtension(dir, id); path != "" {
+				evidence = append(evidence, Evidence{Kind: EvidenceExtension, Detail: path})
+				break extension
+			}
+		}
+	}
+
+desktop:
+	for _, name := range def.DesktopFiles {
+		for _, dir := range p.desktopDirs {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				evidence = append(evidence, Evidence{Kind: EvidenceDesktopFile, Detail: path})
+				break desktop
+			}
+		}
+	}
+
+	for _, name := range slices.Concat(def.Binaries, def.Processes) {
+		if p.processes[name] || p.processes[commName(name)] {
+			evidence = append(evidence, Evidence{Kind: EvidenceProcess, Detail: name})
+			break
+		}
+	}
+
+	return evidence
+}
+
+// withEvidence records the config file's state and the install signs on a detected
+// client of def, and rates its confidence.
+func withEvidence(def ClientDefinition, c DetectedClient, install []Evidence) DetectedClient {
+	c.Evidence = nil
+	if _, err := os.Stat(c.ConfigPath); err == nil {
+		c.Evidence = append(c.Evidence, Evidence{Kind: EvidenceConfigFile, Detail: c.ConfigPath})
+	} else if _, err := os.Stat(filepath.Dir(c.ConfigPath)); err == nil {
+		c.Evidence = append(c.Evidence, Evidence{Kind: EvidenceConfigDir, Detail: filepath.Dir(c.ConfigPath)})
+	}
+	c.Evidence = append(c.Evidence, install...)
+	c.Confidence = RateConfidence(c.Evidence)
+	// An uninstalled extension leaves its config behind, so only its install counts
+	if len(def.Extensions) > 0 && !slices.ContainsFunc(install, func(e Evidence) bool { return e.Kind == EvidenceExtension }) {
+		c.Confidence = ConfidenceLow
+	}
+	return c
+}
+
+// RateConfidence sums up evidence: an existing config file and an install sign (binary,
+// extension, desktop entry or process) together are high confidence, either one alone is medium,
+// and a bare directory is low.
+func RateConfidence(evidence []Evidence) ConfidenceEnum {
+	hasConfig, hasInstall := false, false
+	for _, e := range evidence {
+		switch e.Kind {
+		case EvidenceConfigFile:
+			hasConfig = true
+		case EvidenceBinary, EvidenceExtension, EvidenceDesktopFile, EvidenceProcess:
+			hasInstall = true
+		}
+	}
+
+	switch {
+	case hasConfig && hasInstall:
+		return ConfidenceHigh
+	case hasConfig || hasInstall:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+// desktopDirs returns the directories .desktop entries are installed to on Linux: the XDG
+// data directories plus the Flatpak and Snap export directories.
+func desktopDirs(homeDir string) []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+
+	dataDirs := []string{BaseDirs(homeDir)[BaseXDGData]}
+	systemDirs := os.Getenv("XDG_DATA_DIRS")
+	if systemDirs == "" {
+		systemDirs = "/usr/local/share:/usr/share"
+	}
+	dataDirs = append(dataDirs, filepath.SplitList(systemDirs)...)
+	dataDirs = append(dataDirs,
+		filepath.Join(homeDir, ".local", "share", "flatpak", "exports", "share"),
+		"/var/lib/flatpak/exports/share",
+		"/var/lib/snapd/desktop",
+	)
+
+	dirs := make([]string, 0, len(dataDirs))
+	for _, dir := range dataDirs {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, filepath.Join(dir, "applications"))
+		}
+	}
+	return dirs
+}
+
+// extensionDirs returns the directories VS Code installs extensions to: $VSCODE_EXTENSIONS
+// if set, and the stable, Insiders, VSCodium and Flatpak defaults.
+func extensionDirs(homeDir string) []string {
+	var dirs []string
+	if dir := os.Getenv("VSCODE_EXTENSIONS"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return append(dirs,
+		filepath.Join(homeDir, ".vscode", "extensions"),
+		filepath.Join(homeDir, ".vscode-insiders", "extensions"),
+		filepath.Join(homeDir, ".vscode-oss", "extensions"),
+		filepath.Join(homeDir, ".var", "app", "com.visualstudio.code", "data", "vscode", "extensions"),
+	)
+}
+
+// findExtension returns the install directory of an extension in dir, named
+// "<publisher.name>-<version>" in lower case, or "".
+func findExtension(dir, id string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	prefix := strings.ToLower(id) + "-"
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() && strings.HasPrefix(name, prefix) && len(name) > len(prefix) && unicode.IsDigit(rune(name[len(prefix)])) {
+			return filepath.Join(dir, entry.Name())
+		}
+	}
+	return ""
+}
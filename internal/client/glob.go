@@ -0,0 +1,57 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// detectGlobInstalls returns one DetectedClient per directory matching the directory part of
+// pattern. Each install is named after the path segment matched by the first wildcard, so
+// "JetBrains/*/options/llm.mcpServers.xml" yields e.g. "jetbrains-ai:goland2024.3".
+func detectGlobInstalls(def ClientDefinition, pattern string) []DetectedClient {
+	dirPattern := filepath.Dir(pattern)
+	matches, err := filepath.Glob(dirPattern)
+	if err != nil {
+		return nil
+	}
+
+	wildcard := -1
+	patternParts := strings.Split(dirPattern, string(filepath.Separator))
+	for i, part := range patternParts {
+		if strings.ContainsAny(part, "*?[") {
+			wildcard = i
+			break
+		}
+	}
+	if wildcard < 0 {
+		return nil
+	}
+
+	var installs []DetectedClient
+	for _, match := range matches {
+		if info, err := os.Stat(match); err != nil || !info.IsDir() {
+			continue
+		}
+
+		parts := strings.Split(match, string(filepath.Separator))
+		if len(parts) != len(patternParts) {
+			continue
+		}
+		installName := profileSlug(parts[wildcard])
+		if installName == "" {
+			continue
+		}
+
+		installs = append(installs, DetectedClient{
+			ID:           def.ID + ProfileSeparator + installName,
+			Name:         def.Name + " (" + parts[wildcard] + ")",
+			ConfigPath:   filepath.Join(match, filepath.Base(pattern)),
+			ConfigFormat: def.ConfigFormat,
+			ConfigKey:    def.ConfigKey,
+			Catalog:      def.Catalog,
+		})
+	}
+
+	return installs
+}
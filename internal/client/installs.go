@@ -0,0 +1,77 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// InstallSeparator separates a client ID from the hash of the path of an additional,
+// unnamed install (e.g. "cursor#26a77d").
+const InstallSeparator = "#"
+
+// installTarget returns the definition of the install of def at pathDef, one of the
+// client's paths, with its ID and name adjusted. The ID depends on the path alone, so it
+// doesn't change when other installs appear or disappear: the client's first unnamed
+// path keeps the plain ID, and the others add a short hash of their path.
+func installTarget(def ClientDefinition, paths []PathDefinition, pathDef PathDefinition) ClientDefinition {
+	if pathDef.Variant != "" {
+		return variantDefinition(def, pathDef.Variant)
+	}
+	for _, p := range paths {
+		if p.Variant == "" && !p.Glob {
+			if p == pathDef {
+				return def
+			}
+			break
+		}
+	}
+
+	hash := installHash(pathDef)
+	def.ID = def.ID + InstallSeparator + hash
+	def.Name = fmt.Sprintf("%s #%s", def.Name, hash)
+	return def
+}
+
+// installHash is a short hash of a path relative to its base, the same on every machine.
+func installHash(pathDef PathDefinition) string {
+	sum := sha256.Sum256([]byte(path.Join(string(pathDef.Base), filepath.ToSlash(pathDef.Path))))
+	return hex.EncodeToString(sum[:3])
+}
+
+// variantDefinition returns def renamed for a named install variant.
+func variantDefinition(def ClientDefinition, variant string) ClientDefinition {
+	if variant == "" {
+		return def
+	}
+	def.ID = def.ID + "-" + variant
+	def.Name = def.Name + " (" + variant + ")"
+	return def
+}
+
+// FindDefinition returns the definition a detected target ID belongs to, resolving profile
+// ("vscode:work"), additional ("cursor#26a77d") and variant ("vscode-flatpak") targets.
+func FindDefinition(targetID string) (ClientDefinition, bool) {
+	id, _, _ := strings.Cut(targetID, ProfileSeparator)
+	id, _, _ = strings.Cut(id, InstallSeparator)
+
+	defs := Definitions()
+	for _, def := range defs {
+		if def.ID == id {
+			return def, true
+		}
+	}
+	for _, def := range defs {
+		for _, paths := range def.Paths {
+			for _, pathDef := range paths {
+				if pathDef.Variant != "" && variantDefinition(def, pathDef.Variant).ID == id {
+					return def, true
+				}
+			}
+		}
+	}
+	return ClientDefinition{}, false
+}
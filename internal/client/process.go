@@ -0,0 +1,72 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// procDir is where running processes are listed. Systems without it report none.
+var procDir = "/proc"
+
+// commLen is the length the kernel truncates process command names to.
+const commLen = 15
+
+// runningProcesses returns the names of running processes. Each process is listed under its
+// command name and the base name of its first argument, since Electron apps and scripts often
+// show up under a different command name than the one they were started as.
+func runningProcesses() map[string]bool {
+	names := make(map[string]bool)
+
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.Trim(entry.Name(), "0123456789") != "" {
+			continue
+		}
+		dir := filepath.Join(procDir, entry.Name())
+
+		if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+			if name := strings.TrimSpace(string(comm)); name != "" {
+				names[name] = true
+			}
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+			argv0, _, _ := strings.Cut(string(cmdline), "\x00")
+			if name := filepath.Base(argv0); argv0 != "" {
+				names[name] = true
+			}
+		}
+	}
+
+	return names
+}
+
+// commName truncates a binary name the way the kernel does for process command names.
+func commName(name string) string {
+	if len(name) > commLen {
+		return name[:commLen]
+	}
+	return name
+}
+
+// RunningProcesses returns the declared Processes of a client (by target ID, see
+// FindDefinition) that are currently running. It finds none outside Linux.
+func RunningProcesses(clientID string) []string {
+	def, ok := FindDefinition(clientID)
+	if !ok || len(def.Processes) == 0 {
+		return nil
+	}
+
+	running := runningProcesses()
+	var found []string
+	for _, name := range def.Processes {
+		if running[name] || running[commName(name)] {
+			found = append(found, name)
+		}
+	}
+	return found
+}
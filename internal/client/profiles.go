@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tailscale/hujson"
+)
+
+// ProfileSeparator separates a client ID from a profile name in target IDs (e.g. "vscode:work").
+const ProfileSeparator = ":"
+
+// detectProfiles returns one DetectedClient per profile subdirectory found under
+// def.ProfilesDir next to the client's main config file.
+func detectProfiles(def ClientDefinition, mainConfigPath string) []DetectedClient {
+	configDir := filepath.Dir(mainConfigPath)
+	profilesDir := filepath.Join(configDir, def.ProfilesDir)
+
+	entries, err := os.ReadDir(profilesDir)
+	if err != nil {
+		return nil
+	}
+
+	names := readProfileNames(configDir)
+
+	var profiles []DetectedClient
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		profileName := profileSlug(entry.Name())
+		if name, ok := names[entry.Name()]; ok && profileSlug(name) != "" {
+			profileName = profileSlug(name)
+		}
+
+		profiles = append(profiles, DetectedClient{
+			ID:           def.ID + ProfileSeparator + profileName,
+			Name:         def.Name + " (" + profileName + ")",
+			ConfigPath:   filepath.Join(profilesDir, entry.Name(), filepath.Base(mainConfigPath)),
+			ConfigFormat: def.ConfigFormat,
+			ConfigKey:    def.ConfigKey,
+			Catalog:      def.Catalog,
+		})
+	}
+
+	return profiles
+}
+
+// readProfileNames maps profile directory names to their display names using
+// VS Code's "globalStorage/storage.json" ("userDataProfiles": [{"location", "name"}]).
+// Missing or unreadable metadata yields an empty map.
+func readProfileNames(userDir string) map[string]string {
+	names := make(map[string]string)
+
+	data, err := os.ReadFile(filepath.Join(userDir, "globalStorage", "storage.json"))
+	if err != nil {
+		return names
+	}
+	standardized, err := hujson.Standardize(data)
+	if err != nil {
+		return names
+	}
+
+	var storage struct {
+		UserDataProfiles []struct {
+			Location string `json:"location"`
+			Name     string `json:"name"`
+		} `json:"userDataProfiles"`
+	}
+	if err := json.Unmarshal(standardized, &storage); err != nil {
+		return names
+	}
+
+	for _, p := range storage.UserDataProfiles {
+		names[p.Location] = p.Name
+	}
+	return names
+}
+
+// profileSlug turns a profile display name into a target-ID friendly slug.
+func profileSlug(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.')
+	}), "-")
+}
@@ -1,12 +1,9 @@
 package client
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
-
-	"gopkg.in/yaml.v3"
 )
 
 // ConfigFormatEnum defines the supported configuration formats
@@ -15,10 +12,32 @@ type ConfigFormatEnum string
 const (
 	FormatClaudeDesktop ConfigFormatEnum = "claude-desktop" // {"mcpServers": {...}}
 	FormatVSCode        ConfigFormatEnum = "vscode"         // {"mcp": {"servers": {...}}} or {"mcp.servers": {...}}
+	FormatVSCodeMCP     ConfigFormatEnum = "vscode-mcp"     // Dedicated User/mcp.json: {"servers": {...}, "inputs": [...]}
+	FormatClaudeCode    ConfigFormatEnum = "claude-code"    // ~/.claude.json: user "mcpServers" plus per-project "projects"[path]."mcpServers"
 	FormatSimpleJSON    ConfigFormatEnum = "simple-json"    // {"mcpServers": {...}} (Standard MCP)
 	FormatYAML          ConfigFormatEnum = "yaml"           // YAML format
 	FormatTOML          ConfigFormatEnum = "toml"           // TOML format
 	FormatContinue      ConfigFormatEnum = "continue"       // Continue.dev config.json structure
+	FormatJetBrainsXML  ConfigFormatEnum = "jetbrains-xml"  // IDE options XML: <component name="McpApplicationServerCommands">
+
+	// Directory-backed formats: ConfigPath points at a directory holding one file per server.
+	FormatDirectoryJSON  ConfigFormatEnum = "directory-json"  // <dir>/<server-id>.json, each {"mcpServers": {"<server-id>": {...}}}
+	FormatContinueBlocks ConfigFormatEnum = "continue-blocks" // <dir>/<server-id>.yaml, Continue "mcpServers" blocks
+)
+
+// IsDirectoryFormat reports whether the format stores one file per server in a directory
+// instead of a single configuration file.
+func IsDirectoryFormat(format ConfigFormatEnum) bool {
+	return format == FormatDirectoryJSON || format == FormatContinueBlocks
+}
+
+// ScopeEnum defines which part of a multi-scope config (Claude Code CLI) is managed
+type ScopeEnum string
+
+const (
+	ScopeUser    ScopeEnum = "user"    // Top-level "mcpServers" in ~/.claude.json
+	ScopeProject ScopeEnum = "project" // "projects"[<abs path>]."mcpServers" in ~/.claude.json
+	ScopeRepo    ScopeEnum = "repo"    // <project>/.mcp.json checked into the repository
 )
 
 // BaseDirEnum defines where the config is relative to
@@ -28,12 +47,22 @@ const (
 	BaseHome        BaseDirEnum = "home"
 	BaseAppData     BaseDirEnum = "appdata"     // Windows %APPDATA%
 	BaseUserProfile BaseDirEnum = "userprofile" // Windows %USERPROFILE%
+	BaseXDGConfig   BaseDirEnum = "xdg-config"  // $XDG_CONFIG_HOME, default ~/.config
+	BaseXDGData     BaseDirEnum = "xdg-data"    // $XDG_DATA_HOME, default ~/.local/share
+	BaseFlatpak     BaseDirEnum = "flatpak"     // ~/.var/app, holding <app-id>/config/...
+	BaseSnap        BaseDirEnum = "snap"        // ~/snap, holding <name>/current/...
 )
 
 // PathDefinition defines a path strategy for a specific OS
 type PathDefinition struct {
 	Base BaseDirEnum
 	Path string // Relative path from the base
+	// Glob marks Path's directory part as a glob pattern (e.g. "JetBrains/*/options").
+	// Every matching directory is a separate install, detected as "<ID>:<matched-name>".
+	Glob bool
+	// Variant names the install found at this path (e.g. "flatpak"), detected as "<ID>-<Variant>".
+	// Unnamed paths after the client's first are detected as "<ID>#<hash of the path>".
+	Variant string
 }
 
 // ClientDefinition defines the metadata and paths for a tool
@@ -47,620 +76,31 @@ type ClientDefinition struct {
 	// Map of OS to list of potential config paths
 	// supported OS keys: "windows", "darwin", "linux"
 	Paths map[string][]PathDefinition
-}
-
-// Registry holds the list of known clients
-var Registry = []ClientDefinition{
-	// --- Desktop IDEs ---
-	{
-		ID:           "claude-desktop",
-		Name:         "Claude Desktop",
-		ConfigFormat: FormatClaudeDesktop,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Claude", "claude_desktop_config.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Claude", "claude_desktop_config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Claude", "claude_desktop_config.json")},
-			},
-		},
-	},
-	{
-		ID:           "cursor",
-		Name:         "Cursor",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".cursor", "mcp.json")},
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Cursor", "User", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Cursor", "User", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".cursor", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "windsurf",
-		Name:         "Windsurf",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".codeium", "windsurf", "mcp_config.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".codeium", "windsurf", "mcp_config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".codeium", "windsurf", "mcp_config.json")},
-			},
-		},
-	},
-	{
-		ID:           "vscode",
-		Name:         "VS Code",
-		ConfigFormat: FormatVSCode,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "vscode-insiders",
-		Name:         "VS Code Insiders",
-		ConfigFormat: FormatVSCode,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code - Insiders", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code - Insiders", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code - Insiders", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "zed",
-		Name:         "Zed",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".config", "zed", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Zed", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "zed", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "trae",
-		Name:         "Trae",
-		ConfigFormat: FormatSimpleJSON, // Assuming standard format, need to verify docs/user info if available
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Trae", "User", "globalStorage", "mcp.json")}, // Guess based on Electron/VSCode forks
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Trae", "User", "globalStorage", "mcp.json")}, // Guess
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Trae", "User", "globalStorage", "mcp.json")}, // Guess
-			},
-		},
-	},
-	{
-		ID:           "amazon-q",
-		Name:         "Amazon Q (CodeWhisperer)",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".aws", "amazonq", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".aws", "amazonq", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".aws", "amazonq", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "jetbrains-junie",
-		Name:         "JetBrains (Junie)",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".junie", "mcp", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseHome, Path: filepath.Join(".junie", "mcp", "mcp.json")}, // Note: Docs say ~/.junie even on Windows, need to verify if it respects %USERPROFILE% (which BaseHome maps to on detection)
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".junie", "mcp", "mcp.json")},
-			},
-		},
-	},
-
-	// --- VSCode Extensions / "Autonomous Agents" ---
-	{
-		ID:           "cody",
-		Name:         "Cody (Sourcegraph)",
-		ConfigFormat: FormatVSCode,
-		ConfigKey:    "openctx.providers",
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "cline",
-		Name:         "Cline",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "roo-code",
-		Name:         "Roo Code",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings", "cline_mcp_settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "continue",
-		Name:         "Continue",
-		ConfigFormat: FormatContinue,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".continue", "config.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".continue", "config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".continue", "config.json")},
-			},
-		},
-	},
-
-	// --- Desktop Apps ---
-	{
-		ID:           "lm-studio",
-		Name:         "LM Studio",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".lmstudio", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".lmstudio", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".lmstudio", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "anythingllm",
-		Name:         "AnythingLLM",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "anythingllm-desktop", "storage", "plugins", "anythingllm_mcp_servers.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("anythingllm-desktop", "storage", "plugins", "anythingllm_mcp_servers.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "anythingllm-desktop", "storage", "plugins", "anythingllm_mcp_servers.json")},
-			},
-		},
-	},
-	{
-		ID:           "tabby",
-		Name:         "Tabby",
-		ConfigFormat: FormatTOML, // Tabby uses config.toml
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".tabby-client", "agent", "config.toml")}, // Typical agent config
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Tabby", "config.toml")}, // Check specific location, often user profile or appdata
-				{Base: BaseUserProfile, Path: filepath.Join(".tabby-client", "agent", "config.toml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".tabby-client", "agent", "config.toml")},
-			},
-		},
-	},
-	{
-		ID:           "librechat",
-		Name:         "LibreChat",
-		ConfigFormat: FormatYAML,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("librechat.yaml")}, // Often in project root or home
-				{Base: BaseHome, Path: filepath.Join(".librechat", "librechat.yaml")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join("librechat.yaml")},
-				{Base: BaseUserProfile, Path: filepath.Join(".librechat", "librechat.yaml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join("librechat.yaml")},
-				{Base: BaseHome, Path: filepath.Join(".librechat", "librechat.yaml")},
-			},
-		},
-	},
-
-	// --- CLIs ---
-	{
-		ID:           "goose",
-		Name:         "Goose CLI",
-		ConfigFormat: FormatYAML,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".config", "goose", "config.yaml")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Block", "goose", "config", "config.yaml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "goose", "config.yaml")},
-			},
-		},
-	},
-	{
-		ID:           "mistral-vibe",
-		Name:         "Mistral Vibe",
-		ConfigFormat: FormatTOML,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".vibe", "config.toml")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".vibe", "config.toml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".vibe", "config.toml")},
-			},
-		},
-	},
-	{
-		ID:           "code-cli",
-		Name:         "Code CLI (Codex)",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".config", "code-cli", "mcp.json")}, // Guess based on convention
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("code-cli", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "code-cli", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "grok-cli",
-		Name:         "Grok CLI",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".grok", "config.json")}, // Typical CLI convention
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".grok", "config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".grok", "config.json")},
-			},
-		},
-	},
-	{
-		ID:           "open-interpreter",
-		Name:         "Open Interpreter",
-		ConfigFormat: FormatYAML, // Often uses YAML for profiles
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".config", "open-interpreter", "config.yaml")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Open Interpreter", "config.yaml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "open-interpreter", "config.yaml")},
-			},
-		},
-	},
-	{
-		ID:           "factory-cli",
-		Name:         "Factory CLI",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".factory", "config.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".factory", "config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".factory", "config.json")},
-			},
-		},
-	},
-	{
-		ID:           "aider",
-		Name:         "Aider",
-		ConfigFormat: FormatYAML,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".aider.conf.yml")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".aider.conf.yml")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".aider.conf.yml")},
-			},
-		},
-	},
-	{
-		ID:           "pearai",
-		Name:         "PearAI",
-		ConfigFormat: FormatSimpleJSON, // VSCode fork, uses settings.json
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "PearAI", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("PearAI", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "PearAI", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "void",
-		Name:         "Void",
-		ConfigFormat: FormatVSCode, // VSCode fork
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Void", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Void", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Void", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "melty",
-		Name:         "Melty",
-		ConfigFormat: FormatVSCode, // VSCode fork
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Melty", "User", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Melty", "User", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Melty", "User", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "codebuddy",
-		Name:         "CodeBuddy",
-		ConfigFormat: FormatVSCode, // VSCode fork/extension
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".codebuddy", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".codebuddy", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".codebuddy", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "kiro",
-		Name:         "Kiro",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".kiro", "settings", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".kiro", "settings", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".kiro", "settings", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "antigravity",
-		Name:         "Antigravity IDE",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".gemini", "antigravity", "mcp_config.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".gemini", "antigravity", "mcp_config.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".gemini", "antigravity", "mcp_config.json")},
-			},
-		},
-	},
-	{
-		ID:           "codegpt",
-		Name:         "CodeGPT",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Code", "User", "globalStorage", "DanielSanMedium.dscodegpt", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Code", "User", "globalStorage", "DanielSanMedium.dscodegpt", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Code", "User", "globalStorage", "DanielSanMedium.dscodegpt", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "5ire",
-		Name:         "5ire",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "5ire", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("5ire", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "5ire", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "jan",
-		Name:         "Jan",
-		ConfigFormat: FormatSimpleJSON, // Guessing simple JSON for now, might be in assistant.json or settings.json
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "Jan", "data", "settings.json")},
-				{Base: BaseHome, Path: filepath.Join("jan", "settings.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("Jan", "data", "settings.json")},
-				{Base: BaseUserProfile, Path: filepath.Join("jan", "settings.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".config", "Jan", "data", "settings.json")},
-				{Base: BaseHome, Path: filepath.Join("jan", "settings.json")},
-			},
-		},
-	},
-	{
-		ID:           "warp",
-		Name:         "Warp",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".local", "state", "warp-terminal", "mcp")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".local", "state", "warp-terminal", "mcp")},
-				{Base: BaseAppData, Path: filepath.Join("Warp", "mcp.json")}, // Fallback guess
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".local", "state", "warp-terminal", "mcp")},
-			},
-		},
-	},
-	{
-		ID:           "llm-cli",
-		Name:         "LLM CLI (Simon Willison)",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".llm-tools-mcp", "mcp.json")},
-				{Base: BaseHome, Path: filepath.Join(".config", "io.datasette.llm", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".llm-tools-mcp", "mcp.json")},
-				{Base: BaseAppData, Path: filepath.Join("io.datasette.llm", "mcp.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".llm-tools-mcp", "mcp.json")},
-				{Base: BaseHome, Path: filepath.Join(".config", "io.datasette.llm", "mcp.json")},
-			},
-		},
-	},
-	{
-		ID:           "claude-code",
-		Name:         "Claude Code CLI",
-		ConfigFormat: FormatSimpleJSON, // ~/.claude.json uses {"mcpServers": ...}
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join(".claude.json")},
-			},
-			"windows": {
-				{Base: BaseUserProfile, Path: filepath.Join(".claude.json")},
-			},
-			"linux": {
-				{Base: BaseHome, Path: filepath.Join(".claude.json")},
-			},
-		},
-	},
-	{
-		ID:           "boltai",
-		Name:         "BoltAI",
-		ConfigFormat: FormatSimpleJSON,
-		Paths: map[string][]PathDefinition{
-			"darwin": {
-				{Base: BaseHome, Path: filepath.Join("Library", "Application Support", "BoltAI", "mcp.json")},
-			},
-			"windows": {
-				{Base: BaseAppData, Path: filepath.Join("BoltAI", "mcp.json")}, // Standard assumption for Electron/similar apps
-			},
-			// Linux support for BoltAI unknown/unlikely
-		},
-	},
+	// ProfilesDir is an optional directory, relative to the config file's directory,
+	// holding one subdirectory per user profile (e.g. VS Code's "User/profiles/<id>/").
+	// Each profile is detected as its own target "<ID>:<profile-name>".
+	ProfilesDir string
+	// Capabilities describes the server settings the client supports.
+	// Nil means DefaultCapabilities.
+	Capabilities *Capabilities
+	// Binaries are executable names that show the client is installed when found on PATH
+	// or running.
+	Binaries []string
+	// Extensions are the VS Code extension IDs (publisher.name) of a client that is an
+	// extension. Its install directory, e.g. ~/.vscode/extensions/<ID>-<version>, shows it
+	// is installed; without one, its config is low confidence, since uninstalling an
+	// extension leaves its storage behind.
+	Extensions []string
+	// DesktopFiles are .desktop entry names installed with the client on Linux.
+	DesktopFiles []string
+	// Processes are process names of a client that rewrites its config on exit, undoing
+	// changes made while it runs. Apply looks for them before writing (Linux only).
+	Processes []string
+	// Disabled hides the client. It lets clients.yaml turn off a built-in definition.
+	Disabled bool
+	// Catalog names the catalog that defined the client and its version,
+	// e.g. "built-in catalog 2026.10.18" or "clients.yaml". Set when loading.
+	Catalog string `yaml:"-"`
 }
 
 // DetectedClient represents a client found on the system
@@ -670,27 +110,24 @@ type DetectedClient struct {
 	ConfigPath   string
 	ConfigFormat ConfigFormatEnum
 	ConfigKey    string
+	// Evidence lists what showed the client to be installed, and Confidence sums it up
+	Evidence   []Evidence
+	Confidence ConfidenceEnum
+	// Catalog is the catalog that defined the client (see ClientDefinition.Catalog)
+	Catalog string
 }
 
-// UserRegistryFile is the path to the user-defined registry file
-const UserRegistryFile = "clients.yaml"
-
-// DetectClients scans the system for known clients
-func DetectClients() (map[string]DetectedClient, error) {
-	clients := make(map[string]DetectedClient)
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-
-	appData := os.Getenv("APPDATA")
-	userProfile := os.Getenv("USERPROFILE")
-
-	// Pre-calculate base paths
+// BaseDirs resolves every BaseDirEnum against the given home directory and the environment.
+// Bases that don't apply on this system (e.g. %APPDATA% outside Windows) resolve to "".
+func BaseDirs(homeDir string) map[BaseDirEnum]string {
 	basePaths := map[BaseDirEnum]string{
 		BaseHome:        homeDir,
-		BaseAppData:     appData,
-		BaseUserProfile: userProfile,
+		BaseAppData:     os.Getenv("APPDATA"),
+		BaseUserProfile: os.Getenv("USERPROFILE"),
+		BaseXDGConfig:   xdgDir("XDG_CONFIG_HOME", filepath.Join(homeDir, ".config")),
+		BaseXDGData:     xdgDir("XDG_DATA_HOME", filepath.Join(homeDir, ".local", "share")),
+		BaseFlatpak:     filepath.Join(homeDir, ".var", "app"),
+		BaseSnap:        filepath.Join(homeDir, "snap"),
 	}
 
 	// Windows fallback for AppData
@@ -703,27 +140,53 @@ func DetectClients() (map[string]DetectedClient, error) {
 		}
 	}
 
-	// Combine built-in registry with user-defined registry
-	registryToScan := Registry
+	return basePaths
+}
 
-	// Load user-defined registry
-	configDir := filepath.Join(homeDir, ".config", "mcpetes")
-	userRegPath := filepath.Join(configDir, UserRegistryFile)
-	if data, err := os.ReadFile(userRegPath); err == nil {
-		var userClients []ClientDefinition
-		if err := yaml.Unmarshal(data, &userClients); err == nil {
-			registryToScan = append(registryToScan, userClients...)
-		} else {
-			fmt.Printf("Warning: Failed to parse user registry at %s: %v\n", userRegPath, err)
-		}
+// xdgDir returns the directory in the XDG environment variable, or fallback if it is unset.
+// Relative paths are invalid per the XDG Base Directory spec and are ignored as well.
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return fallback
+}
+
+// DetectClients scans the system for known clients. Problems in clients.yaml are returned
+// as the error, alongside the clients detected from the valid definitions.
+func DetectClients() (map[string]DetectedClient, error) {
+	clients := make(map[string]DetectedClient)
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
 	}
 
-	for _, def := range registryToScan {
+	basePaths := BaseDirs(homeDir)
+	probe := newInstallProbe(homeDir)
+
+	// Built-in definitions with clients.yaml applied. Invalid user entries are skipped
+	// and reported after the valid ones have been detected.
+	definitions, defErr := loadDefinitions(homeDir)
+
+	for _, def := range definitions {
 		paths, ok := def.Paths[runtime.GOOS]
 		if !ok {
 			continue
 		}
 
+		// Every matching location is its own target (see installTarget)
+		seen := make(map[string]bool)
+		// Binaries, extensions, desktop entries and processes are the same for every location,
+		// so look for them once, when the first location matches
+		var install []Evidence
+		probed := false
+		installEvidence := func() []Evidence {
+			if !probed {
+				install = probe.installEvidence(def)
+				probed = true
+			}
+			return install
+		}
 		for _, pathDef := range paths {
 			basePath := basePaths[pathDef.Base]
 			if basePath == "" {
@@ -732,33 +195,46 @@ func DetectClients() (map[string]DetectedClient, error) {
 
 			fullPath := filepath.Join(basePath, pathDef.Path)
 
-			// Check if file exists
-			if _, err := os.Stat(fullPath); err == nil {
-				clients[def.ID] = DetectedClient{
-					ID:           def.ID,
-					Name:         def.Name,
-					ConfigPath:   fullPath,
-					ConfigFormat: def.ConfigFormat,
-					ConfigKey:    def.ConfigKey,
+			if pathDef.Glob {
+				for _, globInstall := range detectGlobInstalls(variantDefinition(def, pathDef.Variant), fullPath) {
+					if !seen[globInstall.ConfigPath] {
+						seen[globInstall.ConfigPath] = true
+						clients[globInstall.ID] = withEvidence(def, globInstall, installEvidence())
+					}
 				}
-				break // Found valid config file
+				continue
 			}
 
-			// Fallback: Check if directory exists
-			dirPath := filepath.Dir(fullPath)
-			if _, err := os.Stat(dirPath); err == nil {
-				// Directory exists, allow this client so we can create the config file
-				clients[def.ID] = DetectedClient{
-					ID:           def.ID,
-					Name:         def.Name,
-					ConfigPath:   fullPath,
-					ConfigFormat: def.ConfigFormat,
-					ConfigKey:    def.ConfigKey,
+			if seen[fullPath] {
+				continue
+			}
+
+			// Check if file exists, or fall back to the directory existing
+			// so we can create the config file
+			_, fileErr := os.Stat(fullPath)
+			_, dirErr := os.Stat(filepath.Dir(fullPath))
+			if fileErr != nil && dirErr != nil {
+				continue
+			}
+			seen[fullPath] = true
+
+			target := installTarget(def, paths, pathDef)
+			clients[target.ID] = withEvidence(def, DetectedClient{
+				ID:           target.ID,
+				Name:         target.Name,
+				ConfigPath:   fullPath,
+				ConfigFormat: def.ConfigFormat,
+				ConfigKey:    def.ConfigKey,
+				Catalog:      def.Catalog,
+			}, installEvidence())
+
+			if def.ProfilesDir != "" {
+				for _, profile := range detectProfiles(target, fullPath) {
+					clients[profile.ID] = withEvidence(def, profile, installEvidence())
 				}
-				break
 			}
 		}
 	}
 
-	return clients, nil
+	return clients, defErr
 }
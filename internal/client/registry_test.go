@@ -1,9 +1,15 @@
 package client_test
 
 import (
+	"errors"
+	"maps"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/tuannvm/mcpenetes/internal/client"
@@ -40,6 +46,8 @@ func TestDetectClients_Found(t *testing.T) {
 					basePath = os.Getenv("APPDATA")
 				case client.BaseUserProfile:
 					basePath = os.Getenv("USERPROFILE")
+				default:
+					basePath = client.BaseDirs(tmpHome)[pathDef.Base]
 				}
 
 				targetPath = filepath.Join(basePath, pathDef.Path)
@@ -105,6 +113,8 @@ func TestDetectClients_FallbackDirectory(t *testing.T) {
 					basePath = os.Getenv("APPDATA")
 				case client.BaseUserProfile:
 					basePath = os.Getenv("USERPROFILE")
+				default:
+					basePath = client.BaseDirs(tmpHome)[pathDef.Base]
 				}
 				targetPath = filepath.Join(basePath, pathDef.Path)
 				found = true
@@ -137,3 +147,654 @@ func TestDetectClients_FallbackDirectory(t *testing.T) {
 		t.Logf("Successfully detected windsurf (directory only) at %s", targetPath)
 	}
 }
+
+// TestDetectClients_VSCodeProfiles verifies that the dedicated mcp.json is detected
+// and that every VS Code profile is exposed as its own target.
+func TestDetectClients_VSCodeProfiles(t *testing.T) {
+	tmpHome := t.TempDir()
+
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+	t.Setenv("APPDATA", filepath.Join(tmpHome, "AppData", "Roaming"))
+
+	var targetPath string
+	for _, def := range client.Registry {
+		if def.ID != "vscode" {
+			continue
+		}
+		paths, ok := def.Paths[runtime.GOOS]
+		if !ok || len(paths) == 0 {
+			break
+		}
+		basePath := client.BaseDirs(tmpHome)[paths[0].Base]
+		targetPath = filepath.Join(basePath, paths[0].Path)
+	}
+
+	if targetPath == "" {
+		t.Skip("VS Code not supported on this OS, skipping test")
+	}
+
+	userDir := filepath.Dir(targetPath)
+	for _, dir := range []string{
+		filepath.Join(userDir, "profiles", "-5a1b2c3d"),
+		filepath.Join(userDir, "profiles", "7f8e9d0c"),
+		filepath.Join(userDir, "globalStorage"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+
+	storage := `{"userDataProfiles": [{"location": "-5a1b2c3d", "name": "Work Stuff"}]}`
+	if err := os.WriteFile(filepath.Join(userDir, "globalStorage", "storage.json"), []byte(storage), 0644); err != nil {
+		t.Fatalf("Failed to write storage.json: %v", err)
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+
+	main, ok := detected["vscode"]
+	if !ok {
+		t.Fatalf("Expected to detect 'vscode'")
+	}
+	if filepath.Base(main.ConfigPath) != "mcp.json" || main.ConfigFormat != client.FormatVSCodeMCP {
+		t.Errorf("Expected vscode to target mcp.json with vscode-mcp format, got %s (%s)", main.ConfigPath, main.ConfigFormat)
+	}
+
+	named, ok := detected["vscode:work-stuff"]
+	if !ok {
+		t.Fatalf("Expected named profile 'vscode:work-stuff' to be detected, got %v", detected)
+	}
+	if named.ConfigPath != filepath.Join(userDir, "profiles", "-5a1b2c3d", "mcp.json") {
+		t.Errorf("Unexpected profile config path: %s", named.ConfigPath)
+	}
+
+	if _, ok := detected["vscode:7f8e9d0c"]; !ok {
+		t.Errorf("Expected unnamed profile 'vscode:7f8e9d0c' to fall back to its directory name")
+	}
+}
+
+// TestDetectClients_JetBrainsInstalls verifies that every installed IDE version matching a
+// glob path is detected as its own target.
+func TestDetectClients_JetBrainsInstalls(t *testing.T) {
+	tmpHome := t.TempDir()
+
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+	t.Setenv("APPDATA", filepath.Join(tmpHome, "AppData", "Roaming"))
+
+	var pattern string
+	for _, def := range client.Registry {
+		if def.ID != "jetbrains-ai" {
+			continue
+		}
+		paths, ok := def.Paths[runtime.GOOS]
+		if !ok || len(paths) == 0 {
+			break
+		}
+		basePath := client.BaseDirs(tmpHome)[paths[0].Base]
+		pattern = filepath.Join(basePath, paths[0].Path)
+	}
+
+	if pattern == "" {
+		t.Skip("JetBrains AI Assistant not supported on this OS, skipping test")
+	}
+
+	jetbrainsDir := filepath.Dir(filepath.Dir(filepath.Dir(pattern)))
+	for _, product := range []string{"GoLand2024.3", "PyCharm2025.1"} {
+		if err := os.MkdirAll(filepath.Join(jetbrainsDir, product, "options"), 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+	// A product directory without options/ isn't an IDE config directory
+	if err := os.MkdirAll(filepath.Join(jetbrainsDir, "consentOptions"), 0755); err != nil {
+		t.Fatalf("Failed to create test directories: %v", err)
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+
+	goland, ok := detected["jetbrains-ai:goland2024.3"]
+	if !ok {
+		t.Fatalf("Expected 'jetbrains-ai:goland2024.3' to be detected, got %v", detected)
+	}
+	if goland.ConfigPath != filepath.Join(jetbrainsDir, "GoLand2024.3", "options", "llm.mcpServers.xml") {
+		t.Errorf("Unexpected config path: %s", goland.ConfigPath)
+	}
+	if _, ok := detected["jetbrains-ai:pycharm2025.1"]; !ok {
+		t.Errorf("Expected 'jetbrains-ai:pycharm2025.1' to be detected")
+	}
+	if _, ok := detected["jetbrains-ai:consentoptions"]; ok {
+		t.Errorf("Did not expect a directory without options/ to be detected")
+	}
+}
+
+// TestCapabilities_UserOverrides verifies that capabilities from clients.yaml start from the
+// defaults, and that profile IDs resolve to their base client.
+func TestCapabilities_UserOverrides(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+
+	userRegistry := `
+- id: my-tool
+  name: My Tool
+  configformat: simple-json
+  capabilities:
+    url: false
+    fields:
+      autoApprove: allowedTools
+`
+	configDir := filepath.Join(tmpHome, ".config", "mcpetes")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, client.UserRegistryFile), []byte(userRegistry), 0644); err != nil {
+		t.Fatalf("Failed to write clients.yaml: %v", err)
+	}
+
+	caps := client.LookupCapabilities("my-tool")
+	if caps.URL {
+		t.Errorf("Expected url to be disabled by clients.yaml")
+	}
+	if !caps.Env || !caps.AutoApprove {
+		t.Errorf("Expected unspecified capabilities to keep their defaults, got %+v", caps)
+	}
+	if caps.FieldName("autoApprove") != "allowedTools" || caps.FieldName("env") != "env" {
+		t.Errorf("Unexpected field names: %+v", caps.Fields)
+	}
+
+	if client.LookupCapabilities("cline:work").FieldName("autoApprove") != "alwaysAllow" {
+		t.Errorf("Expected profile ID to resolve to Cline's capabilities")
+	}
+	if !client.LookupCapabilities("unknown").URL {
+		t.Errorf("Expected unknown clients to get default capabilities")
+	}
+}
+
+// TestDetectClients_MultipleInstalls verifies that every matching path becomes its own target:
+// the first path keeps the plain ID, named variants get a suffix and further paths a hash of
+// the path, which stays the same when other paths disappear.
+func TestDetectClients_MultipleInstalls(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+	t.Setenv("APPDATA", filepath.Join(tmpHome, "AppData", "Roaming"))
+
+	userRegistry := `
+- id: multi
+  name: Multi
+  configformat: simple-json
+  paths:
+    ` + runtime.GOOS + `:
+      - base: home
+        path: .multi/mcp.json
+      - base: home
+        path: .multi/mcp.json
+      - base: home
+        path: alt/multi/mcp.json
+      - base: home
+        path: sandbox/multi/mcp.json
+        variant: sandbox
+      - base: home
+        path: missing/multi/mcp.json
+`
+	configDir := filepath.Join(tmpHome, ".config", "mcpetes")
+	for _, dir := range []string{configDir, ".multi", "alt/multi", "sandbox/multi"} {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(tmpHome, dir)
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(configDir, client.UserRegistryFile), []byte(userRegistry), 0644); err != nil {
+		t.Fatalf("Failed to write clients.yaml: %v", err)
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+
+	want := map[string]string{
+		"multi":         filepath.Join(tmpHome, ".multi", "mcp.json"),
+		"multi#6b4f9e":  filepath.Join(tmpHome, "alt", "multi", "mcp.json"),
+		"multi-sandbox": filepath.Join(tmpHome, "sandbox", "multi", "mcp.json"),
+	}
+	for id, path := range want {
+		got, ok := detected[id]
+		if !ok {
+			t.Errorf("Expected target %q to be detected", id)
+			continue
+		}
+		if got.ConfigPath != path {
+			t.Errorf("%s: config path = %s, want %s", id, got.ConfigPath, path)
+		}
+	}
+	for id := range detected {
+		if strings.HasPrefix(id, "multi") && want[id] == "" {
+			t.Errorf("Duplicate or missing paths must not produce extra targets, got %q", id)
+		}
+	}
+
+	// Without the first path, the others keep their IDs
+	if err := os.RemoveAll(filepath.Join(tmpHome, ".multi")); err != nil {
+		t.Fatal(err)
+	}
+	detected, err = client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+	if _, ok := detected["multi"]; ok {
+		t.Errorf("multi detected without its first path")
+	}
+	if got := detected["multi#6b4f9e"]; got.ConfigPath != want["multi#6b4f9e"] {
+		t.Errorf("multi#6b4f9e: config path = %q after the first path disappeared", got.ConfigPath)
+	}
+
+	for _, id := range []string{"multi#6b4f9e", "multi-sandbox", "multi:profile"} {
+		if def, ok := client.FindDefinition(id); !ok || def.ID != "multi" {
+			t.Errorf("FindDefinition(%q) = %v, %v; want multi", id, def.ID, ok)
+		}
+	}
+}
+
+// TestDetectClients_LinuxPackaging verifies that $XDG_CONFIG_HOME is honoured and that
+// Flatpak and Snap installs are detected as their own variants.
+func TestDetectClients_LinuxPackaging(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Flatpak, Snap and XDG paths only apply to Linux")
+	}
+
+	tmpHome := t.TempDir()
+	xdgConfig := filepath.Join(tmpHome, "custom-config")
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("XDG_CONFIG_HOME", xdgConfig)
+
+	for _, dir := range []string{
+		filepath.Join(xdgConfig, "Code", "User"),
+		filepath.Join(tmpHome, ".var", "app", "com.visualstudio.code", "config", "Code", "User"),
+		filepath.Join(tmpHome, "snap", "code", "current", ".config", "Code", "User"),
+		filepath.Join(tmpHome, ".var", "app", "dev.zed.Zed", "config", "zed"),
+		// Ignored: ~/.config is not used when $XDG_CONFIG_HOME is set
+		filepath.Join(tmpHome, ".config", "Claude"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+
+	want := map[string]string{
+		"vscode":         filepath.Join(xdgConfig, "Code", "User", "mcp.json"),
+		"vscode-flatpak": filepath.Join(tmpHome, ".var", "app", "com.visualstudio.code", "config", "Code", "User", "mcp.json"),
+		"vscode-snap":    filepath.Join(tmpHome, "snap", "code", "current", ".config", "Code", "User", "mcp.json"),
+		"zed-flatpak":    filepath.Join(tmpHome, ".var", "app", "dev.zed.Zed", "config", "zed", "settings.json"),
+	}
+	for id, path := range want {
+		got, ok := detected[id]
+		if !ok {
+			t.Errorf("Expected %q to be detected", id)
+			continue
+		}
+		if got.ConfigPath != path {
+			t.Errorf("%s: config path = %s, want %s", id, got.ConfigPath, path)
+		}
+	}
+	if _, ok := detected["zed"]; ok {
+		t.Errorf("Did not expect native Zed to be detected")
+	}
+	if _, ok := detected["claude-desktop"]; ok {
+		t.Errorf("Did not expect ~/.config to be scanned when $XDG_CONFIG_HOME is set")
+	}
+}
+
+func TestDetectClients_Confidence(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Desktop entries and the XDG layout only apply to Linux")
+	}
+
+	tmpHome := t.TempDir()
+	binDir := filepath.Join(tmpHome, "bin")
+	dataDirs := filepath.Join(tmpHome, "share")
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_DATA_DIRS", dataDirs)
+	t.Setenv("PATH", binDir)
+
+	for _, dir := range []string{
+		binDir,
+		filepath.Join(dataDirs, "applications"),
+		filepath.Join(tmpHome, ".cursor"),              // Cursor: config file + binary
+		filepath.Join(tmpHome, ".codeium", "windsurf"), // Windsurf: desktop entry only
+		filepath.Join(tmpHome, ".aws", "amazonq"),      // Amazon Q: config file only
+		filepath.Join(tmpHome, ".config", "goose"),     // Goose: leftover directory
+		// Cline: settings left behind by an uninstalled extension
+		filepath.Join(tmpHome, ".config", "Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings"),
+		// Roo Code: installed extension, settings not written yet
+		filepath.Join(tmpHome, ".config", "Code", "User", "globalStorage", "rooveterinaryinc.roo-cline", "settings"),
+		filepath.Join(tmpHome, ".vscode", "extensions", "rooveterinaryinc.roo-cline-3.28.0"),
+		// Not Cline: another extension whose ID starts like it
+		filepath.Join(tmpHome, ".vscode", "extensions", "saoudrizwan.claude-dev-nightly-1.0.0"),
+	} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+	for path, mode := range map[string]os.FileMode{
+		filepath.Join(binDir, "cursor"):                             0755,
+		filepath.Join(dataDirs, "applications", "windsurf.desktop"): 0644,
+		filepath.Join(tmpHome, ".cursor", "mcp.json"):               0644,
+		filepath.Join(tmpHome, ".aws", "amazonq", "mcp.json"):       0644,
+		filepath.Join(tmpHome, ".config", "Code", "User", "globalStorage", "saoudrizwan.claude-dev", "settings", "cline_mcp_settings.json"): 0644,
+	} {
+		if err := os.WriteFile(path, []byte("{}"), mode); err != nil {
+			t.Fatalf("Failed to create %s: %v", path, err)
+		}
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+
+	want := map[string]struct {
+		confidence client.ConfidenceEnum
+		evidence   []client.EvidenceEnum
+	}{
+		"cursor":   {client.ConfidenceHigh, []client.EvidenceEnum{client.EvidenceConfigFile, client.EvidenceBinary}},
+		"windsurf": {client.ConfidenceMedium, []client.EvidenceEnum{client.EvidenceConfigDir, client.EvidenceDesktopFile}},
+		"amazon-q": {client.ConfidenceMedium, []client.EvidenceEnum{client.EvidenceConfigFile}},
+		"goose":    {client.ConfidenceLow, []client.EvidenceEnum{client.EvidenceConfigDir}},
+		"cline":    {client.ConfidenceLow, []client.EvidenceEnum{client.EvidenceConfigFile}},
+		"roo-code": {client.ConfidenceMedium, []client.EvidenceEnum{client.EvidenceConfigDir, client.EvidenceExtension}},
+	}
+	for id, w := range want {
+		got, ok := detected[id]
+		if !ok {
+			t.Errorf("Expected %q to be detected", id)
+			continue
+		}
+		if got.Confidence != w.confidence {
+			t.Errorf("%s: confidence = %s, want %s", id, got.Confidence, w.confidence)
+		}
+		var kinds []client.EvidenceEnum
+		for _, e := range got.Evidence {
+			// The test binary may match a running process; only check the deterministic signs
+			if e.Kind != client.EvidenceProcess {
+				kinds = append(kinds, e.Kind)
+			}
+		}
+		if !reflect.DeepEqual(kinds, w.evidence) {
+			t.Errorf("%s: evidence = %v, want %v", id, kinds, w.evidence)
+		}
+	}
+}
+
+// TestDefinitions_UserOverrides verifies that clients.yaml entries override built-ins by ID,
+// keeping the fields they don't set, and that "disabled: true" hides a built-in client.
+func TestDefinitions_UserOverrides(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+
+	userRegistry := `
+- id: cursor
+  paths:
+    ` + runtime.GOOS + `:
+      - base: home
+        path: custom/cursor/mcp.json
+  capabilities:
+    max_tools: 80
+- id: windsurf
+  disabled: true
+`
+	configDir := filepath.Join(tmpHome, ".config", "mcpetes")
+	for _, dir := range []string{configDir, filepath.Join(tmpHome, "custom", "cursor"), filepath.Join(tmpHome, ".codeium", "windsurf")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create test directories: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(configDir, client.UserRegistryFile), []byte(userRegistry), 0644); err != nil {
+		t.Fatalf("Failed to write clients.yaml: %v", err)
+	}
+
+	cursorCount := 0
+	for _, def := range client.Definitions() {
+		if def.ID == "cursor" {
+			cursorCount++
+			if def.Name != "Cursor" || def.ConfigFormat != client.FormatSimpleJSON {
+				t.Errorf("Expected unset fields to keep their built-in values, got %+v", def)
+			}
+		}
+		if def.ID == "windsurf" {
+			t.Errorf("Expected windsurf to be hidden")
+		}
+	}
+	if cursorCount != 1 {
+		t.Errorf("Expected one cursor definition, got %d", cursorCount)
+	}
+
+	caps := client.LookupCapabilities("cursor")
+	if caps.MaxTools != 80 || caps.Disabled {
+		t.Errorf("Expected capabilities to start from the built-in ones, got %+v", caps)
+	}
+
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+	if got := detected["cursor"].ConfigPath; got != filepath.Join(tmpHome, "custom", "cursor", "mcp.json") {
+		t.Errorf("cursor: config path = %s", got)
+	}
+	if _, ok := detected["windsurf"]; ok {
+		t.Errorf("Did not expect a disabled client to be detected")
+	}
+
+	// The built-in definition itself is left untouched
+	for _, def := range client.Registry {
+		if def.ID == "cursor" && def.Capabilities.MaxTools != 40 {
+			t.Errorf("Override leaked into the built-in definition: %+v", def.Capabilities)
+		}
+	}
+
+	// Definitions are loaded again once clients.yaml changes
+	if err := os.Remove(filepath.Join(configDir, client.UserRegistryFile)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.FindDefinition("windsurf"); !ok {
+		t.Errorf("Expected windsurf to be back once clients.yaml is removed")
+	}
+}
+
+// TestValidateDefinitions verifies that every problem is reported with its line number.
+func TestValidateDefinitions(t *testing.T) {
+	data := `- id: my-tool
+  configformat: simple-jsn
+  paths:
+    linux:
+      - base: xdg
+        path: /etc/my-tool.json
+    plan9:
+      - base: home
+        path: ../outside.json
+  colour: blue
+- id: my-tool
+  configformat: yaml
+- id: Bad:ID
+- id: no-format
+- id: cursor
+  name: [not, a, string]
+`
+	err := client.ValidateDefinitions("clients.yaml", []byte(data))
+	var defErrs client.DefinitionErrors
+	if !errors.As(err, &defErrs) {
+		t.Fatalf("Expected DefinitionErrors, got %v", err)
+	}
+
+	want := []string{
+		`clients.yaml:10: unknown client field "colour"`,
+		`clients.yaml:2: unknown configformat "simple-jsn"`,
+		`clients.yaml:5: unknown base "xdg"`,
+		`clients.yaml:6: path "/etc/my-tool.json" must be relative to its base`,
+		`clients.yaml:7: unknown OS "plan9"`,
+		`clients.yaml:9: path "../outside.json" must not leave its base directory`,
+		`clients.yaml:11: duplicate id "my-tool", first defined on line 1`,
+		`clients.yaml:13: invalid id "Bad:ID"`,
+		`clients.yaml:14: client "no-format" is not built in, so it needs a configformat`,
+		`clients.yaml:16: cannot unmarshal`,
+	}
+	got := err.Error()
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("Expected error containing %q, got:\n%s", w, got)
+		}
+	}
+
+	if err := client.ValidateDefinitions("clients.yaml", []byte("- id: cursor\n  disabled: true\n")); err != nil {
+		t.Errorf("Expected a valid file, got %v", err)
+	}
+}
+
+// TestCatalog_Update verifies that an installed catalog replaces the built-in one only
+// while it is newer, and that detection reports the catalog of each client.
+func TestCatalog_Update(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("USERPROFILE", tmpHome)
+
+	if client.RegistryVersion == "" || len(client.Registry) == 0 {
+		t.Fatalf("Expected the built-in catalog to have a version and clients")
+	}
+	for _, def := range client.Registry {
+		if def.Catalog != "built-in catalog "+client.RegistryVersion {
+			t.Errorf("%s: catalog = %q", def.ID, def.Catalog)
+		}
+	}
+
+	catalog := func(version string) []byte {
+		return []byte(`version: ` + version + `
+clients:
+  - id: new-tool
+    configformat: simple-json
+    paths:
+      ` + runtime.GOOS + `:
+        - base: home
+          path: .new-tool/mcp.json
+`)
+	}
+
+	if _, _, err := client.InstallCatalog(tmpHome, "old.yaml", catalog("2000.1.1")); err == nil {
+		t.Errorf("Expected an older catalog to be rejected")
+	}
+	if _, _, err := client.InstallCatalog(tmpHome, "bad.yaml", []byte("clients:\n  - id: x\n")); err == nil {
+		t.Errorf("Expected a catalog without a version or configformat to be rejected")
+	}
+	if _, err := os.Stat(client.CatalogPath(tmpHome)); !os.IsNotExist(err) {
+		t.Fatalf("Expected rejected catalogs not to be saved")
+	}
+
+	newer := client.RegistryVersion + ".1"
+	if _, version, err := client.InstallCatalog(tmpHome, "new.yaml", catalog(newer)); err != nil || version != newer {
+		t.Fatalf("InstallCatalog = %q, %v", version, err)
+	}
+	if version, path := client.ActiveCatalog(); version != newer || path != client.CatalogPath(tmpHome) {
+		t.Errorf("ActiveCatalog = %q, %q", version, path)
+	}
+
+	if err := os.MkdirAll(filepath.Join(tmpHome, ".new-tool"), 0755); err != nil {
+		t.Fatalf("Failed to create test directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpHome, ".new-tool", "mcp.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+	if got := detected["new-tool"].Catalog; got != "updated catalog "+newer {
+		t.Errorf("new-tool: catalog = %q", got)
+	}
+
+	// A later release shipping a newer built-in catalog overtakes the installed one
+	if err := os.WriteFile(client.CatalogPath(tmpHome), catalog("2000.1.1"), 0644); err != nil {
+		t.Fatalf("Failed to write catalog: %v", err)
+	}
+	if version, path := client.ActiveCatalog(); version != client.RegistryVersion || path != "" {
+		t.Errorf("Expected the built-in catalog to be used, got %q, %q", version, path)
+	}
+
+	if client.CompareVersions("2026.10.18", "2026.9.30") != 1 || client.CompareVersions("1.2", "1.2.0") != 0 {
+		t.Errorf("CompareVersions compared parts as strings")
+	}
+}
+
+// TestRunningProcesses verifies that a client's declared processes are found in /proc.
+func TestRunningProcesses(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process detection reads /proc")
+	}
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+
+	configDir := filepath.Join(tmpHome, ".config", "mcpetes")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directories: %v", err)
+	}
+	userRegistry := `
+- id: sleeper
+  configformat: simple-json
+  processes: [sleep]
+  paths:
+    linux:
+      - base: home
+        path: .sleeper/mcp.json
+      - base: home
+        path: alt/sleeper/mcp.json
+- id: cursor
+  processes: [mcpenetes-no-such-process]
+`
+	if err := os.WriteFile(filepath.Join(configDir, client.UserRegistryFile), []byte(userRegistry), 0644); err != nil {
+		t.Fatalf("Failed to write clients.yaml: %v", err)
+	}
+
+	sleep := exec.Command("sleep", "30")
+	if err := sleep.Start(); err != nil {
+		t.Skipf("Cannot start sleep: %v", err)
+	}
+	defer func() {
+		_ = sleep.Process.Kill()
+		_ = sleep.Wait()
+	}()
+
+	// Every install of a client shares its processes; the second path is "sleeper#<hash>"
+	if err := os.MkdirAll(filepath.Join(tmpHome, "alt", "sleeper"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	detected, err := client.DetectClients()
+	if err != nil {
+		t.Fatalf("DetectClients failed: %v", err)
+	}
+	if _, ok := detected["sleeper#0c6115"]; !ok {
+		t.Fatalf("Expected the second install as sleeper#0c6115, got %v", slices.Collect(maps.Keys(detected)))
+	}
+	if got := client.RunningProcesses("sleeper#0c6115"); !reflect.DeepEqual(got, []string{"sleep"}) {
+		t.Errorf("RunningProcesses(sleeper#0c6115) = %v", got)
+	}
+	if got := client.RunningProcesses("cursor"); got != nil {
+		t.Errorf("RunningProcesses(cursor) = %v", got)
+	}
+	if got := client.RunningProcesses("unknown"); got != nil {
+		t.Errorf("RunningProcesses(unknown) = %v", got)
+	}
+}
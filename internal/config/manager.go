@@ -42,6 +42,12 @@ func getConfigPaths() (configFilePath, mcpFilePath string, err error) {
 	return configFilePath, mcpFilePath, nil
 }
 
+// MCPConfigPath returns the path of the local mcp.json file.
+func MCPConfigPath() (string, error) {
+	_, mcpFilePath, err := getConfigPaths()
+	return mcpFilePath, err
+}
+
 // GetDefaultConfig returns the default configuration structure.
 func GetDefaultConfig() *Config {
 	// Define default values here
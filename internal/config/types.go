@@ -11,8 +11,16 @@ type Config struct {
 
 // Registry defines a registry endpoint
 type Registry struct {
-	Name string `yaml:"name"`
-	URL  string `yaml:"url"`
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url" json:"url"`
+	// Type is the registry's API, e.g. "glama" (see registry.Types). Empty detects it
+	// from the URL and the shape of the response.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// PublicKeys are the base64 ed25519 keys trusted to sign the registry's payloads.
+	// A payload with a bad signature is rejected.
+	PublicKeys []string `yaml:"public_keys,omitempty" json:"public_keys,omitempty"`
+	// RequireSignature rejects unsigned payloads too
+	RequireSignature bool `yaml:"require_signature,omitempty" json:"require_signature,omitempty"`
 }
 
 // Client defines a target client configuration location
@@ -23,6 +31,20 @@ type Client struct {
 	Type string `yaml:"type,omitempty"`
 	// Key overrides the default JSON key if set (e.g. "openctx.providers")
 	Key string `yaml:"key,omitempty"`
+	// Scope selects the part of a multi-scope config to manage (Claude Code CLI):
+	// "user" (default), "project" or "repo"
+	Scope string `yaml:"scope,omitempty"`
+	// Project is the absolute project directory used by the "project" and "repo" scopes
+	Project string `yaml:"project,omitempty"`
+	// Disabled excludes the client from apply. An entry with only this field set toggles
+	// a detected client by its target ID (e.g. "cursor#26a77d": {disabled: true})
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Confidence ("high", "medium" or "low") and Evidence explain why a detected client
+	// was picked, and Catalog names the client catalog and version that defined it. They
+	// are filled in by detection and never saved.
+	Confidence string   `yaml:"-"`
+	Evidence   []string `yaml:"-"`
+	Catalog    string   `yaml:"-"`
 }
 
 // BackupConfig defines backup settings
@@ -46,4 +68,17 @@ type MCPServer struct {
 	Env         map[string]string `json:"env,omitempty"`
 	Disabled    bool              `json:"disabled,omitempty"`
 	AutoApprove []string          `json:"autoApprove,omitempty"`
+	// SecretEnv lists env keys whose values are secrets. Clients that can prompt for
+	// secrets (VS Code "inputs") reference them instead of having the value inlined.
+	SecretEnv []string `json:"secretEnv,omitempty"`
+	// Permissions controls access to individual tools. Each client gets them in its own keys
+	// (e.g. "alwaysAllow", "disabledTools"); clients that can't enforce them are warned about.
+	Permissions *ToolPermissions `json:"permissions,omitempty"`
+}
+
+// ToolPermissions lists tools by name for a single server
+type ToolPermissions struct {
+	AutoApprove []string `json:"autoApprove,omitempty"` // Run without asking for confirmation
+	Deny        []string `json:"deny,omitempty"`        // Never allowed to run
+	Hide        []string `json:"hide,omitempty"`        // Not offered to the model at all
 }
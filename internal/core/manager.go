@@ -2,8 +2,13 @@ package core
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
+	"github.com/tuannvm/mcpenetes/internal/client"
 	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/search"
 	"github.com/tuannvm/mcpenetes/internal/translator"
 )
 
@@ -12,6 +17,16 @@ type Manager struct {
 	Config    *config.Config
 	MCPConfig *config.MCPConfig
 	Trans     *translator.Translator
+	// RunningPolicy and WaitTimeout decide what happens when a client is running (see
+	// RunningPolicyEnum). OnWait, if set, is called before waiting for a client to exit.
+	RunningPolicy RunningPolicyEnum
+	WaitTimeout   time.Duration
+	OnWait        func(clientName string, running []string)
+
+	// counts caches toolCounts
+	counts map[string]int
+	// processes replaces client.RunningProcesses in tests
+	processes func(clientName string) []string
 }
 
 // NewManager creates a new Manager instance.
@@ -20,21 +35,55 @@ func NewManager(cfg *config.Config, mcpCfg *config.MCPConfig) *Manager {
 		Config:    cfg,
 		MCPConfig: mcpCfg,
 		Trans:     translator.NewTranslator(cfg, mcpCfg),
+
+		RunningPolicy: RunningWarn,
+		WaitTimeout:   DefaultWaitTimeout,
 	}
 }
 
+// toolCounts returns the number of tools of the servers in mcp.json, as far as the index
+// or the registries' cache know.
+func (m *Manager) toolCounts() map[string]int {
+	if m.counts != nil {
+		return m.counts
+	}
+	m.counts = make(map[string]int)
+	idx, err := search.LoadIndex()
+	if err != nil {
+		return m.counts
+	}
+	names := slices.Collect(maps.Keys(m.MCPConfig.MCPServers))
+	m.counts = idx.ToolCounts(m.Config.Registries, names)
+	return m.counts
+}
+
 // ApplyResult holds the result of an apply operation for a single client.
 type ApplyResult struct {
 	ClientName string
 	Success    bool
 	BackupPath string
 	Error      error
+	// Warnings lists server settings the client could not represent
+	Warnings []translator.Warning
+	// Running lists the client's processes found running before the write. Skipped is
+	// set if the config was left untouched because of them, and Waited if they exited.
+	Running []string
+	Skipped bool
+	Waited  bool
 }
 
 // ApplyToClient applies the current MCP configuration to a specific client.
 // It handles backup, translation/application of all servers, and cleanup of obsolete servers.
-func (m *Manager) ApplyToClient(clientName string, clientConf config.Client) ApplyResult {
-	res := ApplyResult{ClientName: clientName, Success: true}
+func (m *Manager) ApplyToClient(clientName string, clientConf config.Client) (res ApplyResult) {
+	res = ApplyResult{ClientName: clientName, Success: true}
+	defer func() {
+		res.Warnings = m.Trans.TakeWarnings()
+	}()
+
+	// 0. A running client may undo the changes when it exits
+	if !m.checkRunning(clientName, &res) {
+		return res
+	}
 
 	// 1. Backup
 	backupPath, err := m.Trans.BackupClientConfig(clientName, clientConf)
@@ -54,6 +103,9 @@ func (m *Manager) ApplyToClient(clientName string, clientConf config.Client) App
 			return res
 		}
 	}
+	if client.LookupCapabilities(clientName).MaxTools > 0 {
+		m.Trans.CheckClientLimits(clientName, m.toolCounts())
+	}
 
 	// 3. Clean Obsolete
 	err = m.Trans.RemoveClientServers(clientName, clientConf)
@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/translator"
 	"github.com/tuannvm/mcpenetes/internal/util"
 )
 
@@ -36,17 +38,17 @@ func (m *Manager) ListBackups() (map[string][]BackupFile, error) {
 
 	backups := make(map[string][]BackupFile)
 	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
 
 		fileName := entry.Name()
+		if strings.HasSuffix(fileName, translator.BackupTargetExt) {
+			continue // Records what the backup next to it is of
+		}
 		// Format: <clientName>-<timestamp>.<ext>
+		// Directory-backed clients are snapshotted as <clientName>-<timestamp>/ (no extension)
 		// Timestamp: YYYYMMDD-HHMMSS (15 chars)
 		// We need to robustly parse this.
 
@@ -54,8 +56,10 @@ func (m *Manager) ListBackups() (map[string][]BackupFile, error) {
 		// Actually the timestamp is fixed length 15 chars.
 		// Let's assume the format is strictly clientName-YYYYMMDD-HHMMSS.ext
 
-		ext := filepath.Ext(fileName)
-		nameWithoutExt := strings.TrimSuffix(fileName, ext)
+		nameWithoutExt := fileName
+		if !entry.IsDir() {
+			nameWithoutExt = strings.TrimSuffix(fileName, filepath.Ext(fileName))
+		}
 
 		// Extract timestamp from end (15 chars)
 		if len(nameWithoutExt) <= 16 { // at least 1 char name + hyphen + 15 char timestamp
@@ -67,10 +71,11 @@ func (m *Manager) ListBackups() (map[string][]BackupFile, error) {
 			continue // Expected hyphen before timestamp
 		}
 
-		clientName := nameWithoutExt[:len(nameWithoutExt)-16]
+		// Target IDs like "vscode:work" are escaped in file names
+		clientName := translator.BackupClientID(nameWithoutExt[:len(nameWithoutExt)-16])
 
 		// Parse timestamp
-		ts, err := time.Parse("20060102-150405", timestampStr)
+		ts, err := time.Parse(translator.BackupTimeFormat, timestampStr)
 		if err != nil {
 			// If strict parsing fails, fallback to file mod time or skip
 			ts = info.ModTime()
@@ -110,17 +115,71 @@ func (m *Manager) RestoreClient(clientName, backupFileName string) error {
 	backupPath := filepath.Join(backupDir, backupFileName)
 
 	// Verify backup file exists
-	if _, err := os.Stat(backupPath); err != nil {
+	backupInfo, err := os.Stat(backupPath)
+	if err != nil {
 		return fmt.Errorf("backup file '%s' not found: %w", backupFileName, err)
 	}
 
-	clientConfigPath, err := util.ExpandPath(clientConf.ConfigPath)
+	// Restore to the file the backup was taken of, which the client's scope decided
+	target, err := translator.ReadBackupTarget(backupPath, clientName, clientConf)
 	if err != nil {
-		return fmt.Errorf("error expanding client config path: %w", err)
+		return err
+	}
+
+	if backupInfo.IsDir() {
+		return restoreDir(backupPath, target.Path)
+	}
+	if client.ConfigFormatEnum(target.Type) == client.FormatClaudeCode {
+		return translator.RestoreClaudeCodeServers(backupPath, target)
 	}
 
 	// Perform copy
-	return copyFile(backupPath, clientConfigPath)
+	return copyFile(backupPath, target.Path)
+}
+
+// rename is os.Rename; tests replace it to make the swap in restoreDir fail.
+var rename = os.Rename
+
+// restoreDir atomically replaces the directory dst with a copy of the snapshot src.
+// The snapshot is staged next to dst and swapped in with renames, so a failure
+// part-way through leaves the original directory in place.
+func restoreDir(src, dst string) error {
+	parent := filepath.Dir(dst)
+	if err := os.MkdirAll(parent, 0750); err != nil {
+		return fmt.Errorf("failed to create destination directory '%s': %w", parent, err)
+	}
+
+	stagingDir, err := os.MkdirTemp(parent, "."+filepath.Base(dst)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := util.CopyDir(src, stagingDir); err != nil {
+		_ = os.RemoveAll(stagingDir)
+		return fmt.Errorf("failed to stage backup '%s': %w", src, err)
+	}
+
+	// Move the current directory out of the way (if any), then swap the staged copy in
+	oldDir := ""
+	if _, err := os.Stat(dst); err == nil {
+		oldDir = stagingDir + ".old"
+		if err := rename(dst, oldDir); err != nil {
+			_ = os.RemoveAll(stagingDir)
+			return fmt.Errorf("failed to move current directory '%s' aside: %w", dst, err)
+		}
+	}
+
+	if err := rename(stagingDir, dst); err != nil {
+		if oldDir != "" {
+			_ = rename(oldDir, dst) // Roll back
+		}
+		_ = os.RemoveAll(stagingDir)
+		return fmt.Errorf("failed to restore directory '%s': %w", dst, err)
+	}
+
+	if oldDir != "" {
+		_ = os.RemoveAll(oldDir)
+	}
+	return nil
 }
 
 // RestoreAllLatest restores the latest backup for every client
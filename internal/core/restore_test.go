@@ -0,0 +1,210 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// TestRestoreClient_ClaudeCodeScopes verifies that a backup of each Claude Code scope is
+// restored to the file it was taken of, and that restoring ~/.claude.json only puts back
+// the scope's servers.
+func TestRestoreClient_ClaudeCodeScopes(t *testing.T) {
+	tmpDir := t.TempDir()
+	userPath := filepath.Join(tmpDir, ".claude.json")
+	project := filepath.Join(tmpDir, "project")
+	other := filepath.Join(tmpDir, "other")
+	repoPath := filepath.Join(project, ".mcp.json")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	writeJSON := func(path string, v interface{}) {
+		t.Helper()
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readJSON := func(path string) map[string]interface{} {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		return v
+	}
+	servers := func(ids ...string) map[string]interface{} {
+		m := make(map[string]interface{})
+		for _, id := range ids {
+			m[id] = map[string]interface{}{"command": id}
+		}
+		return m
+	}
+	// state is ~/.claude.json with Claude Code's own state at a given count
+	state := func(startups float64, userServers, projectServers map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"numStartups": startups,
+			"mcpServers":  userServers,
+			"projects": map[string]interface{}{
+				project: map[string]interface{}{"mcpServers": projectServers},
+				other:   map[string]interface{}{"history": []interface{}{startups}},
+			},
+		}
+	}
+
+	tests := []struct {
+		scope string
+		// want is ~/.claude.json and .mcp.json after the restore
+		wantUser, wantRepo map[string]interface{}
+	}{
+		{scope: "user",
+			wantUser: state(2, servers("git"), servers("new-project")),
+			wantRepo: map[string]interface{}{"mcpServers": servers("new-repo")}},
+		{scope: "project",
+			wantUser: state(2, servers("new-user"), servers("files")),
+			wantRepo: map[string]interface{}{"mcpServers": servers("new-repo")}},
+		{scope: "repo",
+			wantUser: state(2, servers("new-user"), servers("new-project")),
+			wantRepo: map[string]interface{}{"mcpServers": servers("docs")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			writeJSON(userPath, state(1, servers("git"), servers("files")))
+			writeJSON(repoPath, map[string]interface{}{"mcpServers": servers("docs")})
+
+			clientConf := config.Client{ConfigPath: userPath, Type: "claude-code", Scope: tt.scope, Project: project}
+			cfg := &config.Config{
+				Backups: config.BackupConfig{Path: filepath.Join(tmpDir, "backups-"+tt.scope)},
+				Clients: map[string]config.Client{"claude-code": clientConf},
+			}
+			m := NewManager(cfg, &config.MCPConfig{})
+			if _, err := m.Trans.BackupClientConfig("claude-code", clientConf); err != nil {
+				t.Fatalf("BackupClientConfig failed: %v", err)
+			}
+
+			// Both files change, and the client is moved to another scope before the restore
+			writeJSON(userPath, state(2, servers("new-user"), servers("new-project")))
+			writeJSON(repoPath, map[string]interface{}{"mcpServers": servers("new-repo")})
+			cfg.Clients["claude-code"] = config.Client{ConfigPath: userPath, Type: "claude-code"}
+
+			backups, err := m.ListBackups()
+			if err != nil || len(backups["claude-code"]) != 1 || len(backups) != 1 {
+				t.Fatalf("ListBackups = %v, %v", backups, err)
+			}
+			if err := m.RestoreClient("claude-code", backups["claude-code"][0].Name); err != nil {
+				t.Fatalf("RestoreClient failed: %v", err)
+			}
+
+			if got := readJSON(userPath); !reflect.DeepEqual(got, tt.wantUser) {
+				t.Errorf("~/.claude.json = %v\nwant %v", got, tt.wantUser)
+			}
+			if got := readJSON(repoPath); !reflect.DeepEqual(got, tt.wantRepo) {
+				t.Errorf(".mcp.json = %v\nwant %v", got, tt.wantRepo)
+			}
+		})
+	}
+}
+
+// TestRestoreClient_Directory verifies that a directory snapshot is restored exactly, with
+// files added since removed, and that a failed swap leaves the current directory as it was.
+func TestRestoreClient_Directory(t *testing.T) {
+	tmpDir := t.TempDir()
+	clientDir := filepath.Join(tmpDir, "mcpServers")
+	writeFiles := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			path := filepath.Join(clientDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// readFiles returns every file under dir by relative path
+	readFiles := func(dir string) map[string]string {
+		t.Helper()
+		files := make(map[string]string)
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			rel, _ := filepath.Rel(dir, path)
+			files[filepath.ToSlash(rel)] = string(data)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+
+	snapshot := map[string]string{"memory.yaml": "name: memory", "remote.yaml": "name: remote", "nested/notes.txt": "keep me"}
+	writeFiles(snapshot)
+
+	clientConf := config.Client{ConfigPath: clientDir, Type: "continue-blocks"}
+	cfg := &config.Config{
+		Backups: config.BackupConfig{Path: filepath.Join(tmpDir, "backups")},
+		Clients: map[string]config.Client{"continue": clientConf},
+	}
+	m := NewManager(cfg, &config.MCPConfig{})
+	backupPath, err := m.Trans.BackupClientConfig("continue", clientConf)
+	if err != nil {
+		t.Fatalf("BackupClientConfig failed: %v", err)
+	}
+
+	// Change a file, remove one and add some
+	if err := os.Remove(filepath.Join(clientDir, "remote.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	modified := map[string]string{"memory.yaml": "name: memory2", "added.yaml": "name: added", "nested/deep/added.txt": "drop me"}
+	writeFiles(modified)
+	modified["nested/notes.txt"] = "keep me"
+
+	// A failed swap rolls back to the current directory and cleans up after itself
+	rename = func(oldPath, newPath string) error {
+		// Only the staged copy fails to move in; the rollback goes through
+		if strings.Contains(filepath.Base(oldPath), ".restore-") && !strings.HasSuffix(oldPath, ".old") && newPath == clientDir {
+			return errors.New("swap failed")
+		}
+		return os.Rename(oldPath, newPath)
+	}
+	err = m.RestoreClient("continue", filepath.Base(backupPath))
+	rename = os.Rename
+	if err == nil {
+		t.Fatal("RestoreClient should fail when the swap fails")
+	}
+	if got := readFiles(clientDir); !reflect.DeepEqual(got, modified) {
+		t.Errorf("after a failed restore = %v, want %v", got, modified)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 2 {
+		t.Errorf("staging directories left behind: %v", entries)
+	}
+
+	if err := m.RestoreClient("continue", filepath.Base(backupPath)); err != nil {
+		t.Fatalf("RestoreClient failed: %v", err)
+	}
+	if got := readFiles(clientDir); !reflect.DeepEqual(got, snapshot) {
+		t.Errorf("restored = %v, want %v", got, snapshot)
+	}
+	if entries, _ := os.ReadDir(tmpDir); len(entries) != 2 {
+		t.Errorf("staging directories left behind: %v", entries)
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/client"
+)
+
+// RunningPolicyEnum says what ApplyToClient does when the client is running. Clients such
+// as Claude Desktop rewrite their config on exit, silently undoing changes made meanwhile.
+type RunningPolicyEnum string
+
+const (
+	RunningWarn RunningPolicyEnum = "warn" // Write anyway and report the running processes
+	RunningWait RunningPolicyEnum = "wait" // Wait for the client to exit, up to Manager.WaitTimeout
+	RunningSkip RunningPolicyEnum = "skip" // Leave the client's config untouched
+)
+
+// DefaultWaitTimeout is how long the wait policy waits for a client to exit.
+const DefaultWaitTimeout = 2 * time.Minute
+
+// waitPollInterval is how often the wait policy looks for the client's processes.
+var waitPollInterval = time.Second
+
+// ParseRunningPolicy checks a policy name; "" means RunningWarn.
+func ParseRunningPolicy(s string) (RunningPolicyEnum, error) {
+	switch policy := RunningPolicyEnum(s); policy {
+	case "":
+		return RunningWarn, nil
+	case RunningWarn, RunningWait, RunningSkip:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid running-client policy '%s': expected warn, wait or skip", s)
+	}
+}
+
+// checkRunning applies the running policy to a client before its config is written. It
+// returns false if the write must not go ahead, with res describing why.
+func (m *Manager) checkRunning(clientName string, res *ApplyResult) bool {
+	running := m.runningProcesses(clientName)
+	if len(running) == 0 {
+		return true
+	}
+	res.Running = running
+
+	switch m.RunningPolicy {
+	case RunningSkip:
+		res.Skipped = true
+		return false
+	case RunningWait:
+		if m.OnWait != nil {
+			m.OnWait(clientName, running)
+		}
+		deadline := time.Now().Add(m.WaitTimeout)
+		for len(running) > 0 {
+			if time.Now().After(deadline) {
+				res.Success = false
+				res.Error = fmt.Errorf("still running after %s (%v); close it and apply again", m.WaitTimeout, running)
+				return false
+			}
+			time.Sleep(waitPollInterval)
+			running = m.runningProcesses(clientName)
+		}
+		res.Waited = true
+	}
+	return true
+}
+
+// runningProcesses returns the client's processes that are running (see
+// client.RunningProcesses), or what Manager.processes says when tests set it.
+func (m *Manager) runningProcesses(clientName string) []string {
+	if m.processes != nil {
+		return m.processes(clientName)
+	}
+	return client.RunningProcesses(clientName)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// TestApplyToClient_RunningPolicies verifies what each running-client policy does before a
+// write: skip leaves the config untouched, wait writes once the client exits or fails after
+// the timeout, and warn writes anyway.
+func TestApplyToClient_RunningPolicies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	waitPollInterval = time.Millisecond
+	defer func() { waitPollInterval = time.Second }()
+
+	// An additional Cursor install, whose ID carries the hash of its path
+	const target = "cursor#26a77d"
+
+	tests := []struct {
+		name   string
+		policy RunningPolicyEnum
+		// exitsAfter is how many checks find the client running; -1 keeps it running
+		exitsAfter int
+		wantWrite  bool
+		wantRes    ApplyResult
+		wantErr    string
+	}{
+		{name: "not running", policy: RunningSkip, exitsAfter: 0, wantWrite: true,
+			wantRes: ApplyResult{Success: true}},
+		{name: "skip", policy: RunningSkip, exitsAfter: -1,
+			wantRes: ApplyResult{Success: true, Running: []string{"Cursor"}, Skipped: true}},
+		{name: "wait until exit", policy: RunningWait, exitsAfter: 3, wantWrite: true,
+			wantRes: ApplyResult{Success: true, Running: []string{"Cursor"}, Waited: true}},
+		{name: "wait timeout", policy: RunningWait, exitsAfter: -1,
+			wantRes: ApplyResult{Running: []string{"Cursor"}}, wantErr: "still running after"},
+		{name: "warn", policy: RunningWarn, exitsAfter: -1, wantWrite: true,
+			wantRes: ApplyResult{Success: true, Running: []string{"Cursor"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			configPath := filepath.Join(tmpDir, "mcp.json")
+			clientConf := config.Client{ConfigPath: configPath, Type: "simple-json"}
+			cfg := &config.Config{
+				Backups: config.BackupConfig{Path: filepath.Join(tmpDir, "backups")},
+				Clients: map[string]config.Client{target: clientConf},
+			}
+			mcpCfg := &config.MCPConfig{MCPServers: map[string]config.MCPServer{"git": {Command: "uvx", Args: []string{"mcp-server-git"}}}}
+
+			m := NewManager(cfg, mcpCfg)
+			m.RunningPolicy, m.WaitTimeout = tt.policy, 20*time.Millisecond
+			checks := 0
+			m.processes = func(clientName string) []string {
+				if clientName != target {
+					t.Errorf("processes looked up for %q, want %q", clientName, target)
+				}
+				checks++
+				if tt.exitsAfter >= 0 && checks > tt.exitsAfter {
+					return nil
+				}
+				return []string{"Cursor"}
+			}
+			var waited []string
+			m.OnWait = func(clientName string, running []string) { waited = running }
+
+			res := m.ApplyToClient(target, clientConf)
+			if tt.wantErr == "" && res.Error != nil || tt.wantErr != "" && (res.Error == nil || !strings.Contains(res.Error.Error(), tt.wantErr)) {
+				t.Fatalf("error = %v, want %q", res.Error, tt.wantErr)
+			}
+			got := ApplyResult{Success: res.Success, Running: res.Running, Skipped: res.Skipped, Waited: res.Waited}
+			if !reflect.DeepEqual(got, tt.wantRes) {
+				t.Errorf("result = %+v, want %+v", got, tt.wantRes)
+			}
+			if _, err := os.Stat(configPath); (err == nil) != tt.wantWrite {
+				t.Errorf("config written = %v, want %v", err == nil, tt.wantWrite)
+			}
+			if wantWaited := tt.policy == RunningWait; (waited != nil) != wantWaited {
+				t.Errorf("OnWait called with %v, want a call %v", waited, wantWaited)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package doctor
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
+	"github.com/tuannvm/mcpenetes/internal/client"
 	"github.com/tuannvm/mcpenetes/internal/config"
 	"github.com/tuannvm/mcpenetes/internal/util"
 )
@@ -17,13 +20,14 @@ type CheckResult struct {
 	Message string `json:"message"`
 }
 
-// RunChecks performs all system health checks
-func RunChecks() []CheckResult {
+// RunChecks performs all system health checks. Detected clients with low confidence
+// are reported as skipped unless includeLowConfidence is set.
+func RunChecks(includeLowConfidence bool) []CheckResult {
 	var results []CheckResult
 
 	results = append(results, checkConfigs()...)
 	results = append(results, checkEnvironment()...)
-	results = append(results, checkClients()...)
+	results = append(results, checkClients(includeLowConfidence)...)
 
 	return results
 }
@@ -63,6 +67,26 @@ func checkConfigs() []CheckResult {
 		})
 	}
 
+	// Check clients.yaml, if there is one
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		path := client.UserRegistryPath(homeDir)
+		if data, err := os.ReadFile(path); err == nil {
+			if err := client.ValidateDefinitions(path, data); err != nil {
+				results = append(results, CheckResult{
+					Name:    "Client Definitions (clients.yaml)",
+					Status:  "error",
+					Message: fmt.Sprintf("Invalid entries are ignored:\n%v", err),
+				})
+			} else {
+				results = append(results, CheckResult{
+					Name:    "Client Definitions (clients.yaml)",
+					Status:  "ok",
+					Message: "Valid",
+				})
+			}
+		}
+	}
+
 	return results
 }
 
@@ -99,11 +123,19 @@ func checkEnvironment() []CheckResult {
 	return results
 }
 
-func checkClients() []CheckResult {
+func checkClients(includeLowConfidence bool) []CheckResult {
 	var results []CheckResult
 
+	version, path := client.ActiveCatalog()
+	catalog := CheckResult{Name: "Client Catalog", Status: "ok", Message: fmt.Sprintf("Built-in catalog %s", version)}
+	if path != "" {
+		catalog.Message = fmt.Sprintf("Updated catalog %s (%s)", version, path)
+	}
+	results = append(results, catalog)
+
 	clients, err := util.DetectMCPClients()
-	if err != nil {
+	var defErrs client.DefinitionErrors
+	if err != nil && !errors.As(err, &defErrs) { // clients.yaml problems are reported by checkConfigs
 		results = append(results, CheckResult{
 			Name:    "Client Detection",
 			Status:  "error",
@@ -127,6 +159,21 @@ func checkClients() []CheckResult {
 	}
 
 	for name, client := range clients {
+		evidence := strings.Join(client.Evidence, "; ")
+		if client.Confidence == "low" && !includeLowConfidence {
+			results = append(results, CheckResult{
+				Name:    fmt.Sprintf("Client: %s", name),
+				Status:  "warning",
+				Message: fmt.Sprintf("Skipped, low confidence that it is installed (%s)", evidence),
+			})
+			continue
+		}
+		results = append(results, CheckResult{
+			Name:    fmt.Sprintf("Detected: %s", name),
+			Status:  "ok",
+			Message: fmt.Sprintf("%s confidence: %s (defined by %s)", client.Confidence, evidence, client.Catalog),
+		})
+
 		path, err := util.ExpandPath(client.ConfigPath)
 		if err != nil {
 			results = append(results, CheckResult{
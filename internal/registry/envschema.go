@@ -0,0 +1,153 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/tuannvm/mcpenetes/internal/secrets"
+)
+
+// EnvSchema is the JSON Schema a registry gives for a server's environment variables,
+// e.g. Glama's "environmentVariablesJsonSchema". Only the keywords used to prompt for
+// and check string values are kept.
+type EnvSchema struct {
+	Properties map[string]EnvProperty `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+// EnvProperty describes one environment variable.
+type EnvProperty struct {
+	Type        string        `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Pattern     string        `json:"pattern,omitempty"`
+	// Format "password" or WriteOnly mark a secret
+	Format    string `json:"format,omitempty"`
+	WriteOnly bool   `json:"writeOnly,omitempty"`
+}
+
+// EnvVariable is an environment variable to prompt for, flattened from an EnvSchema.
+type EnvVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	// Secret values are kept in the secret store instead of mcp.json
+	Secret  bool     `json:"secret"`
+	Default string   `json:"default,omitempty"`
+	Enum    []string `json:"enum,omitempty"`
+	Type    string   `json:"type,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+}
+
+// parseEnvSchema reads a schema from a registry response. It returns nil for a missing
+// or unusable schema, or one that declares no variables.
+func parseEnvSchema(raw json.RawMessage) *EnvSchema {
+	if len(raw) == 0 {
+		return nil
+	}
+	var schema EnvSchema
+	if err := json.Unmarshal(raw, &schema); err != nil || len(schema.Properties) == 0 {
+		return nil
+	}
+	return &schema
+}
+
+// Variables lists the schema's variables, required ones first, each group by name.
+func (s *EnvSchema) Variables() []EnvVariable {
+	if s == nil {
+		return nil
+	}
+	vars := make([]EnvVariable, 0, len(s.Properties))
+	for name, prop := range s.Properties {
+		v := EnvVariable{
+			Name:        name,
+			Description: prop.Description,
+			Required:    slices.Contains(s.Required, name),
+			Secret:      prop.Format == "password" || prop.WriteOnly || secrets.LooksSecret(name),
+			Type:        prop.Type,
+			Pattern:     prop.Pattern,
+		}
+		if prop.Default != nil {
+			v.Default = fmt.Sprint(prop.Default)
+		}
+		for _, e := range prop.Enum {
+			v.Enum = append(v.Enum, fmt.Sprint(e))
+		}
+		vars = append(vars, v)
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		if vars[i].Required != vars[j].Required {
+			return vars[i].Required
+		}
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
+
+// Variable returns the named variable, if the schema declares it.
+func (s *EnvSchema) Variable(name string) (EnvVariable, bool) {
+	for _, v := range s.Variables() {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return EnvVariable{}, false
+}
+
+// Validate checks values against the schema: every required variable must be set and
+// every value must match its variable's type, enum and pattern. Values of variables
+// the schema doesn't declare are accepted, and references to stored secrets are taken
+// as set. A nil schema accepts anything.
+func (s *EnvSchema) Validate(values map[string]string) error {
+	var errs []error
+	for _, v := range s.Variables() {
+		if _, ok := secrets.ParseRef(values[v.Name]); ok {
+			continue
+		}
+		if err := v.Validate(values[v.Name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks a single value. An empty value is only an error for a required variable.
+func (v EnvVariable) Validate(value string) error {
+	if value == "" {
+		if v.Required {
+			return fmt.Errorf("%s is required", v.Name)
+		}
+		return nil
+	}
+
+	switch v.Type {
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("%s must be an integer", v.Name)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number", v.Name)
+		}
+	case "boolean":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s must be true or false", v.Name)
+		}
+	}
+	if len(v.Enum) > 0 && !slices.Contains(v.Enum, value) {
+		return fmt.Errorf("%s must be one of %v", v.Name, v.Enum)
+	}
+	if v.Pattern != "" {
+		re, err := regexp.Compile(v.Pattern)
+		if err == nil && !re.MatchString(value) {
+			return fmt.Errorf("%s must match %s", v.Name, v.Pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const githubEnvSchema = `{
+  "type": "object",
+  "properties": {
+    "LOG_LEVEL": {"type": "string", "enum": ["debug", "info"], "default": "info"},
+    "GITHUB_PERSONAL_ACCESS_TOKEN": {"type": "string", "description": "Token with repo scope", "pattern": "^gh[ps]_"},
+    "GITHUB_HOST": {"type": "string", "description": "GitHub Enterprise host"},
+    "TIMEOUT": {"type": "integer"},
+    "SIGNING_PHRASE": {"type": "string", "format": "password"}
+  },
+  "required": ["GITHUB_PERSONAL_ACCESS_TOKEN"]
+}`
+
+// TestEnvSchema verifies prompting order, secret detection and validation.
+func TestEnvSchema(t *testing.T) {
+	schema := parseEnvSchema(json.RawMessage(githubEnvSchema))
+	if schema == nil {
+		t.Fatal("parseEnvSchema returned nil")
+	}
+
+	var names []string
+	secret := map[string]bool{}
+	for _, v := range schema.Variables() {
+		names = append(names, v.Name)
+		secret[v.Name] = v.Secret
+	}
+	if got := strings.Join(names, ","); got != "GITHUB_PERSONAL_ACCESS_TOKEN,GITHUB_HOST,LOG_LEVEL,SIGNING_PHRASE,TIMEOUT" {
+		t.Errorf("Variables = %s", got)
+	}
+	if !secret["GITHUB_PERSONAL_ACCESS_TOKEN"] || !secret["SIGNING_PHRASE"] || secret["GITHUB_HOST"] {
+		t.Errorf("Secret = %v", secret)
+	}
+	if v, _ := schema.Variable("LOG_LEVEL"); v.Default != "info" || len(v.Enum) != 2 {
+		t.Errorf("LOG_LEVEL = %+v", v)
+	}
+
+	tests := []struct {
+		values  map[string]string
+		wantErr string
+	}{
+		{values: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "ghp_abc", "TIMEOUT": "30"}},
+		{values: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "${secret:github/GITHUB_PERSONAL_ACCESS_TOKEN}"}},
+		{values: map[string]string{}, wantErr: "GITHUB_PERSONAL_ACCESS_TOKEN is required"},
+		{values: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "abc"}, wantErr: "must match"},
+		{values: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "ghp_abc", "TIMEOUT": "soon"}, wantErr: "TIMEOUT must be an integer"},
+		{values: map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "ghp_abc", "LOG_LEVEL": "trace"}, wantErr: "LOG_LEVEL must be one of"},
+	}
+	for _, tt := range tests {
+		err := schema.Validate(tt.values)
+		if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("Validate(%v) = %v, want %q", tt.values, err, tt.wantErr)
+		}
+	}
+
+	if parseEnvSchema(json.RawMessage(`{"type": "object", "properties": {}}`)) != nil {
+		t.Error("Expected no schema without variables")
+	}
+}
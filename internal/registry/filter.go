@@ -0,0 +1,67 @@
+package registry
+
+import (
+	"slices"
+	"strings"
+)
+
+// AttributeRemoteCapable is Glama's attribute for servers that can be used remotely
+const AttributeRemoteCapable = "hosting:remote-capable"
+
+// Filter narrows search results by server metadata. The zero Filter matches every server.
+type Filter struct {
+	// Tools must each be matched by a tool name containing it, ignoring case
+	Tools []string
+	// License is an SPDX identifier or license name, ignoring case
+	License string
+	// RemoteCapable keeps servers with a hosted endpoint or Glama's remote-capable attribute
+	RemoteCapable bool
+	// Attributes must all be present, ignoring case
+	Attributes []string
+}
+
+// Empty reports whether the filter matches every server.
+func (f Filter) Empty() bool {
+	return len(f.Tools) == 0 && f.License == "" && !f.RemoteCapable && len(f.Attributes) == 0
+}
+
+// Match reports whether a server passes the filter.
+func (f Filter) Match(s ServerData) bool {
+	if f.License != "" && !strings.EqualFold(s.License, f.License) {
+		return false
+	}
+	if f.RemoteCapable && !s.RemoteCapable() {
+		return false
+	}
+	for _, attr := range f.Attributes {
+		if !slices.ContainsFunc(s.Attributes, func(a string) bool { return strings.EqualFold(a, attr) }) {
+			return false
+		}
+	}
+	for _, want := range f.Tools {
+		want = strings.ToLower(want)
+		if !slices.ContainsFunc(s.Tools, func(t Tool) bool { return strings.Contains(strings.ToLower(t.Name), want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the servers that pass the filter.
+func (f Filter) Apply(servers []ServerData) []ServerData {
+	if f.Empty() {
+		return servers
+	}
+	var matched []ServerData
+	for _, s := range servers {
+		if f.Match(s) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+// RemoteCapable reports whether the server can be used without running it locally.
+func (s ServerData) RemoteCapable() bool {
+	return len(s.Remotes) > 0 || slices.Contains(s.Attributes, AttributeRemoteCapable)
+}
@@ -0,0 +1,39 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFilter verifies each metadata filter and that they combine.
+func TestFilter(t *testing.T) {
+	servers := []ServerData{
+		{ID: "postgres", License: "MIT", Tools: []Tool{{Name: "query"}, {Name: "list_tables"}}, Attributes: []string{"author:official"}},
+		{ID: "hosted", License: "Apache-2.0", Remotes: []Remote{{Type: "sse", URL: "https://mcp.example.com/sse"}}},
+		{ID: "glama-remote", Attributes: []string{AttributeRemoteCapable}, Tools: []Tool{{Name: "search_docs"}}},
+		{ID: "bare"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{name: "none", filter: Filter{}, want: []string{"postgres", "hosted", "glama-remote", "bare"}},
+		{name: "tool substring", filter: Filter{Tools: []string{"TABLE"}}, want: []string{"postgres"}},
+		{name: "all tools", filter: Filter{Tools: []string{"query", "search"}}, want: nil},
+		{name: "license", filter: Filter{License: "mit"}, want: []string{"postgres"}},
+		{name: "remote-capable", filter: Filter{RemoteCapable: true}, want: []string{"hosted", "glama-remote"}},
+		{name: "attribute", filter: Filter{Attributes: []string{"Author:Official"}}, want: []string{"postgres"}},
+		{name: "combined", filter: Filter{RemoteCapable: true, Tools: []string{"docs"}}, want: []string{"glama-remote"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range tt.filter.Apply(servers) {
+			got = append(got, s.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
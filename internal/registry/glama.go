@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// glamaSource reads the Glama MCP API (https://glama.ai/api/mcp/v1/servers), which pages
+// with an "after" cursor and serves details at ".../servers/<namespace>/<slug>".
+type glamaSource struct {
+	url   string
+	trust *trust
+}
+
+type glamaServer struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace"`
+	Slug        string   `json:"slug"`
+	Attributes  []string `json:"attributes"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Repository  struct {
+		URL string `json:"url"`
+	} `json:"repository"`
+	SPDXLicense struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"spdxLicense"`
+	Tools                          []Tool          `json:"tools"`
+	EnvironmentVariablesJSONSchema json.RawMessage `json:"environmentVariablesJsonSchema"`
+}
+
+type glamaPage struct {
+	PageInfo struct {
+		EndCursor   string `json:"endCursor"`
+		HasNextPage bool   `json:"hasNextPage"`
+	} `json:"pageInfo"`
+	Servers []glamaServer `json:"servers"`
+}
+
+func (s *glamaSource) ListPage(cursor string) (Page, error) {
+	pageURL := s.url
+	if cursor != "" {
+		pageURL = withQuery(s.url, url.Values{"after": {cursor}, "first": {"100"}})
+	}
+
+	var resp glamaPage
+	if err := s.trust.getJSON(pageURL, &resp); err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Servers: make([]ServerData, 0, len(resp.Servers))}
+	for _, server := range resp.Servers {
+		page.Servers = append(page.Servers, server.serverData())
+	}
+	if resp.PageInfo.HasNextPage {
+		page.NextCursor = resp.PageInfo.EndCursor
+	}
+	return page, nil
+}
+
+func (s *glamaSource) GetServer(id string) (*ServerData, error) {
+	var server glamaServer
+	if err := s.trust.getJSON(itemURL(s.url, id), &server); err != nil {
+		return nil, err
+	}
+	data := server.serverData()
+	return &data, nil
+}
+
+func (g glamaServer) serverData() ServerData {
+	id := g.ID
+	if g.Namespace != "" && g.Slug != "" {
+		id = g.Namespace + "/" + g.Slug
+	}
+	return ServerData{
+		ID:            id,
+		Name:          g.Name,
+		Description:   g.Description,
+		RepositoryURL: g.Repository.URL,
+		Attributes:    g.Attributes,
+		Tools:         g.Tools,
+		License:       spdxLicense(g.SPDXLicense.Name, g.SPDXLicense.URL),
+		EnvSchema:     parseEnvSchema(g.EnvironmentVariablesJSONSchema),
+	}
+}
+
+// spdxLicense prefers the SPDX identifier at the end of the license URL
+// ("https://spdx.org/licenses/MIT" gives "MIT") over the license's full name.
+func spdxLicense(name, licenseURL string) string {
+	if i := strings.LastIndex(licenseURL, "/"); i >= 0 && strings.Contains(licenseURL, "spdx.org") {
+		if id := strings.TrimSuffix(licenseURL[i+1:], ".html"); id != "" {
+			return id
+		}
+	}
+	return name
+}
@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// Problem is something wrong with a registry's catalog, found by Lint.
+type Problem struct {
+	// Where is the server ID, or the manifest file in a directory registry
+	Where   string
+	Message string
+	// Warning marks problems that don't keep the server from being installed
+	Warning bool
+}
+
+func (p Problem) String() string {
+	return p.Where + ": " + p.Message
+}
+
+// Lint reads every page of a registry and checks its servers the way search and
+// install use them: each needs an ID, unique in the registry, and its packages and
+// remotes have to build a command. It returns an error if the registry can't be read.
+func Lint(reg config.Registry) ([]Problem, error) {
+	src, err := NewSource(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []ServerData
+	var problems []Problem
+	if dir, ok := src.(*dirSource); ok {
+		if servers, problems, err = dir.manifests(); err != nil {
+			return nil, err
+		}
+	} else {
+		// Unlike ListAll, a page that fails is an error
+		seen := make(map[string]bool)
+		cursor := ""
+		for {
+			page, err := src.ListPage(cursor)
+			if err != nil {
+				return nil, err
+			}
+			servers = append(servers, page.Servers...)
+			if page.NextCursor == "" {
+				break
+			}
+			if seen[page.NextCursor] {
+				problems = append(problems, Problem{Where: reg.URL, Message: fmt.Sprintf("cursor %q repeats; pagination never ends", page.NextCursor)})
+				break
+			}
+			seen[page.NextCursor] = true
+			cursor = page.NextCursor
+		}
+	}
+	if len(servers) == 0 && len(problems) == 0 {
+		problems = append(problems, Problem{Where: reg.URL, Message: "lists no servers"})
+	}
+
+	ids := make(map[string]int)
+	for i, s := range servers {
+		where := s.ID
+		if where == "" {
+			where = fmt.Sprintf("server #%d", i+1)
+		}
+		problems = append(problems, lintServer(where, s)...)
+		if s.ID != "" {
+			if ids[s.ID]++; ids[s.ID] == 2 {
+				problems = append(problems, Problem{Where: where, Message: "ID is listed more than once"})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// lintServer checks one server.
+func lintServer(where string, s ServerData) []Problem {
+	var problems []Problem
+	add := func(warning bool, format string, args ...interface{}) {
+		problems = append(problems, Problem{Where: where, Message: fmt.Sprintf(format, args...), Warning: warning})
+	}
+
+	if s.ID == "" {
+		add(false, "has no ID")
+	}
+	if s.Description == "" {
+		add(true, "has no description, so search can only match its name")
+	}
+	if s.RepositoryURL != "" && !isHTTPURL(s.RepositoryURL) {
+		add(true, "repository %q isn't an http(s) URL", s.RepositoryURL)
+	}
+
+	for _, p := range s.Packages {
+		if p.Transport != "" && p.Transport != "stdio" {
+			if p.Identifier == "" {
+				add(false, "%s package has no identifier", p.RegistryType)
+			}
+			continue
+		}
+		if _, err := p.MCPServer(); err != nil {
+			add(false, "%v", err)
+			continue
+		}
+		if p.Version == "" {
+			add(true, "%s package %s has no version; installs pin the latest one", p.RegistryType, p.Identifier)
+		}
+	}
+	for _, r := range s.Remotes {
+		if r.Type != "streamable-http" && r.Type != "sse" {
+			add(false, "remote %s has transport %q; expected streamable-http or sse", r.URL, r.Type)
+		}
+		if !isHTTPURL(r.URL) {
+			add(false, "remote URL %q isn't an http(s) URL", r.URL)
+		}
+	}
+	if len(s.Packages) == 0 && len(s.Remotes) == 0 && s.RepositoryURL == "" {
+		add(true, "has no packages, remotes or repository; installs fall back to guessing a command")
+	}
+
+	for _, v := range s.EnvSchema.Variables() {
+		if v.Pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			add(false, "environment variable %s has an invalid pattern: %v", v.Name, err)
+		}
+	}
+	return problems
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,131 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tuannvm/mcpenetes/internal/log"
+)
+
+// dirSource reads a directory of per-server manifests: server.json documents, bare or
+// wrapped like an official registry listing entry, in any subdirectory. It lets a
+// catalog live in a git checkout or on a shared drive.
+type dirSource struct {
+	dir   string
+	trust *trust
+}
+
+// LocalPath returns the file or directory a registry URL points at, for file:// URLs
+// and plain paths (absolute, or starting with ~/, ./ or ../), and false for others.
+func LocalPath(registryURL string) (string, bool) {
+	if strings.HasPrefix(registryURL, "file://") {
+		u, err := url.Parse(registryURL)
+		if err != nil || (u.Host != "" && u.Host != "localhost") {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
+	}
+
+	path, _, _ := strings.Cut(registryURL, "?")
+	switch {
+	case strings.HasPrefix(path, "~/"):
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		return filepath.Join(homeDir, path[2:]), true
+	case filepath.IsAbs(path), strings.HasPrefix(path, "./"), strings.HasPrefix(path, "../"):
+		return filepath.Clean(path), true
+	}
+	return "", false
+}
+
+// isLocalDir reports whether a registry URL points at a local directory.
+func isLocalDir(registryURL string) bool {
+	path, ok := LocalPath(registryURL)
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ListPage lists every manifest as one page. Manifests that can't be read are skipped
+// with a warning; 'mcpenetes registry lint' reports them.
+func (s *dirSource) ListPage(cursor string) (Page, error) {
+	servers, problems, err := s.manifests()
+	if err != nil {
+		return Page{}, err
+	}
+	for _, p := range problems {
+		log.Warn("Skipping %s: %s", p.Where, p.Message)
+	}
+	return Page{Servers: servers}, nil
+}
+
+func (s *dirSource) GetServer(id string) (*ServerData, error) {
+	return findServer(s, id)
+}
+
+// manifests reads the server.json documents below the directory, in path order,
+// with a problem for each file that isn't one.
+func (s *dirSource) manifests() ([]ServerData, []Problem, error) {
+	var servers []ServerData
+	var problems []Problem
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != s.dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Signatures (SignatureExt) sit next to the manifests
+		if filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(s.dir, path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, Problem{Where: rel, Message: err.Error()})
+			return nil
+		}
+		if err := s.trust.verifyFile(path, data); err != nil {
+			problems = append(problems, Problem{Where: rel, Message: err.Error()})
+			return nil
+		}
+		var entry officialEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			problems = append(problems, Problem{Where: rel, Message: fmt.Sprintf("not valid JSON: %v", err)})
+			return nil
+		}
+		doc := entry.document()
+		if doc.Name == "" {
+			problems = append(problems, Problem{Where: rel, Message: `not a server.json manifest: it has no "name"`})
+			return nil
+		}
+		servers = append(servers, entry.serverData())
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read registry directory %s: %w", s.dir, err)
+	}
+	return servers, problems, nil
+}
+
+// readLocal reads a local registry file, for fetch.
+func readLocal(path string) ([]byte, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry file: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestLocalRegistries verifies file:// and path registries: an index file read by the
+// adapter of its format, and a directory of server.json manifests.
+func TestLocalRegistries(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"index.json":                `{"servers": [{"server": {"name": "acme/files", "packages": [{"registryType": "npm", "identifier": "@acme/files", "version": "1.0.0", "transport": {"type": "stdio"}}]}}], "metadata": {}}`,
+		"catalog/files/server.json": `{"name": "acme/files", "description": "Files", "packages": [{"registryType": "npm", "identifier": "@acme/files", "transport": {"type": "stdio"}}]}`,
+		"catalog/hosted.json":       `{"server": {"name": "acme/hosted", "remotes": [{"type": "streamable-http", "url": "https://mcp.acme.com/mcp"}]}}`,
+		"catalog/notes.txt":         "not a manifest",
+		"catalog/.git/config.json":  `{}`,
+	})
+
+	indexFile := filepath.Join(dir, "index.json")
+	catalog := filepath.Join(dir, "catalog")
+	tests := []struct {
+		url      string
+		wantType string
+		wantIDs  []string
+	}{
+		{url: indexFile, wantType: TypeOfficial, wantIDs: []string{"acme/files"}},
+		{url: "file://" + filepath.ToSlash(indexFile), wantType: TypeOfficial, wantIDs: []string{"acme/files"}},
+		{url: catalog, wantType: TypeDirectory, wantIDs: []string{"acme/files", "acme/hosted"}},
+	}
+	for _, tt := range tests {
+		if typ, err := DetectType(tt.url); err != nil || typ != tt.wantType {
+			t.Errorf("DetectType(%s) = %q, %v; want %q", tt.url, typ, err, tt.wantType)
+		}
+		servers, err := FetchServers(config.Registry{Name: "local", URL: tt.url}, false)
+		if err != nil {
+			t.Fatalf("FetchServers(%s) failed: %v", tt.url, err)
+		}
+		if got := serverIDs(servers); !reflect.DeepEqual(got, tt.wantIDs) {
+			t.Errorf("FetchServers(%s) = %v, want %v", tt.url, got, tt.wantIDs)
+		}
+	}
+
+	if _, ok := LocalPath("nodomain"); ok {
+		t.Error("LocalPath(nodomain) should not be a path")
+	}
+	if _, ok := LocalPath("https://example.com/servers"); ok {
+		t.Error("LocalPath(https://...) should not be a path")
+	}
+}
+
+// TestLint verifies the problems reported for a directory registry.
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.json":       `{"name": "acme/ok", "description": "Fine", "packages": [{"registryType": "npm", "identifier": "@acme/ok", "version": "1.0.0"}]}`,
+		"b.json":       `{"name": "acme/ok", "description": "Again"}`,
+		"broken.json":  `{"name": `,
+		"empty.json":   `{"description": "No name"}`,
+		"bad-pkg.json": `{"name": "acme/bad", "packages": [{"registryType": "cargo", "identifier": "bad"}], "remotes": [{"type": "websocket", "url": "ws://x"}]}`,
+	})
+
+	problems, err := Lint(config.Registry{URL: dir})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var errs, warnings []string
+	for _, p := range problems {
+		if p.Warning {
+			warnings = append(warnings, p.String())
+		} else {
+			errs = append(errs, p.String())
+		}
+	}
+
+	wantErrs := []string{
+		"broken.json: not valid JSON",
+		"empty.json: not a server.json manifest",
+		"acme/bad: cargo packages are not supported",
+		`acme/bad: remote ws://x has transport "websocket"`,
+		`acme/bad: remote URL "ws://x" isn't an http(s) URL`,
+		"acme/ok: ID is listed more than once",
+	}
+	for _, want := range wantErrs {
+		if !containsPrefix(errs, want) {
+			t.Errorf("missing error %q in %v", want, errs)
+		}
+	}
+	if len(errs) != len(wantErrs) {
+		t.Errorf("errors = %v", errs)
+	}
+	if !containsPrefix(warnings, "acme/bad: has no description") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func containsPrefix(list []string, prefix string) bool {
+	for _, s := range list {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,16 @@ import (
 	"fmt"
 
 	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/registry"
 )
 
-// AddRegistry adds a new registry to the configuration
-func AddRegistry(name, url string) error {
+// AddRegistry adds a new registry to the configuration. An empty registryType is
+// detected when the registry is fetched.
+func AddRegistry(name, url, registryType string) error {
+	if err := registry.ValidateType(registryType); err != nil {
+		return err
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
@@ -26,6 +32,7 @@ func AddRegistry(name, url string) error {
 	cfg.Registries = append(cfg.Registries, config.Registry{
 		Name: name,
 		URL:  url,
+		Type: registryType,
 	})
 
 	if err := config.SaveConfig(cfg); err != nil {
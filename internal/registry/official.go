@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// officialSource reads the official MCP Registry API
+// (https://registry.modelcontextprotocol.io/v0/servers). Each entry is a server.json
+// document, pages follow "metadata.nextCursor", and details are served at
+// "/v0/servers/<name>/versions/latest".
+type officialSource struct {
+	url   string
+	trust *trust
+}
+
+// serverJSON is the part of a server.json document mcpenetes uses.
+type serverJSON struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	Repository  struct {
+		URL string `json:"url"`
+	} `json:"repository"`
+	Packages []officialPackage `json:"packages"`
+	Remotes  []Remote          `json:"remotes"`
+}
+
+// officialPackage is a server.json package. Its transport is an object, unlike Package's.
+type officialPackage struct {
+	Package
+	Transport struct {
+		Type string `json:"type"`
+	} `json:"transport"`
+}
+
+// MetaKey is the "_meta" key under which a registry served by 'mcpenetes registry serve'
+// gives what server.json has no place for, like the exact command (see mcpenetesMeta).
+const MetaKey = "io.github.tuannvm/mcpenetes"
+
+// mcpenetesMeta is the metadata under MetaKey.
+type mcpenetesMeta struct {
+	Config     *config.MCPServer `json:"config,omitempty"`
+	EnvSchema  *EnvSchema        `json:"envSchema,omitempty"`
+	Tools      []Tool            `json:"tools,omitempty"`
+	License    string            `json:"license,omitempty"`
+	Attributes []string          `json:"attributes,omitempty"`
+}
+
+// officialEntry is a listing entry: the server.json under "server" with registry metadata
+// beside it. Older registry versions list the server.json itself, with "_meta" inside.
+type officialEntry struct {
+	Server *serverJSON `json:"server"`
+	Meta   struct {
+		Official *struct {
+			IsLatest *bool `json:"isLatest"`
+			// Status is "active", "deprecated" or "deleted"
+			Status    string    `json:"status"`
+			UpdatedAt time.Time `json:"updatedAt"`
+		} `json:"io.modelcontextprotocol.registry/official"`
+		Mcpenetes *mcpenetesMeta `json:"io.github.tuannvm/mcpenetes"`
+	} `json:"_meta"`
+	serverJSON
+}
+
+type officialPage struct {
+	Servers  []officialEntry `json:"servers"`
+	Metadata struct {
+		NextCursor       string `json:"nextCursor"`
+		LegacyNextCursor string `json:"next_cursor"`
+	} `json:"metadata"`
+}
+
+func (s *officialSource) ListPage(cursor string) (Page, error) {
+	return s.listPage(url.Values{}, cursor)
+}
+
+// ListUpdatedPage lists the servers published or changed since a time, with "updated_since".
+func (s *officialSource) ListUpdatedPage(since time.Time, cursor string) (Page, error) {
+	return s.listPage(url.Values{"updated_since": {since.UTC().Format(time.RFC3339)}}, cursor)
+}
+
+func (s *officialSource) listPage(params url.Values, cursor string) (Page, error) {
+	params.Set("limit", "100")
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+
+	var resp officialPage
+	date, err := s.trust.getDatedJSON(withQuery(s.url, params), &resp)
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{Date: date}
+	for _, entry := range resp.Servers {
+		meta := entry.Meta.Official
+		if meta != nil && date.IsZero() && meta.UpdatedAt.After(page.Date) {
+			page.Date = meta.UpdatedAt
+		}
+		if meta != nil && meta.Status == "deleted" {
+			page.Removed = append(page.Removed, entry.document().Name)
+			continue
+		}
+		// The registry lists every published version; keep the latest one
+		if meta != nil && meta.IsLatest != nil && !*meta.IsLatest {
+			continue
+		}
+		page.Servers = append(page.Servers, entry.serverData())
+	}
+	page.NextCursor = resp.Metadata.NextCursor
+	if page.NextCursor == "" {
+		page.NextCursor = resp.Metadata.LegacyNextCursor
+	}
+	return page, nil
+}
+
+func (s *officialSource) GetServer(id string) (*ServerData, error) {
+	var entry officialEntry
+	if err := s.trust.getJSON(itemURL(s.url, url.PathEscape(id)+"/versions/latest"), &entry); err != nil {
+		return nil, err
+	}
+	data := entry.serverData()
+	return &data, nil
+}
+
+// serverData converts the entry, with the metadata mcpenetes registries add.
+func (e officialEntry) serverData() ServerData {
+	data := e.document().serverData()
+	if m := e.Meta.Mcpenetes; m != nil {
+		data.Config, data.Tools = m.Config, m.Tools
+		data.License, data.Attributes = m.License, m.Attributes
+		if m.EnvSchema != nil {
+			data.EnvSchema = m.EnvSchema
+		}
+	}
+	return data
+}
+
+func (e officialEntry) document() serverJSON {
+	if e.Server != nil {
+		return *e.Server
+	}
+	return e.serverJSON
+}
+
+func (d serverJSON) serverData() ServerData {
+	name := d.Title
+	if name == "" {
+		name = d.Name
+	}
+	data := ServerData{
+		ID:            d.Name,
+		Name:          name,
+		Description:   d.Description,
+		RepositoryURL: d.Repository.URL,
+		Version:       d.Version,
+		Remotes:       d.Remotes,
+	}
+	for _, p := range d.Packages {
+		pkg := p.Package
+		pkg.Transport = p.Transport.Type
+		data.Packages = append(data.Packages, pkg)
+	}
+	data.EnvSchema = packagesEnvSchema(data.Packages)
+	return data
+}
+
+// officialHost serves the official MCP Registry
+const officialHost = "registry.modelcontextprotocol.io"
+
+// isOfficialURL reports whether a registry URL is the official API's server listing.
+func isOfficialURL(registryURL string) bool {
+	u, err := url.Parse(registryURL)
+	return err == nil && (u.Host == officialHost || strings.HasSuffix(u.Path, "/v0/servers"))
+}
@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+const officialPage1 = `{
+  "servers": [
+    {
+      "server": {
+        "name": "io.github.acme/files",
+        "description": "Files",
+        "version": "1.2.0",
+        "repository": {"url": "https://github.com/acme/files", "source": "github"},
+        "packages": [
+          {"registryType": "npm", "identifier": "@acme/files", "version": "1.2.0", "transport": {"type": "stdio"},
+           "packageArguments": [{"type": "positional", "value": "/data"}, {"type": "named", "name": "--mode", "default": "ro"}, {"type": "positional", "valueHint": "path"}]}
+        ]
+      },
+      "_meta": {"io.modelcontextprotocol.registry/official": {"isLatest": true}}
+    },
+    {
+      "server": {"name": "io.github.acme/files", "version": "1.1.0"},
+      "_meta": {"io.modelcontextprotocol.registry/official": {"isLatest": false}}
+    }
+  ],
+  "metadata": {"nextCursor": "page2", "count": 2}
+}`
+
+const officialPage2 = `{
+  "servers": [
+    {"server": {"name": "io.github.acme/weather", "packages": [{"registryType": "pypi", "identifier": "acme-weather", "version": "0.3.1", "transport": {"type": "stdio"}}]}},
+    {"server": {"name": "io.github.acme/db", "packages": [{"registryType": "oci", "identifier": "ghcr.io/acme/db", "version": "2.0.0", "transport": {"type": "stdio"},
+      "environmentVariables": [{"name": "DB_URL", "description": "Database connection URL", "isRequired": true, "isSecret": true}, {"name": "DB_MODE", "default": "ro", "choices": ["ro", "rw"]}]}]}},
+    {"server": {"name": "com.acme/hosted", "remotes": [{"type": "streamable-http", "url": "https://mcp.acme.com/mcp"}]}}
+  ],
+  "metadata": {"count": 3}
+}`
+
+// TestOfficialSource verifies cursor pagination, latest-version filtering and the
+// commands resolved from server.json packages and remotes.
+func TestOfficialSource(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		switch {
+		case r.URL.Path == "/v0/servers" && r.URL.Query().Get("cursor") == "":
+			_, _ = w.Write([]byte(officialPage1))
+		case r.URL.Path == "/v0/servers" && r.URL.Query().Get("cursor") == "page2":
+			_, _ = w.Write([]byte(officialPage2))
+		case r.URL.RawPath == "/v0/servers/io.github.acme%2Fweather/versions/latest":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"server": map[string]interface{}{"name": "io.github.acme/weather", "description": "Weather"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	if typ, err := DetectType(srv.URL + "/v0/servers"); err != nil || typ != TypeOfficial {
+		t.Fatalf("DetectType = %q, %v", typ, err)
+	}
+
+	src, err := NewSource(config.Registry{URL: srv.URL + "/v0/servers"})
+	if err != nil {
+		t.Fatalf("NewSource failed: %v", err)
+	}
+	servers, err := ListAll(src)
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if got := serverIDs(servers); !reflect.DeepEqual(got, []string{"io.github.acme/files", "io.github.acme/weather", "io.github.acme/db", "com.acme/hosted"}) {
+		t.Fatalf("IDs = %v (requests: %v)", got, paths)
+	}
+
+	want := []config.MCPServer{
+		{Command: "npx", Args: []string{"-y", "@acme/files@1.2.0", "/data", "--mode", "ro"}},
+		{Command: "uvx", Args: []string{"acme-weather==0.3.1"}},
+		{Command: "docker", Args: []string{"run", "-i", "--rm", "-e", "DB_URL", "-e", "DB_MODE", "ghcr.io/acme/db:2.0.0"}},
+		{URL: "https://mcp.acme.com/mcp"},
+	}
+	resolver := &Resolver{LookPath: func(file string) (string, error) { return "/usr/bin/" + file, nil }}
+	for i, s := range servers {
+		if got := resolver.Resolve(s.ID, &servers[i]); !reflect.DeepEqual(got.Server, want[i]) {
+			t.Errorf("%s: Server = %+v; want %+v (%v)", s.ID, got.Server, want[i], got.Reasons)
+		}
+	}
+	if servers[0].RepositoryURL != "https://github.com/acme/files" || servers[0].Version != "1.2.0" {
+		t.Errorf("files: %+v", servers[0])
+	}
+
+	// The packages' environment variables are prompted for
+	wantEnv := []EnvVariable{
+		{Name: "DB_URL", Description: "Database connection URL", Required: true, Secret: true},
+		{Name: "DB_MODE", Default: "ro", Enum: []string{"ro", "rw"}},
+	}
+	if got := servers[2].EnvSchema.Variables(); !reflect.DeepEqual(got, wantEnv) {
+		t.Errorf("db: env = %+v; want %+v", got, wantEnv)
+	}
+	if servers[1].EnvSchema != nil {
+		t.Errorf("weather: env = %+v; want none", servers[1].EnvSchema)
+	}
+
+	details, err := src.GetServer("io.github.acme/weather")
+	if err != nil || details.Description != "Weather" {
+		t.Errorf("GetServer = %+v, %v", details, err)
+	}
+}
+
+// TestPackageMCPServer covers package shapes the listing test doesn't.
+func TestPackageMCPServer(t *testing.T) {
+	tests := []struct {
+		pkg     Package
+		want    config.MCPServer
+		wantErr bool
+	}{
+		{pkg: Package{RegistryType: PackageOCI, Identifier: "acme/db:latest", Version: "2.0.0"}, want: config.MCPServer{Command: "docker", Args: []string{"run", "-i", "--rm", "acme/db:latest"}}},
+		{pkg: Package{RegistryType: PackageOCI, Identifier: "localhost:5000/db", Version: "1"}, want: config.MCPServer{Command: "docker", Args: []string{"run", "-i", "--rm", "localhost:5000/db:1"}}},
+		{pkg: Package{RegistryType: PackageOCI, Identifier: "acme/db", RuntimeArguments: []Argument{{Type: "positional", Value: "-e"}, {Type: "positional", Value: "TOKEN=x"}},
+			EnvironmentVariables: []EnvironmentVariable{{Name: "TOKEN"}, {Name: "REGION"}}},
+			want: config.MCPServer{Command: "docker", Args: []string{"run", "-i", "--rm", "-e", "REGION", "-e", "TOKEN=x", "acme/db"}}},
+		{pkg: Package{RegistryType: PackageNPM, Identifier: "unpinned"}, want: config.MCPServer{Command: "npx", Args: []string{"-y", "unpinned"}}},
+		{pkg: Package{RegistryType: "nuget", Identifier: "Acme.Mcp"}, wantErr: true},
+		{pkg: Package{RegistryType: PackageNPM, Identifier: "http-only", Transport: "streamable-http"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.pkg.MCPServer()
+		if (err != nil) != tt.wantErr || (!tt.wantErr && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%s %s: got %+v, %v", tt.pkg.RegistryType, tt.pkg.Identifier, got, err)
+		}
+	}
+}
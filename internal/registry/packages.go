@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// Package registry types used in server.json "packages"
+const (
+	PackageNPM  = "npm"
+	PackagePyPI = "pypi"
+	PackageOCI  = "oci"
+)
+
+// Package is a published package a server can be run from, as in server.json "packages".
+type Package struct {
+	RegistryType string `json:"registryType"`
+	Identifier   string `json:"identifier"`
+	Version      string `json:"version,omitempty"`
+	// RuntimeHint is the suggested runner, e.g. "npx", "uvx" or "docker"
+	RuntimeHint string `json:"runtimeHint,omitempty"`
+	// Transport is how clients talk to the running package: "stdio" (default),
+	// "streamable-http" or "sse"
+	Transport string `json:"transport,omitempty"`
+	// RuntimeArguments go to the runner and PackageArguments to the server itself
+	RuntimeArguments []Argument `json:"runtimeArguments,omitempty"`
+	PackageArguments []Argument `json:"packageArguments,omitempty"`
+	// EnvironmentVariables are the variables the server reads
+	EnvironmentVariables []EnvironmentVariable `json:"environmentVariables,omitempty"`
+}
+
+// EnvironmentVariable is a variable a package reads, as in server.json "environmentVariables".
+type EnvironmentVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	IsRequired  bool   `json:"isRequired,omitempty"`
+	IsSecret    bool   `json:"isSecret,omitempty"`
+	Default     string `json:"default,omitempty"`
+	// Format is "string" (default), "number", "boolean" or "filepath"
+	Format  string   `json:"format,omitempty"`
+	Choices []string `json:"choices,omitempty"`
+}
+
+// Argument is a server.json command-line argument: a positional value or a named flag.
+type Argument struct {
+	Type  string `json:"type"` // "positional" or "named"
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+	// Default is used when Value is unset. Arguments with neither need user input and are left out.
+	Default string `json:"default,omitempty"`
+}
+
+// Remote is a hosted endpoint of a server, as in server.json "remotes".
+type Remote struct {
+	Type string `json:"type"` // "streamable-http" or "sse"
+	URL  string `json:"url"`
+}
+
+// MCPServer builds the command that runs the package, pinned to its version:
+// "npx -y pkg@version", "uvx pkg==version" or "docker run -i --rm image:version".
+func (p Package) MCPServer() (config.MCPServer, error) {
+	if p.Transport != "" && p.Transport != "stdio" {
+		return config.MCPServer{}, fmt.Errorf("%s package %s uses the %s transport, which needs a URL once it is running", p.RegistryType, p.Identifier, p.Transport)
+	}
+	if p.Identifier == "" {
+		return config.MCPServer{}, fmt.Errorf("%s package has no identifier", p.RegistryType)
+	}
+
+	var server config.MCPServer
+	switch p.RegistryType {
+	case PackageNPM:
+		spec := p.Identifier
+		if p.Version != "" {
+			spec += "@" + p.Version
+		}
+		server.Command = "npx"
+		server.Args = append(append([]string{"-y"}, argumentValues(p.RuntimeArguments)...), spec)
+	case PackagePyPI:
+		spec := p.Identifier
+		if p.Version != "" {
+			spec += "==" + p.Version
+		}
+		server.Command = "uvx"
+		server.Args = append(argumentValues(p.RuntimeArguments), spec)
+	case PackageOCI:
+		image := p.Identifier
+		if p.Version != "" && !imageHasTag(image) {
+			image += ":" + p.Version
+		}
+		// The container only sees the variables passed to it with -e
+		runtimeArgs := argumentValues(p.RuntimeArguments)
+		server.Command = "docker"
+		server.Args = []string{"run", "-i", "--rm"}
+		for _, v := range p.EnvironmentVariables {
+			if v.Name != "" && !passesEnv(runtimeArgs, v.Name) {
+				server.Args = append(server.Args, "-e", v.Name)
+			}
+		}
+		server.Args = append(append(server.Args, runtimeArgs...), image)
+	default:
+		return config.MCPServer{}, fmt.Errorf("%s packages are not supported", p.RegistryType)
+	}
+	server.Args = append(server.Args, argumentValues(p.PackageArguments)...)
+	return server, nil
+}
+
+// MCPServer points at the remote endpoint.
+func (r Remote) MCPServer() config.MCPServer {
+	return config.MCPServer{URL: r.URL}
+}
+
+// argumentValues flattens arguments that have a value into command-line words.
+func argumentValues(args []Argument) []string {
+	var words []string
+	for _, arg := range args {
+		value := arg.Value
+		if value == "" {
+			value = arg.Default
+		}
+		switch {
+		case arg.Type == "named" && arg.Name != "":
+			if value == "" {
+				continue
+			}
+			words = append(words, arg.Name, value)
+		case value != "":
+			words = append(words, value)
+		}
+	}
+	return words
+}
+
+// passesEnv reports whether docker arguments already pass the variable name with -e or --env.
+func passesEnv(args []string, name string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] != "-e" && args[i] != "--env" {
+			continue
+		}
+		if v := args[i+1]; v == name || strings.HasPrefix(v, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// packagesEnvSchema describes the variables the packages read as an EnvSchema, or returns
+// nil if they declare none. The packages of a server run the same code, so their
+// variables are merged, and one required by any package is required.
+func packagesEnvSchema(packages []Package) *EnvSchema {
+	schema := &EnvSchema{Properties: make(map[string]EnvProperty)}
+	for _, p := range packages {
+		for _, v := range p.EnvironmentVariables {
+			if v.Name == "" {
+				continue
+			}
+			if _, ok := schema.Properties[v.Name]; !ok {
+				prop := EnvProperty{Description: v.Description}
+				if v.Format == "number" || v.Format == "boolean" {
+					prop.Type = v.Format
+				}
+				if v.IsSecret {
+					prop.Format = "password"
+				}
+				if v.Default != "" {
+					prop.Default = v.Default
+				}
+				for _, choice := range v.Choices {
+					prop.Enum = append(prop.Enum, choice)
+				}
+				schema.Properties[v.Name] = prop
+			}
+			if v.IsRequired && !slices.Contains(schema.Required, v.Name) {
+				schema.Required = append(schema.Required, v.Name)
+			}
+		}
+	}
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+	return schema
+}
+
+// imageHasTag reports whether an OCI image reference already names a tag or digest.
+func imageHasTag(image string) bool {
+	if strings.Contains(image, "@") {
+		return true
+	}
+	last := image[strings.LastIndex(image, "/")+1:]
+	return strings.Contains(last, ":")
+}
@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// Runners a Resolution can choose
+const (
+	RunnerNPX    = "npx"
+	RunnerUVX    = "uvx"
+	RunnerPipx   = "pipx"
+	RunnerDocker = "docker"
+	RunnerURL    = "url"
+	// RunnerCommand runs the command the registry gives as is
+	RunnerCommand = "command"
+)
+
+// Resolution is how to run a server, as chosen by a Resolver.
+type Resolution struct {
+	Server config.MCPServer
+	Runner string
+	// Version is the version the command is pinned to, "" if it couldn't be pinned
+	Version string
+	// Reasons explain the choice, in the order it was made
+	Reasons []string
+	// Guessed is set when nothing ties the command to the server: it runs a package that
+	// is only named like the server, or "npx -y <server ID>"
+	Guessed bool
+}
+
+// Resolver chooses how to run a server found in a registry. A configuration the
+// registry gives (ServerData.Config) is used as is; otherwise it tries, in order:
+//  1. packages the registry lists whose runner is installed (npx, uvx or pipx, docker)
+//  2. remote endpoints the registry lists
+//  3. packages whose runner isn't installed yet
+//  4. a server.json, package.json or pyproject.toml in the server's GitHub repository
+//  5. an npm or PyPI package named like the server, as a guess
+//
+// and otherwise guesses an npm package named like the server. Unpinned packages are
+// pinned to the latest version published on npm or PyPI.
+type Resolver struct {
+	NPMRegistryURL string // e.g. https://registry.npmjs.org
+	PyPIURL        string // e.g. https://pypi.org/pypi
+	GitHubRawURL   string // e.g. https://raw.githubusercontent.com
+	// LookPath finds runners on PATH
+	LookPath func(file string) (string, error)
+	// SkipRepository leaves out the server's repository manifests, which no registry
+	// signature covers (see ResolverFor)
+	SkipRepository bool
+}
+
+// NewResolver returns a Resolver using the public package registries.
+func NewResolver() *Resolver {
+	return &Resolver{
+		NPMRegistryURL: "https://registry.npmjs.org",
+		PyPIURL:        "https://pypi.org/pypi",
+		GitHubRawURL:   "https://raw.githubusercontent.com",
+		LookPath:       exec.LookPath,
+	}
+}
+
+// ResolverFor returns a Resolver for a server listed by one of registries: when its
+// registry requires signatures, only what the registry signed is trusted, so the
+// server's repository manifests are skipped.
+func ResolverFor(registries []config.Registry, data *ServerData) *Resolver {
+	r := NewResolver()
+	if data == nil {
+		return r
+	}
+	for _, reg := range registries {
+		if reg.Name == data.Registry && reg.RequireSignature {
+			r.SkipRepository = true
+		}
+	}
+	return r
+}
+
+// Resolve chooses how to run serverID. data is the server's registry entry, or nil if
+// the server was named directly.
+func (r *Resolver) Resolve(serverID string, data *ServerData) Resolution {
+	var res Resolution
+
+	if data != nil && data.Config != nil {
+		res.Server = copyServer(*data.Config)
+		res.Runner = RunnerCommand
+		if res.Server.URL != "" && res.Server.Command == "" {
+			res.Runner = RunnerURL
+		}
+		res.Version = data.Version
+		res.Reasons = append(res.Reasons, "The registry gives the exact configuration to install")
+		return res
+	}
+
+	if data != nil {
+		if r.fromPackages(&res, data.Packages, "the registry", true) || r.fromRemotes(&res, data.Remotes) ||
+			r.fromPackages(&res, data.Packages, "the registry", false) {
+			return res
+		}
+		if slices.Contains(data.Attributes, AttributeRemoteCapable) {
+			res.Reasons = append(res.Reasons, "The registry marks it remote-capable but lists no endpoint")
+		}
+		if data.RepositoryURL != "" {
+			if r.SkipRepository {
+				res.Reasons = append(res.Reasons, "Skipped the repository's manifests: the registry requires signatures, and they aren't signed")
+			} else if r.fromRepository(&res, data.RepositoryURL) {
+				return res
+			}
+		}
+	}
+
+	if r.fromPackageName(&res, serverID) {
+		return res
+	}
+
+	res.Server = config.MCPServer{Command: "npx", Args: []string{"-y", serverID}, Env: make(map[string]string)}
+	res.Runner = RunnerNPX
+	res.Guessed = true
+	res.Reasons = append(res.Reasons, fmt.Sprintf("Nothing identifies a package to run, so npm package %s is a guess; review mcp.json", serverID))
+	return res
+}
+
+// fromPackages picks the first runnable package. With installedOnly, only packages whose
+// runner is on PATH are considered.
+func (r *Resolver) fromPackages(res *Resolution, packages []Package, source string, installedOnly bool) bool {
+	for _, pkg := range packages {
+		if _, err := pkg.MCPServer(); err != nil {
+			if installedOnly {
+				res.Reasons = append(res.Reasons, fmt.Sprintf("Skipped: %v", err))
+			}
+			continue
+		}
+
+		runner := r.runner(pkg.RegistryType)
+		if installedOnly && runner == "" {
+			continue
+		}
+
+		res.Reasons = append(res.Reasons, fmt.Sprintf("%s lists %s package %s", capitalize(source), pkg.RegistryType, pkg.Identifier))
+		if pkg.Version == "" {
+			r.pin(res, &pkg)
+		}
+		server, _ := pkg.MCPServer()
+
+		switch {
+		case runner == "":
+			runner = defaultRunner(pkg.RegistryType)
+			res.Reasons = append(res.Reasons, fmt.Sprintf("%s is not on PATH; install it before applying", runner))
+		case runner == RunnerPipx:
+			// pipx runs the same package spec as uvx
+			server.Command, server.Args = "pipx", append([]string{"run"}, server.Args...)
+			res.Reasons = append(res.Reasons, "uvx is not on PATH, so it runs with pipx")
+		}
+
+		res.Server = server
+		res.Runner = runner
+		res.Version = pkg.Version
+		return true
+	}
+	return false
+}
+
+// fromRemotes picks a remote endpoint, preferring streamable HTTP over SSE.
+func (r *Resolver) fromRemotes(res *Resolution, remotes []Remote) bool {
+	var chosen *Remote
+	for i, remote := range remotes {
+		if remote.URL != "" && (chosen == nil || (remote.Type == "streamable-http" && chosen.Type != "streamable-http")) {
+			chosen = &remotes[i]
+		}
+	}
+	if chosen == nil {
+		return false
+	}
+	res.Server = chosen.MCPServer()
+	res.Runner = RunnerURL
+	res.Reasons = append(res.Reasons, fmt.Sprintf("The registry lists a hosted %s endpoint", chosen.Type))
+	return true
+}
+
+var githubRepo = regexp.MustCompile(`^https?://github\.com/([^/]+)/([^/#?]+?)(?:\.git)?(?:/tree/([^/]+)(/[^#?]*)?)?/?$`)
+
+// fromRepository looks for package manifests in a GitHub repository. They are read
+// from GitHub as they are, unchecked by the registry's signature, and the reasons say so.
+func (r *Resolver) fromRepository(res *Resolution, repositoryURL string) bool {
+	m := githubRepo.FindStringSubmatch(repositoryURL)
+	if m == nil {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("Can't read manifests from %s, which is not a GitHub repository", repositoryURL))
+		return false
+	}
+	ref := m[3]
+	if ref == "" {
+		ref = "HEAD"
+	}
+	base := strings.TrimSuffix(fmt.Sprintf("%s/%s/%s/%s%s", r.GitHubRawURL, m[1], m[2], ref, m[4]), "/")
+	found := func() bool {
+		res.Reasons = append(res.Reasons, "The repository's manifests are read from GitHub, not from the registry, so no registry signature covers them")
+		return true
+	}
+
+	if body, err := fetch(base + "/server.json"); err == nil {
+		var doc serverJSON
+		if json.Unmarshal(body, &doc) == nil {
+			data := doc.serverData()
+			if r.fromPackages(res, data.Packages, "server.json in the repository", true) || r.fromRemotes(res, data.Remotes) ||
+				r.fromPackages(res, data.Packages, "server.json in the repository", false) {
+				return found()
+			}
+		}
+	}
+
+	if body, err := fetch(base + "/package.json"); err == nil {
+		var manifest struct {
+			Name    string `json:"name"`
+			Private bool   `json:"private"`
+		}
+		if json.Unmarshal(body, &manifest) == nil && manifest.Name != "" {
+			if manifest.Private {
+				res.Reasons = append(res.Reasons, fmt.Sprintf("package.json names %s, but it is private", manifest.Name))
+			} else if r.published(res, Package{RegistryType: PackageNPM, Identifier: manifest.Name}, "package.json in the repository") {
+				return found()
+			}
+		}
+	}
+
+	if body, err := fetch(base + "/pyproject.toml"); err == nil {
+		var manifest struct {
+			Project struct {
+				Name string `toml:"name"`
+			} `toml:"project"`
+		}
+		if toml.Unmarshal(body, &manifest) == nil && manifest.Project.Name != "" {
+			if r.published(res, Package{RegistryType: PackagePyPI, Identifier: manifest.Project.Name}, "pyproject.toml in the repository") {
+				return found()
+			}
+		}
+	}
+
+	return false
+}
+
+var (
+	npmName  = regexp.MustCompile(`^(@[a-z0-9-~][a-z0-9-._~]*/)?[a-z0-9-~][a-z0-9-._~]*$`)
+	pypiName = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?$`)
+)
+
+// fromPackageName looks for a published npm or PyPI package named like the server.
+// Nothing ties such a package to the server, so picking it is a guess.
+func (r *Resolver) fromPackageName(res *Resolution, serverID string) bool {
+	var registryType string
+	switch {
+	case npmName.MatchString(serverID) && r.published(res, Package{RegistryType: PackageNPM, Identifier: serverID}, "the npm registry"):
+		registryType = PackageNPM
+	case pypiName.MatchString(serverID) && r.published(res, Package{RegistryType: PackagePyPI, Identifier: serverID}, "the pypi registry"):
+		registryType = PackagePyPI
+	default:
+		return false
+	}
+	res.Guessed = true
+	res.Reasons = append(res.Reasons, fmt.Sprintf("Nothing links %s package %s to the server but its name, so it is a guess; review mcp.json", registryType, serverID))
+	return true
+}
+
+// published picks pkg, pinned to its latest version, if it is published on npm or PyPI.
+func (r *Resolver) published(res *Resolution, pkg Package, source string) bool {
+	version := r.latestVersion(pkg)
+	if version == "" {
+		return false
+	}
+	pkg.Version = version
+	if !r.fromPackages(res, []Package{pkg}, source, false) {
+		return false
+	}
+	res.Reasons = append(res.Reasons, fmt.Sprintf("Pinned to %s, the latest version on %s", version, pkg.RegistryType))
+	return true
+}
+
+// pin sets an unpinned package's version to the latest one published.
+func (r *Resolver) pin(res *Resolution, pkg *Package) {
+	if pkg.RegistryType == PackageOCI {
+		if !imageHasTag(pkg.Identifier) {
+			res.Reasons = append(res.Reasons, "Not pinned: no image tag is given")
+		}
+		return
+	}
+	if version := r.latestVersion(*pkg); version != "" {
+		pkg.Version = version
+		res.Reasons = append(res.Reasons, fmt.Sprintf("Pinned to %s, the latest version on %s", version, pkg.RegistryType))
+	} else {
+		res.Reasons = append(res.Reasons, fmt.Sprintf("Not pinned: no published version of %s was found", pkg.Identifier))
+	}
+}
+
+// latestVersion returns the latest published version of an npm or PyPI package, or "".
+func (r *Resolver) latestVersion(pkg Package) string {
+	var latest struct {
+		Version string `json:"version"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	switch pkg.RegistryType {
+	case PackageNPM:
+		if getJSON(fmt.Sprintf("%s/%s/latest", r.NPMRegistryURL, pkg.Identifier), &latest) == nil {
+			return latest.Version
+		}
+	case PackagePyPI:
+		if getJSON(fmt.Sprintf("%s/%s/json", r.PyPIURL, url.PathEscape(pkg.Identifier)), &latest) == nil {
+			return latest.Info.Version
+		}
+	}
+	return ""
+}
+
+// runner returns the installed runner for a package type, or "".
+func (r *Resolver) runner(registryType string) string {
+	candidates := map[string][]string{
+		PackageNPM:  {RunnerNPX},
+		PackagePyPI: {RunnerUVX, RunnerPipx},
+		PackageOCI:  {RunnerDocker},
+	}[registryType]
+	for _, name := range candidates {
+		if _, err := r.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func defaultRunner(registryType string) string {
+	switch registryType {
+	case PackagePyPI:
+		return RunnerUVX
+	case PackageOCI:
+		return RunnerDocker
+	}
+	return RunnerNPX
+}
+
+// copyServer copies a server configuration, so changes to the copy's env and
+// arguments don't reach the registry data.
+func copyServer(server config.MCPServer) config.MCPServer {
+	server.Args = slices.Clone(server.Args)
+	server.AutoApprove = slices.Clone(server.AutoApprove)
+	server.SecretEnv = slices.Clone(server.SecretEnv)
+	env := make(map[string]string, len(server.Env))
+	for key, value := range server.Env {
+		env[key] = value
+	}
+	server.Env = env
+	return server
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
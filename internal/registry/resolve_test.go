@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// TestResolver covers each source the resolver reads, with stand-ins for npm, PyPI and
+// raw GitHub content.
+func TestResolver(t *testing.T) {
+	files := map[string]string{
+		"/npm/@acme/files/latest":                    `{"name": "@acme/files", "version": "1.4.0"}`,
+		"/npm/acme-notes/latest":                     `{"name": "acme-notes", "version": "0.9.0"}`,
+		"/npm/weather-mcp/latest":                    `{"name": "weather-mcp", "version": "3.0.1"}`,
+		"/pypi/acme-search/json":                     `{"info": {"version": "2.1.0"}}`,
+		"/raw/acme/notes/HEAD/package.json":          `{"name": "acme-notes"}`,
+		"/raw/acme/search/HEAD/package.json":         `{"name": "search-workspace", "private": true}`,
+		"/raw/acme/search/HEAD/pyproject.toml":       "[project]\nname = \"acme-search\"\n",
+		"/raw/acme/mono/main/servers/db/server.json": `{"name": "io.github.acme/db", "packages": [{"registryType": "oci", "identifier": "ghcr.io/acme/db", "version": "2.0.0", "transport": {"type": "stdio"}}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	resolverWith := func(installed ...string) *Resolver {
+		return &Resolver{
+			NPMRegistryURL: srv.URL + "/npm",
+			PyPIURL:        srv.URL + "/pypi",
+			GitHubRawURL:   srv.URL + "/raw",
+			LookPath: func(file string) (string, error) {
+				if slices.Contains(installed, file) {
+					return "/usr/bin/" + file, nil
+				}
+				return "", errors.New("not found")
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		serverID  string
+		data      *ServerData
+		installed []string
+		want      config.MCPServer
+		runner    string
+		version   string
+		guessed   bool
+		// skipRepository is set for registries that require signatures
+		skipRepository bool
+	}{
+		{
+			name:      "registry package pinned to latest",
+			serverID:  "files",
+			data:      &ServerData{Packages: []Package{{RegistryType: PackageNPM, Identifier: "@acme/files"}}},
+			installed: []string{"npx"},
+			want:      config.MCPServer{Command: "npx", Args: []string{"-y", "@acme/files@1.4.0"}},
+			runner:    RunnerNPX,
+			version:   "1.4.0",
+		},
+		{
+			name:      "pipx when uvx is missing",
+			serverID:  "search",
+			data:      &ServerData{Packages: []Package{{RegistryType: PackagePyPI, Identifier: "acme-search", Version: "2.0.0"}}},
+			installed: []string{"pipx"},
+			want:      config.MCPServer{Command: "pipx", Args: []string{"run", "acme-search==2.0.0"}},
+			runner:    RunnerPipx,
+			version:   "2.0.0",
+		},
+		{
+			name:     "remote before a package whose runner is missing",
+			serverID: "hosted",
+			data: &ServerData{
+				Packages: []Package{{RegistryType: PackageOCI, Identifier: "ghcr.io/acme/hosted:1"}},
+				Remotes:  []Remote{{Type: "sse", URL: "https://mcp.acme.com/sse"}, {Type: "streamable-http", URL: "https://mcp.acme.com/mcp"}},
+			},
+			want:   config.MCPServer{URL: "https://mcp.acme.com/mcp"},
+			runner: RunnerURL,
+		},
+		{
+			name:     "package whose runner is missing",
+			serverID: "db",
+			data:     &ServerData{Packages: []Package{{RegistryType: PackageOCI, Identifier: "ghcr.io/acme/db", Version: "2.0.0"}}},
+			want:     config.MCPServer{Command: "docker", Args: []string{"run", "-i", "--rm", "ghcr.io/acme/db:2.0.0"}},
+			runner:   RunnerDocker,
+			version:  "2.0.0",
+		},
+		{
+			name:      "package.json in the repository",
+			serverID:  "acme/notes",
+			data:      &ServerData{RepositoryURL: "https://github.com/acme/notes.git"},
+			installed: []string{"npx"},
+			want:      config.MCPServer{Command: "npx", Args: []string{"-y", "acme-notes@0.9.0"}},
+			runner:    RunnerNPX,
+			version:   "0.9.0",
+		},
+		{
+			name:      "pyproject.toml when package.json is private",
+			serverID:  "acme/search",
+			data:      &ServerData{RepositoryURL: "https://github.com/acme/search"},
+			installed: []string{"uvx"},
+			want:      config.MCPServer{Command: "uvx", Args: []string{"acme-search==2.1.0"}},
+			runner:    RunnerUVX,
+			version:   "2.1.0",
+		},
+		{
+			name:      "server.json in a repository subdirectory",
+			serverID:  "acme/db",
+			data:      &ServerData{RepositoryURL: "https://github.com/acme/mono/tree/main/servers/db"},
+			installed: []string{"docker"},
+			want:      config.MCPServer{Command: "docker", Args: []string{"run", "-i", "--rm", "ghcr.io/acme/db:2.0.0"}},
+			runner:    RunnerDocker,
+			version:   "2.0.0",
+		},
+		{
+			name:      "package named like the server",
+			serverID:  "weather-mcp",
+			installed: []string{"npx"},
+			want:      config.MCPServer{Command: "npx", Args: []string{"-y", "weather-mcp@3.0.1"}},
+			runner:    RunnerNPX,
+			version:   "3.0.1",
+			guessed:   true,
+		},
+		{
+			name:           "repository skipped when signatures are required",
+			serverID:       "acme/notes",
+			data:           &ServerData{RepositoryURL: "https://github.com/acme/notes.git"},
+			installed:      []string{"npx"},
+			skipRepository: true,
+			want:           config.MCPServer{Command: "npx", Args: []string{"-y", "acme/notes"}, Env: map[string]string{}},
+			runner:         RunnerNPX,
+			guessed:        true,
+		},
+		{
+			name:     "guess",
+			serverID: "Unknown Server",
+			data:     &ServerData{RepositoryURL: "https://gitlab.com/acme/unknown"},
+			want:     config.MCPServer{Command: "npx", Args: []string{"-y", "Unknown Server"}, Env: map[string]string{}},
+			runner:   RunnerNPX,
+			guessed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolverWith(tt.installed...)
+			r.SkipRepository = tt.skipRepository
+			got := r.Resolve(tt.serverID, tt.data)
+			if !reflect.DeepEqual(got.Server, tt.want) || got.Runner != tt.runner || got.Version != tt.version || got.Guessed != tt.guessed {
+				t.Errorf("Resolve = %+v, %s, %q, guessed %v; want %+v, %s, %q, guessed %v (reasons: %v)",
+					got.Server, got.Runner, got.Version, got.Guessed, tt.want, tt.runner, tt.version, tt.guessed, got.Reasons)
+			}
+			if len(got.Reasons) == 0 {
+				t.Error("Resolve gave no reasons")
+			}
+		})
+	}
+}
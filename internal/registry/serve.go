@@ -0,0 +1,495 @@
+package registry
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/secrets"
+)
+
+// Catalog is a registry built from mcp.json files and metadata files, served by
+// 'mcpenetes registry serve' (see Handler). The files are read again when they change.
+type Catalog struct {
+	// MCPFiles are mcp.json files; a server in several of them is taken from the last
+	MCPFiles []string
+	// MetadataFiles describe the servers (see ServerMetadata), in YAML or JSON
+	MetadataFiles []string
+	// SigningKey, if set, signs every response (see SignatureHeader)
+	SigningKey ed25519.PrivateKey
+
+	mu        sync.Mutex
+	servers   []ServerData
+	updatedAt time.Time
+	modTimes  map[string]time.Time
+	loadedAt  time.Time
+	// removed records when servers dropped out of the files, for updated_since listings
+	removed map[string]time.Time
+}
+
+// ServerMetadata describes a served server: what mcp.json has no place for.
+type ServerMetadata struct {
+	// Name is shown instead of the mcp.json key, which stays the server's ID
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Repository  string   `yaml:"repository"`
+	Version     string   `yaml:"version"`
+	License     string   `yaml:"license"`
+	Attributes  []string `yaml:"attributes"`
+	Tools       []Tool   `yaml:"tools"`
+	// Env describes the environment variables installers are prompted for
+	Env map[string]EnvMetadata `yaml:"env"`
+}
+
+// EnvMetadata describes one environment variable of a served server.
+type EnvMetadata struct {
+	Description string   `yaml:"description"`
+	Required    bool     `yaml:"required"`
+	Secret      bool     `yaml:"secret"`
+	Default     string   `yaml:"default"`
+	Enum        []string `yaml:"enum"`
+	Pattern     string   `yaml:"pattern"`
+}
+
+// metadataFile is the layout of a metadata file: servers by mcp.json key.
+type metadataFile struct {
+	Servers map[string]ServerMetadata `yaml:"servers"`
+}
+
+// Servers returns the catalog, sorted by ID, and when its files last changed. The
+// files are read again if any of them changed since the last call.
+func (c *Catalog) Servers() ([]ServerData, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	modTimes := make(map[string]time.Time)
+	for _, path := range append(slices.Clone(c.MCPFiles), c.MetadataFiles...) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to read catalog file: %w", err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+	if c.modTimes != nil && maps.EqualFunc(c.modTimes, modTimes, time.Time.Equal) {
+		return c.servers, c.updatedAt, nil
+	}
+
+	servers, err := c.load()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	now := time.Now()
+	if c.modTimes == nil {
+		c.loadedAt = now
+		c.removed = make(map[string]time.Time)
+	}
+	for _, old := range c.servers {
+		if !slices.ContainsFunc(servers, func(s ServerData) bool { return s.ID == old.ID }) {
+			c.removed[old.ID] = now
+		}
+	}
+	for _, s := range servers {
+		delete(c.removed, s.ID)
+	}
+
+	c.servers, c.modTimes = servers, modTimes
+	c.updatedAt = time.Time{}
+	for _, t := range modTimes {
+		if t.After(c.updatedAt) {
+			c.updatedAt = t
+		}
+	}
+	return c.servers, c.updatedAt, nil
+}
+
+// load reads the files into servers.
+func (c *Catalog) load() ([]ServerData, error) {
+	configs := make(map[string]config.MCPServer)
+	for _, path := range c.MCPFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalog file: %w", err)
+		}
+		var mcpCfg config.MCPConfig
+		if err := json.Unmarshal(data, &mcpCfg); err != nil {
+			return nil, fmt.Errorf("failed to parse mcp config file '%s': %w", path, err)
+		}
+		for name, server := range mcpCfg.MCPServers {
+			if _, ok := configs[name]; ok {
+				log.Warn("Server '%s' in %s replaces the one in an earlier file", name, path)
+			}
+			configs[name] = server
+		}
+	}
+
+	meta := make(map[string]ServerMetadata)
+	for _, path := range c.MetadataFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalog file: %w", err)
+		}
+		var file metadataFile
+		// YAML is a superset of JSON, so this reads both
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse metadata file '%s': %w", path, err)
+		}
+		for name, m := range file.Servers {
+			if _, ok := configs[name]; !ok {
+				return nil, fmt.Errorf("metadata file '%s' describes server '%s', which no mcp.json file has", path, name)
+			}
+			meta[name] = m
+		}
+	}
+
+	servers := make([]ServerData, 0, len(configs))
+	for name, server := range configs {
+		if server.Disabled {
+			continue
+		}
+		if where := secretOutsideEnv(server); where != "" {
+			log.Warn("Not serving '%s': %s looks like a secret. Move it to the server's env, which is never served.", name, where)
+			continue
+		}
+		servers = append(servers, catalogServer(name, server, meta[name]))
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].ID < servers[j].ID })
+	return servers, nil
+}
+
+// secretOutsideEnv returns where a server has a secret-looking value outside its env,
+// or "": a secret reference, a NAME=value or --name value argument or a URL query
+// parameter whose name looks secret, a "Name: value" header argument likewise, or a
+// password in the URL. Only env values are
+// kept out of the catalog, so a server with one anywhere else isn't served at all.
+func secretOutsideEnv(server config.MCPServer) string {
+	if strings.Contains(server.Command, "${secret:") {
+		return "the command"
+	}
+	for i, arg := range server.Args {
+		where := fmt.Sprintf("argument %d (%s)", i+1, secretArgName(arg))
+		if strings.Contains(arg, "${secret:") {
+			return where
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue {
+			// A header, as in --header "Authorization: Bearer <token>"
+			name, value, hasValue = strings.Cut(arg, ":")
+			value = strings.TrimSpace(value)
+		}
+		if !secrets.LooksSecret(strings.ReplaceAll(name, "-", "_")) {
+			continue
+		}
+		switch {
+		case hasValue && value != "":
+			return where
+		case !hasValue && strings.HasPrefix(arg, "-") && i+1 < len(server.Args) && !strings.HasPrefix(server.Args[i+1], "-"):
+			// --api-key <value>
+			return fmt.Sprintf("argument %d (the value of %s)", i+2, arg)
+		}
+	}
+
+	if server.URL == "" {
+		return ""
+	}
+	if strings.Contains(server.URL, "${secret:") {
+		return "the URL"
+	}
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		return "the URL, which can't be parsed,"
+	}
+	if _, ok := u.User.Password(); ok {
+		return "the URL's password"
+	}
+	for key := range u.Query() {
+		if secrets.LooksSecret(strings.ReplaceAll(key, "-", "_")) {
+			return fmt.Sprintf("the URL's %s parameter", key)
+		}
+	}
+	return ""
+}
+
+// secretArgName names an argument in a warning without repeating its value.
+func secretArgName(arg string) string {
+	if name, _, ok := strings.Cut(arg, "="); ok {
+		return name + "=…"
+	}
+	if name, _, ok := strings.Cut(arg, ":"); ok && !strings.Contains(name, "/") {
+		return name + ": …"
+	}
+	if strings.HasPrefix(arg, "-") {
+		return arg
+	}
+	return "…"
+}
+
+// catalogServer builds a served server. Secret env values never leave the machine: they
+// are dropped from the configuration, and the env schema has installers enter them.
+func catalogServer(name string, server config.MCPServer, meta ServerMetadata) ServerData {
+	data := ServerData{
+		ID:            name,
+		Name:          name,
+		Description:   meta.Description,
+		RepositoryURL: meta.Repository,
+		Version:       meta.Version,
+		License:       meta.License,
+		Attributes:    meta.Attributes,
+		Tools:         meta.Tools,
+	}
+	if meta.Name != "" {
+		data.Name = meta.Name
+	}
+
+	server = copyServer(server)
+	schema := &EnvSchema{Properties: make(map[string]EnvProperty)}
+	for key, value := range server.Env {
+		_, isRef := secrets.ParseRef(value)
+		if isRef || slices.Contains(server.SecretEnv, key) || secrets.LooksSecret(key) || meta.Env[key].Secret {
+			delete(server.Env, key)
+			schema.Properties[key] = EnvProperty{Type: "string", Format: "password"}
+			schema.Required = append(schema.Required, key)
+		}
+	}
+	server.SecretEnv = nil
+	if len(server.Env) == 0 {
+		server.Env = nil
+	}
+
+	for key, env := range meta.Env {
+		prop := EnvProperty{Type: "string", Description: env.Description, Pattern: env.Pattern}
+		if env.Secret {
+			prop.Format = "password"
+		}
+		if env.Default != "" {
+			prop.Default = env.Default
+		}
+		for _, e := range env.Enum {
+			prop.Enum = append(prop.Enum, e)
+		}
+		schema.Properties[key] = prop
+		schema.Required = slices.DeleteFunc(schema.Required, func(k string) bool { return k == key })
+		if env.Required {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+	if len(schema.Properties) > 0 {
+		sort.Strings(schema.Required)
+		data.EnvSchema = schema
+	}
+
+	data.Config = &server
+	return data
+}
+
+// servedEntry is a listing entry as the official MCP Registry API serves it.
+type servedEntry struct {
+	Server servedServer `json:"server"`
+	Meta   servedMeta   `json:"_meta"`
+}
+
+type servedServer struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Repository  *struct {
+		URL string `json:"url"`
+	} `json:"repository,omitempty"`
+}
+
+type servedMeta struct {
+	Official struct {
+		Status    string    `json:"status"`
+		IsLatest  bool      `json:"isLatest"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	} `json:"io.modelcontextprotocol.registry/official"`
+	Mcpenetes mcpenetesMeta `json:"io.github.tuannvm/mcpenetes"`
+}
+
+func newServedEntry(s ServerData, updatedAt time.Time, status string) servedEntry {
+	entry := servedEntry{Server: servedServer{Name: s.ID, Description: s.Description, Version: s.Version}}
+	if s.Name != s.ID {
+		entry.Server.Title = s.Name
+	}
+	if s.RepositoryURL != "" {
+		entry.Server.Repository = &struct {
+			URL string `json:"url"`
+		}{URL: s.RepositoryURL}
+	}
+	entry.Meta.Official.Status = status
+	entry.Meta.Official.IsLatest = true
+	entry.Meta.Official.UpdatedAt = updatedAt.UTC()
+	entry.Meta.Mcpenetes = mcpenetesMeta{Config: s.Config, EnvSchema: s.EnvSchema, Tools: s.Tools, License: s.License, Attributes: s.Attributes}
+	return entry
+}
+
+// Page sizes of served listings
+const (
+	defaultServeLimit = 30
+	maxServeLimit     = 100
+)
+
+// Handler serves the catalog like the official MCP Registry API, so any mcpenetes can
+// add it as a registry of type "official":
+//
+//	GET /v0/servers?limit=&cursor=&updated_since=   a page of servers and "metadata.nextCursor"
+//	GET /v0/servers/{name}/versions/latest          one server
+//
+// Every response has an ETag, and is signed if the catalog has a SigningKey. A request
+// whose If-None-Match matches gets 304 Not Modified. updated_since lists the servers
+// whose files changed since then, and the ones removed while serving; for a time before
+// the catalog was first read, nothing says what was removed, so it answers 410 Gone
+// and clients list everything.
+func (c *Catalog) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v0/servers", c.handleList)
+	mux.HandleFunc("/v0/servers/", c.handleServer)
+	return mux
+}
+
+func (c *Catalog) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	servers, updatedAt, err := c.Servers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	params := r.URL.Query()
+	limit := defaultServeLimit
+	if l, err := strconv.Atoi(params.Get("limit")); err == nil && l > 0 {
+		limit = min(l, maxServeLimit)
+	}
+
+	var entries []servedEntry
+	if s := params.Get("updated_since"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "updated_since must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		loadedAt := c.loadedAt
+		for id, at := range c.removed {
+			if at.After(since) {
+				entries = append(entries, newServedEntry(ServerData{ID: id, Name: id}, at, "deleted"))
+			}
+		}
+		c.mu.Unlock()
+		// RFC 3339 times in the query have whole seconds. Before the catalog was loaded,
+		// only the files' modification time tells whether anything changed.
+		if since.Before(loadedAt.Truncate(time.Second)) && updatedAt.After(since) {
+			http.Error(w, "changes are only known since "+loadedAt.UTC().Format(time.RFC3339)+"; list every server", http.StatusGone)
+			return
+		}
+		if updatedAt.After(since) {
+			for _, s := range servers {
+				entries = append(entries, newServedEntry(s, updatedAt, "active"))
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Server.Name < entries[j].Server.Name })
+	} else {
+		for _, s := range servers {
+			entries = append(entries, newServedEntry(s, updatedAt, "active"))
+		}
+	}
+
+	// The cursor is the name of the first server of the next page
+	start := 0
+	if cursor := params.Get("cursor"); cursor != "" {
+		start = sort.Search(len(entries), func(i int) bool { return entries[i].Server.Name >= cursor })
+	}
+	end := min(start+limit, len(entries))
+
+	var resp struct {
+		Servers  []servedEntry `json:"servers"`
+		Metadata struct {
+			NextCursor string `json:"nextCursor,omitempty"`
+			Count      int    `json:"count"`
+		} `json:"metadata"`
+	}
+	resp.Servers = append([]servedEntry{}, entries[start:end]...)
+	resp.Metadata.Count = len(resp.Servers)
+	if end < len(entries) {
+		resp.Metadata.NextCursor = entries[end].Server.Name
+	}
+	c.write(w, r, resp)
+}
+
+func (c *Catalog) handleServer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.EscapedPath(), "/v0/servers/")
+	escaped, ok := strings.CutSuffix(rest, "/versions/latest")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := url.PathUnescape(escaped)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	servers, updatedAt, err := c.Servers()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	i := slices.IndexFunc(servers, func(s ServerData) bool { return s.ID == id })
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	c.write(w, r, newServedEntry(servers[i], updatedAt, "active"))
+}
+
+// write writes v as JSON with an ETag of its content and its signature, or 304 Not
+// Modified if the client already has it.
+func (c *Catalog) write(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if c.SigningKey != nil {
+		w.Header().Set(SignatureHeader, Sign(c.SigningKey, body))
+	}
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		if c := strings.TrimSpace(candidate); c == etag || c == "W/"+etag || c == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,192 @@
+package registry
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// Registry payloads can be signed with ed25519. The signature is over the exact bytes of
+// the payload, base64 encoded, and is given in the SignatureHeader of an HTTP response
+// or in a file next to the payload named like it with SignatureExt, e.g. index.json.sig.
+const (
+	SignatureHeader = "X-Registry-Signature"
+	SignatureExt    = ".sig"
+)
+
+// ErrNoSignature is returned for an unsigned payload from a registry that requires signatures.
+var ErrNoSignature = errors.New("payload is not signed")
+
+// trust checks a registry's payloads against its public keys (config.Registry.PublicKeys).
+// A nil trust accepts everything.
+type trust struct {
+	keys    []ed25519.PublicKey
+	require bool
+}
+
+// newTrust returns the checks for reg's payloads, or nil if it configures none.
+func newTrust(reg config.Registry) (*trust, error) {
+	if len(reg.PublicKeys) == 0 {
+		if reg.RequireSignature {
+			return nil, fmt.Errorf("registry %s requires signatures but has no public_keys", reg.Name)
+		}
+		return nil, nil
+	}
+	t := &trust{require: reg.RequireSignature}
+	for _, key := range reg.PublicKeys {
+		pub, err := ParsePublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", reg.Name, err)
+		}
+		t.keys = append(t.keys, pub)
+	}
+	return t, nil
+}
+
+// TrustKey identifies a registry's trust settings, its public_keys and require_signature,
+// so data cached or indexed under other settings isn't used: a registry that gets keys
+// is fetched and checked again. It is "" for a registry without keys.
+func TrustKey(reg config.Registry) string {
+	if len(reg.PublicKeys) == 0 && !reg.RequireSignature {
+		return ""
+	}
+	keys := make([]string, len(reg.PublicKeys))
+	for i, key := range reg.PublicKeys {
+		keys[i] = strings.TrimSpace(key)
+	}
+	slices.Sort(keys)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%t", strings.Join(keys, "\n"), reg.RequireSignature)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// getJSON fetches a registry URL like getJSON, checking the payload's signature first.
+func (t *trust) getJSON(registryURL string, v interface{}) error {
+	_, err := t.getDatedJSON(registryURL, v)
+	return err
+}
+
+// getDatedJSON is getJSON, also returning the time on the registry's clock when it
+// answered (its Date header), or zero when it doesn't say.
+func (t *trust) getDatedJSON(registryURL string, v interface{}) (time.Time, error) {
+	body, header, err := fetchSigned(registryURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t != nil {
+		signature := header.Get(SignatureHeader)
+		if signature == "" {
+			signature = fetchSidecar(registryURL)
+		}
+		if err := t.verify(body, signature); err != nil {
+			return time.Time{}, fmt.Errorf("%s: %w", registryURL, err)
+		}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse JSON from %s: %w", registryURL, err)
+	}
+	date, _ := http.ParseTime(header.Get("Date"))
+	return date, nil
+}
+
+// verifyFile checks a local file against the signature file next to it.
+func (t *trust) verifyFile(path string, body []byte) error {
+	if t == nil {
+		return nil
+	}
+	signature, _ := os.ReadFile(path + SignatureExt)
+	return t.verify(body, string(signature))
+}
+
+// verify accepts a payload signed by any trusted key. An unsigned payload is only
+// rejected if signatures are required; a bad signature always is.
+func (t *trust) verify(body []byte, signature string) error {
+	signature = strings.TrimSpace(signature)
+	if signature == "" {
+		if t.require {
+			return ErrNoSignature
+		}
+		return nil
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.New("signature is not a base64 ed25519 signature")
+	}
+	for _, key := range t.keys {
+		if ed25519.Verify(key, body, sig) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match any trusted public key")
+}
+
+// fetchSidecar returns the signature file next to a registry URL's payload, or "" if
+// there is none. The query is kept, for servers that sign each page.
+func fetchSidecar(registryURL string) string {
+	if path, ok := LocalPath(registryURL); ok {
+		signature, _ := os.ReadFile(path + SignatureExt)
+		return string(signature)
+	}
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return ""
+	}
+	u.Path += SignatureExt
+	u.RawPath = ""
+	signature, err := fetch(u.String())
+	if err != nil {
+		return ""
+	}
+	return string(signature)
+}
+
+// GenerateKey returns a new key pair, base64 encoded: the public key goes in the
+// registry's public_keys, the private key signs its payloads.
+func GenerateKey() (publicKey, privateKey string, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(pub), base64.StdEncoding.EncodeToString(priv), nil
+}
+
+// ParsePublicKey decodes a base64 ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key %q is not a base64 ed25519 public key", s)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ParsePrivateKey decodes a base64 ed25519 private key, or its 32-byte seed.
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("private key is not base64")
+	}
+	switch len(key) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(key), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(key), nil
+	}
+	return nil, errors.New("private key is not an ed25519 key")
+}
+
+// Sign returns the base64 signature of a payload.
+func Sign(key ed25519.PrivateKey, payload []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, payload))
+}
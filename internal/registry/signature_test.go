@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+const signedIndex = `{"servers": [{"server": {"name": "acme/files", "remotes": [{"type": "streamable-http", "url": "https://mcp.acme.com/mcp"}]}}], "metadata": {}}`
+
+// TestSignatures verifies that signed payloads are checked against a registry's public
+// keys, from a .sig file or the signature header of a served catalog.
+func TestSignatures(t *testing.T) {
+	pub, priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParsePrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := GenerateKey()
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"signed.json":                  signedIndex,
+		"signed.json" + SignatureExt:   Sign(key, []byte(signedIndex)) + "\n",
+		"tampered.json":                signedIndex + " ",
+		"tampered.json" + SignatureExt: Sign(key, []byte(signedIndex)),
+		"unsigned.json":                signedIndex,
+	})
+
+	tests := []struct {
+		file    string
+		keys    []string
+		require bool
+		wantErr bool
+	}{
+		{file: "signed.json", keys: []string{pub}, require: true},
+		{file: "signed.json", keys: []string{otherPub, pub}, require: true},
+		{file: "signed.json", keys: []string{otherPub}, wantErr: true},
+		{file: "tampered.json", keys: []string{pub}, wantErr: true},
+		{file: "unsigned.json", keys: []string{pub}},
+		{file: "unsigned.json", keys: []string{pub}, require: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		reg := config.Registry{Name: "local", URL: filepath.Join(dir, tt.file), PublicKeys: tt.keys, RequireSignature: tt.require}
+		servers, err := FetchServers(reg, false)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FetchServers(%s, %d keys, require %v) error = %v, want error %v", tt.file, len(tt.keys), tt.require, err, tt.wantErr)
+			continue
+		}
+		if err == nil && len(servers) != 1 {
+			t.Errorf("FetchServers(%s) = %v", tt.file, serverIDs(servers))
+		}
+	}
+
+	if _, err := NewSource(config.Registry{Name: "nokeys", URL: dir, RequireSignature: true}); err == nil {
+		t.Error("NewSource should fail when signatures are required without public keys")
+	}
+	if _, err := NewSource(config.Registry{Name: "badkey", URL: dir, PublicKeys: []string{"not-a-key"}}); err == nil {
+		t.Error("NewSource should fail on a malformed public key")
+	}
+
+	// A directory registry skips manifests that fail the check
+	catalog := t.TempDir()
+	manifest := `{"name": "acme/signed", "remotes": [{"type": "streamable-http", "url": "https://mcp.acme.com/mcp"}]}`
+	writeFiles(t, catalog, map[string]string{
+		"signed.json":                manifest,
+		"signed.json" + SignatureExt: Sign(key, []byte(manifest)),
+		"unsigned.json":              `{"name": "acme/unsigned", "remotes": [{"type": "streamable-http", "url": "https://other.example.com/mcp"}]}`,
+	})
+	servers, err := FetchServers(config.Registry{Name: "catalog", URL: catalog, PublicKeys: []string{pub}, RequireSignature: true}, false)
+	if err != nil {
+		t.Fatalf("FetchServers(catalog) failed: %v", err)
+	}
+	if got := serverIDs(servers); !reflect.DeepEqual(got, []string{"acme/signed"}) {
+		t.Errorf("FetchServers(catalog) = %v", got)
+	}
+	problems, err := Lint(config.Registry{URL: catalog, PublicKeys: []string{pub}, RequireSignature: true})
+	if err != nil || !containsPrefix(problemStrings(problems), "unsigned.json: ") {
+		t.Errorf("Lint(catalog) = %v, %v", problems, err)
+	}
+
+	// A served catalog signs each response in the header
+	mcpFile := filepath.Join(dir, "mcp.json")
+	writeFiles(t, dir, map[string]string{"mcp.json": `{"mcpServers": {"docs": {"url": "https://docs.example.com/mcp"}}}`})
+	for _, signing := range []ed25519.PrivateKey{key, nil} {
+		srv := httptest.NewServer((&Catalog{MCPFiles: []string{mcpFile}, SigningKey: signing}).Handler())
+		src, err := NewSource(config.Registry{Name: "team", URL: srv.URL + "/v0/servers", Type: TypeOfficial, PublicKeys: []string{pub}, RequireSignature: true})
+		if err != nil {
+			srv.Close()
+			t.Fatalf("NewSource failed: %v", err)
+		}
+		servers, err := ListAll(src)
+		if signing != nil && (err != nil || len(servers) != 1) {
+			t.Errorf("ListAll(signed) = %v, %v", serverIDs(servers), err)
+		}
+		if signing == nil && !errors.Is(err, ErrNoSignature) {
+			t.Errorf("ListAll(unsigned) error = %v, want ErrNoSignature", err)
+		}
+		srv.Close()
+	}
+	if _, err := os.Stat(mcpFile + SignatureExt); err == nil {
+		t.Error("serving should not write signature files")
+	}
+}
+
+func TestTrustKey(t *testing.T) {
+	pub, _, _ := GenerateKey()
+	otherPub, _, _ := GenerateKey()
+	if key := TrustKey(config.Registry{Name: "plain"}); key != "" {
+		t.Errorf("TrustKey(no keys) = %q", key)
+	}
+	signed := TrustKey(config.Registry{PublicKeys: []string{pub, otherPub}})
+	if signed == "" || signed != TrustKey(config.Registry{PublicKeys: []string{otherPub, " " + pub}}) {
+		t.Error("TrustKey depends on the order of the keys")
+	}
+	if signed == TrustKey(config.Registry{PublicKeys: []string{pub, otherPub}, RequireSignature: true}) {
+		t.Error("TrustKey ignores require_signature")
+	}
+	if signed == TrustKey(config.Registry{PublicKeys: []string{pub}}) {
+		t.Error("TrustKey ignores a removed key")
+	}
+}
+
+func problemStrings(problems []Problem) []string {
+	var list []string
+	for _, p := range problems {
+		list = append(list, p.String())
+	}
+	return list
+}
@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// smitherySource reads the Smithery registry API (https://registry.smithery.ai/servers),
+// which numbers its pages and serves details at ".../servers/<qualifiedName>". The older
+// {"smitheryServers": [...]} index, a single page, is read too.
+type smitherySource struct {
+	url   string
+	trust *trust
+}
+
+type smitheryServer struct {
+	QualifiedName string `json:"qualifiedName"`
+	DisplayName   string `json:"displayName"`
+	Description   string `json:"description"`
+	Version       string `json:"version"`
+	Homepage      string `json:"homepage"`
+}
+
+type smitheryPage struct {
+	Servers         []smitheryServer `json:"servers"`
+	SmitheryServers []smitheryServer `json:"smitheryServers"`
+	Pagination      struct {
+		CurrentPage int `json:"currentPage"`
+		TotalPages  int `json:"totalPages"`
+	} `json:"pagination"`
+}
+
+func (s *smitherySource) ListPage(cursor string) (Page, error) {
+	pageURL := s.url
+	if cursor != "" {
+		pageURL = withQuery(s.url, url.Values{"page": {cursor}, "pageSize": {"100"}})
+	}
+
+	var resp smitheryPage
+	if err := s.trust.getJSON(pageURL, &resp); err != nil {
+		return Page{}, err
+	}
+
+	var page Page
+	for _, server := range append(resp.Servers, resp.SmitheryServers...) {
+		page.Servers = append(page.Servers, server.serverData())
+	}
+	if p := resp.Pagination; p.CurrentPage > 0 && p.CurrentPage < p.TotalPages {
+		page.NextCursor = strconv.Itoa(p.CurrentPage + 1)
+	}
+	return page, nil
+}
+
+func (s *smitherySource) GetServer(id string) (*ServerData, error) {
+	var server smitheryServer
+	if err := s.trust.getJSON(itemURL(s.url, id), &server); err != nil {
+		return nil, err
+	}
+	data := server.serverData()
+	return &data, nil
+}
+
+func (s smitheryServer) serverData() ServerData {
+	name := s.DisplayName
+	if name == "" {
+		name = s.QualifiedName
+	}
+	description := s.Description
+	if description == "" {
+		description = s.Version
+	}
+	return ServerData{
+		ID:          s.QualifiedName,
+		Name:        name,
+		Description: description,
+	}
+}
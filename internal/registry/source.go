@@ -0,0 +1,274 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+)
+
+// Registry types, set as config.Registry.Type
+const (
+	TypeGlama     = "glama"     // Glama API: "servers" and a "pageInfo" cursor
+	TypeSmithery  = "smithery"  // Smithery registry API: "servers" and numbered "pagination" pages
+	TypeVersions  = "versions"  // A static {"versions": [...]} list
+	TypeOfficial  = "official"  // Official MCP Registry API: server.json documents and a "metadata" cursor
+	TypeDirectory = "directory" // A local directory of server.json manifests
+)
+
+// Types lists the registry types NewSource accepts.
+var Types = []string{TypeOfficial, TypeGlama, TypeSmithery, TypeVersions, TypeDirectory}
+
+// Page is one page of a registry listing.
+type Page struct {
+	Servers []ServerData
+	// NextCursor fetches the following page; "" on the last page
+	NextCursor string
+	// Removed lists the IDs of servers the registry deleted, in incremental listings
+	Removed []string
+	// Date is the time on the registry's clock when it answered, from its Date header
+	// or else the newest update it listed; zero when it tells neither
+	Date time.Time
+}
+
+// RegistrySource reads MCP servers from one type of registry.
+type RegistrySource interface {
+	// ListPage fetches the page at cursor. The first page has cursor "".
+	ListPage(cursor string) (Page, error)
+	// GetServer fetches the details of a server by the ID from its listing.
+	GetServer(id string) (*ServerData, error)
+}
+
+// UpdatedSource is implemented by registries that can list only the servers changed
+// since a point in time, such as the official registry's "updated_since". It lets
+// a local index sync incrementally.
+type UpdatedSource interface {
+	RegistrySource
+	// ListUpdatedPage fetches the page at cursor of the servers changed since a time.
+	// A registry that can't tell what changed since then, e.g. one serving a catalog
+	// only since later, answers 410 Gone (a StatusError) to ask for a full listing.
+	ListUpdatedPage(since time.Time, cursor string) (Page, error)
+}
+
+// NewSource returns the adapter for reg's type, detecting the type when it isn't set.
+func NewSource(reg config.Registry) (RegistrySource, error) {
+	registryURL := formatRegistryURL(reg.URL)
+
+	typ := reg.Type
+	if typ == "" {
+		var err error
+		if typ, err = DetectType(registryURL); err != nil {
+			return nil, err
+		}
+		log.Detail("  Detected registry type %s for %s", typ, registryURL)
+	}
+
+	// Payloads are checked against the registry's public keys, if it has any
+	t, err := newTrust(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case TypeGlama:
+		return &glamaSource{url: registryURL, trust: t}, nil
+	case TypeSmithery:
+		return &smitherySource{url: registryURL, trust: t}, nil
+	case TypeVersions:
+		return &versionsSource{url: registryURL, trust: t}, nil
+	case TypeOfficial:
+		return &officialSource{url: registryURL, trust: t}, nil
+	case TypeDirectory:
+		dir, ok := LocalPath(registryURL)
+		if !ok {
+			return nil, fmt.Errorf("registry %s is of type %s but isn't a local path", registryURL, TypeDirectory)
+		}
+		return &dirSource{dir: dir, trust: t}, nil
+	}
+	return nil, ValidateType(typ)
+}
+
+// ValidateType returns an error if typ isn't a registry type. Empty means autodetect.
+func ValidateType(typ string) error {
+	if typ == "" {
+		return nil
+	}
+	for _, t := range Types {
+		if t == typ {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown registry type '%s': expected %s", typ, strings.Join(Types, ", "))
+}
+
+var (
+	glamaURL    = regexp.MustCompile(`^https?://glama\.ai(/.*)?$`)
+	smitheryURL = regexp.MustCompile(`^https?://registry\.smithery\.ai(/.*)?$`)
+)
+
+// DetectType guesses a registry's type from its URL, or else from the fields of its first page.
+// A local directory is a directory of manifests; a local file is detected like a response.
+func DetectType(registryURL string) (string, error) {
+	switch {
+	case isLocalDir(registryURL):
+		return TypeDirectory, nil
+	case glamaURL.MatchString(registryURL):
+		return TypeGlama, nil
+	case smitheryURL.MatchString(registryURL):
+		return TypeSmithery, nil
+	case isOfficialURL(registryURL):
+		return TypeOfficial, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := getJSON(registryURL, &fields); err != nil {
+		return "", err
+	}
+	switch {
+	case fields["versions"] != nil:
+		return TypeVersions, nil
+	case fields["smitheryServers"] != nil, fields["pagination"] != nil:
+		return TypeSmithery, nil
+	case fields["servers"] != nil && fields["metadata"] != nil:
+		return TypeOfficial, nil
+	case fields["servers"] != nil:
+		return TypeGlama, nil
+	}
+	return "", fmt.Errorf("could not detect the type of registry %s; set its type in config.yaml", registryURL)
+}
+
+// ListAll fetches every page from src. If a later page fails, the servers fetched so far
+// are returned with a warning.
+func ListAll(src RegistrySource) ([]ServerData, error) {
+	var servers []ServerData
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		page, err := src.ListPage(cursor)
+		if err != nil {
+			if cursor == "" {
+				return nil, err
+			}
+			log.Warn("Failed to fetch next page: %v", err)
+			break
+		}
+		servers = append(servers, page.Servers...)
+
+		// Stop on the last page, or if the registry keeps returning the same cursor
+		if page.NextCursor == "" || seen[page.NextCursor] {
+			break
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+	return servers, nil
+}
+
+// findServer looks a server up in the full listing, for registries without a details endpoint.
+func findServer(src RegistrySource, id string) (*ServerData, error) {
+	servers, err := ListAll(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range servers {
+		if s.ID == id {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("server '%s' not found", id)
+}
+
+// StatusError is returned for a registry response that isn't 200 OK.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to fetch from %s: received status code %d", e.URL, e.StatusCode)
+}
+
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second, // Add a timeout to prevent hanging indefinitely
+}
+
+// getJSON fetches a registry URL and decodes its JSON body into v.
+func getJSON(registryURL string, v interface{}) error {
+	body, err := fetch(registryURL)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse JSON from %s: %w", registryURL, err)
+	}
+	return nil
+}
+
+// fetch returns the body of a successful GET request, or the content of a local
+// registry file (see LocalPath). A file is a single page, whatever the query asks for.
+func fetch(registryURL string) ([]byte, error) {
+	body, _, err := fetchSigned(registryURL)
+	return body, err
+}
+
+// fetchSigned is fetch, also returning the response's headers, like its SignatureHeader
+// and Date. A local file has none.
+func fetchSigned(registryURL string) ([]byte, http.Header, error) {
+	if path, ok := LocalPath(registryURL); ok {
+		body, err := readLocal(path)
+		return body, nil, err
+	}
+
+	req, err := http.NewRequest("GET", registryURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request for %s: %w", registryURL, err)
+	}
+	req.Header.Set("User-Agent", "mcpetes-cli/0.0.1")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch from %s: %w", registryURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, &StatusError{URL: registryURL, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body from %s: %w", registryURL, err)
+	}
+	return body, resp.Header, nil
+}
+
+// withQuery sets query parameters on a URL, keeping the ones it already has.
+func withQuery(registryURL string, params url.Values) string {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return registryURL
+	}
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// itemURL returns the URL of one item below a listing URL, e.g. ".../servers/<id>".
+// The path is used as given, so callers escape it as their API expects.
+func itemURL(registryURL, path string) string {
+	base, _, _ := strings.Cut(registryURL, "?")
+	return strings.TrimSuffix(base, "/") + "/" + path
+}
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// serveJSON starts a test server answering each path (with its query) from pages.
+func serveJSON(t *testing.T, pages map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func serverIDs(servers []ServerData) []string {
+	ids := make([]string, len(servers))
+	for i, s := range servers {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+// TestGlamaSource verifies cursor pagination and the details endpoint.
+func TestGlamaSource(t *testing.T) {
+	srv := serveJSON(t, map[string]interface{}{
+		"/servers": map[string]interface{}{
+			"pageInfo": map[string]interface{}{"endCursor": "c1", "hasNextPage": true},
+			"servers": []interface{}{map[string]interface{}{"id": "a1", "name": "alpha", "namespace": "acme", "slug": "alpha", "repository": map[string]string{"url": "https://github.com/acme/alpha"},
+				"attributes":  []string{"hosting:remote-capable"},
+				"spdxLicense": map[string]string{"name": "MIT License", "url": "https://spdx.org/licenses/MIT"},
+				"tools":       []interface{}{map[string]interface{}{"name": "query", "description": "Run a query", "inputSchema": map[string]string{"type": "object"}}}}},
+		},
+		"/servers?after=c1&first=100": map[string]interface{}{
+			"pageInfo": map[string]interface{}{"endCursor": "c2", "hasNextPage": false},
+			"servers":  []interface{}{map[string]interface{}{"id": "b2", "name": "beta"}},
+		},
+		"/servers/acme/alpha": map[string]interface{}{"id": "a1", "name": "alpha", "namespace": "acme", "slug": "alpha", "description": "Alpha server",
+			"environmentVariablesJsonSchema": map[string]interface{}{"type": "object", "properties": map[string]interface{}{"ALPHA_TOKEN": map[string]string{"type": "string"}}, "required": []string{"ALPHA_TOKEN"}}},
+	})
+
+	src, err := NewSource(config.Registry{URL: srv.URL + "/servers", Type: TypeGlama})
+	if err != nil {
+		t.Fatalf("NewSource failed: %v", err)
+	}
+	servers, err := ListAll(src)
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if got := serverIDs(servers); !reflect.DeepEqual(got, []string{"acme/alpha", "b2"}) {
+		t.Errorf("IDs = %v", got)
+	}
+	if servers[0].RepositoryURL != "https://github.com/acme/alpha" {
+		t.Errorf("RepositoryURL = %q", servers[0].RepositoryURL)
+	}
+	if s := servers[0]; s.License != "MIT" || !s.RemoteCapable() || !reflect.DeepEqual(s.Tools, []Tool{{Name: "query", Description: "Run a query"}}) {
+		t.Errorf("metadata = %+v", s)
+	}
+
+	details, err := src.GetServer("acme/alpha")
+	if err != nil || details.Description != "Alpha server" {
+		t.Errorf("GetServer = %+v, %v", details, err)
+	}
+	if vars := details.EnvSchema.Variables(); len(vars) != 1 || vars[0].Name != "ALPHA_TOKEN" || !vars[0].Required {
+		t.Errorf("EnvSchema variables = %+v", vars)
+	}
+}
+
+// TestSmitherySource verifies numbered pages and the legacy single-page index.
+func TestSmitherySource(t *testing.T) {
+	srv := serveJSON(t, map[string]interface{}{
+		"/servers": map[string]interface{}{
+			"servers":    []interface{}{map[string]string{"qualifiedName": "@acme/one", "displayName": "One", "description": "First"}},
+			"pagination": map[string]int{"currentPage": 1, "totalPages": 2},
+		},
+		"/servers?page=2&pageSize=100": map[string]interface{}{
+			"servers":    []interface{}{map[string]string{"qualifiedName": "two"}},
+			"pagination": map[string]int{"currentPage": 2, "totalPages": 2},
+		},
+		"/legacy": map[string]interface{}{
+			"smitheryServers": []interface{}{map[string]string{"qualifiedName": "old", "version": "1.0.0"}},
+		},
+	})
+
+	src, _ := NewSource(config.Registry{URL: srv.URL + "/servers", Type: TypeSmithery})
+	servers, err := ListAll(src)
+	if err != nil {
+		t.Fatalf("ListAll failed: %v", err)
+	}
+	if got := serverIDs(servers); !reflect.DeepEqual(got, []string{"@acme/one", "two"}) {
+		t.Errorf("IDs = %v", got)
+	}
+	if servers[0].Name != "One" || servers[1].Name != "two" {
+		t.Errorf("Names = %q, %q", servers[0].Name, servers[1].Name)
+	}
+
+	legacy, _ := NewSource(config.Registry{URL: srv.URL + "/legacy", Type: TypeSmithery})
+	servers, err = ListAll(legacy)
+	if err != nil || len(servers) != 1 || servers[0].Description != "1.0.0" {
+		t.Errorf("Legacy index = %+v, %v", servers, err)
+	}
+}
+
+// TestDetectType verifies that the type is detected from the response when unset.
+func TestDetectType(t *testing.T) {
+	srv := serveJSON(t, map[string]interface{}{
+		"/versions": map[string]interface{}{"versions": []string{"1.2.0", "1.1.0"}},
+		"/glama":    map[string]interface{}{"servers": []interface{}{}, "pageInfo": map[string]interface{}{}},
+		"/smithery": map[string]interface{}{"servers": []interface{}{}, "pagination": map[string]int{}},
+		"/unknown":  map[string]interface{}{"items": []interface{}{}},
+	})
+
+	for path, want := range map[string]string{"/versions": TypeVersions, "/glama": TypeGlama, "/smithery": TypeSmithery} {
+		if got, err := DetectType(srv.URL + path); err != nil || got != want {
+			t.Errorf("DetectType(%s) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+	if _, err := DetectType(srv.URL + "/unknown"); err == nil {
+		t.Errorf("Expected an unknown shape to fail detection")
+	}
+	if got, _ := DetectType("https://glama.ai/mcp/servers"); got != TypeGlama {
+		t.Errorf("Expected glama.ai URLs to be detected without fetching, got %q", got)
+	}
+
+	src, err := NewSource(config.Registry{URL: srv.URL + "/versions"})
+	if err != nil {
+		t.Fatalf("NewSource failed: %v", err)
+	}
+	details, err := src.GetServer("1.1.0")
+	if err != nil || details.Name != "1.1.0" {
+		t.Errorf("GetServer = %+v, %v", details, err)
+	}
+
+	if _, err := NewSource(config.Registry{URL: srv.URL, Type: "npm"}); err == nil {
+		t.Errorf("Expected an unknown type to be rejected")
+	}
+}
@@ -0,0 +1,27 @@
+package registry
+
+// versionsSource reads a static {"versions": [...]} index. It has a single page and no
+// details beyond each entry's name.
+type versionsSource struct {
+	url   string
+	trust *trust
+}
+
+func (s *versionsSource) ListPage(cursor string) (Page, error) {
+	var resp struct {
+		Versions []string `json:"versions"`
+	}
+	if err := s.trust.getJSON(s.url, &resp); err != nil {
+		return Page{}, err
+	}
+
+	var page Page
+	for _, version := range resp.Versions {
+		page.Servers = append(page.Servers, ServerData{ID: version, Name: version})
+	}
+	return page, nil
+}
+
+func (s *versionsSource) GetServer(id string) (*ServerData, error) {
+	return findServer(s, id)
+}
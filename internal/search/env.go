@@ -0,0 +1,59 @@
+package search
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+	"github.com/tuannvm/mcpenetes/internal/secrets"
+)
+
+// applyEnv adds the values entered for a server's environment variables to its env,
+// after checking them against the registry's schema (nil when there is none). Secret
+// values are saved to the secret store and the env references them instead; the keys
+// saved are returned with the store's name.
+func applyEnv(serverID string, server *config.MCPServer, schema *registry.EnvSchema, values map[string]string) (map[string]string, error) {
+	merged := make(map[string]string, len(server.Env)+len(values))
+	for key, value := range server.Env {
+		merged[key] = value
+	}
+	for key, value := range values {
+		if value != "" {
+			merged[key] = value
+		}
+	}
+	if err := schema.Validate(merged); err != nil {
+		return nil, fmt.Errorf("invalid environment variables for %s:\n%w", serverID, err)
+	}
+
+	saved := make(map[string]string)
+	for key, value := range values {
+		if value == "" {
+			continue
+		}
+		if server.Env == nil {
+			server.Env = make(map[string]string)
+		}
+
+		secret := secrets.LooksSecret(key)
+		if v, ok := schema.Variable(key); ok {
+			secret = v.Secret
+		}
+		if !secret {
+			server.Env[key] = value
+			continue
+		}
+
+		ref, store, err := secrets.Save(serverID, key, value)
+		if err != nil {
+			return nil, err
+		}
+		server.Env[key] = ref
+		if !slices.Contains(server.SecretEnv, key) {
+			server.SecretEnv = append(server.SecretEnv, key)
+		}
+		saved[key] = store
+	}
+	return saved, nil
+}
@@ -0,0 +1,314 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/log"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+)
+
+// IndexFileName is the local catalog index, kept next to config.yaml.
+const IndexFileName = "index.json"
+
+// indexVersion changes when the file's layout does; an index of another version is rebuilt.
+const indexVersion = 1
+
+// Index is a local copy of the registries' catalogs with an inverted index over it, so
+// searches don't touch the network. 'mcpenetes registry sync' fills it (see Sync).
+type Index struct {
+	Version int `json:"version"`
+	// Registries holds each synced registry's servers, by registry name
+	Registries map[string]*RegistryState `json:"registries"`
+	// Terms maps each token of the servers' names, IDs, descriptions and tool names to
+	// the documents containing it. Documents are numbered through the registries in
+	// name order, each in listing order.
+	Terms map[string][]int `json:"terms"`
+
+	path string
+	docs []docRef
+}
+
+// RegistryState is what the index holds for one registry.
+type RegistryState struct {
+	URL string `json:"url"`
+	// Trust identifies the registry's signature settings the servers were checked under
+	// (see registry.TrustKey)
+	Trust   string                `json:"trust,omitempty"`
+	Servers []registry.ServerData `json:"servers"`
+	// SyncedAt is when a sync last completed; zero until a full listing has
+	SyncedAt time.Time `json:"syncedAt,omitempty"`
+	// UpdatedSince is when the last completed sync started by the registry's clock,
+	// less a margin: the next incremental sync asks for the servers changed since then
+	UpdatedSince time.Time `json:"updatedSince,omitempty"`
+	// Started is when the sync in progress started, by the registry's clock
+	Started time.Time `json:"started,omitempty"`
+	// ListCursor and Pending resume an interrupted full listing: the next page and
+	// the servers fetched so far
+	ListCursor string                `json:"listCursor,omitempty"`
+	Pending    []registry.ServerData `json:"pending,omitempty"`
+	// UpdatesCursor resumes an interrupted incremental sync
+	UpdatesCursor string `json:"updatesCursor,omitempty"`
+}
+
+type docRef struct {
+	registry string
+	server   int
+}
+
+// DefaultIndexPath returns where the index is kept, ~/.config/mcpetes/index.json.
+func DefaultIndexPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "mcpetes", IndexFileName), nil
+}
+
+// LoadIndex opens the index at the default path.
+func LoadIndex() (*Index, error) {
+	path, err := DefaultIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	return OpenIndex(path)
+}
+
+// OpenIndex reads the index at path. A missing file, or one written by another version,
+// gives an empty index that Save writes there.
+func OpenIndex(path string) (*Index, error) {
+	idx := &Index{Version: indexVersion, Registries: make(map[string]*RegistryState), path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, fmt.Errorf("failed to read index '%s': %w", path, err)
+	}
+
+	var stored Index
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse index '%s': %w", path, err)
+	}
+	if stored.Version != indexVersion {
+		log.Warn("Ignoring the index at %s, written by another version; run 'mcpenetes registry sync'", path)
+		return idx, nil
+	}
+	if stored.Registries != nil {
+		idx.Registries = stored.Registries
+	}
+	idx.Terms = stored.Terms
+	idx.numberDocs()
+	for _, postings := range idx.Terms {
+		if len(postings) > 0 && postings[len(postings)-1] >= len(idx.docs) {
+			idx.rebuild()
+			break
+		}
+	}
+	return idx, nil
+}
+
+// Save rebuilds the inverted index and writes the index atomically.
+func (idx *Index) Save() error {
+	idx.rebuild()
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for index: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), IndexFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to write index '%s': %w", idx.path, err)
+	}
+	return nil
+}
+
+// Path returns the index file's path.
+func (idx *Index) Path() string {
+	return idx.path
+}
+
+// Synced returns the state of a registry whose catalog the index holds, or nil if it
+// was never fully synced or its URL or trust settings changed since.
+func (idx *Index) Synced(reg config.Registry) *RegistryState {
+	state := idx.Registries[reg.Name]
+	if state == nil || state.SyncedAt.IsZero() || state.URL != reg.URL || state.Trust != registry.TrustKey(reg) {
+		return nil
+	}
+	return state
+}
+
+// Prune drops the registries that are no longer configured.
+func (idx *Index) Prune(registries []config.Registry) {
+	configured := make(map[string]bool, len(registries))
+	for _, reg := range registries {
+		configured[reg.Name] = true
+	}
+	for name := range idx.Registries {
+		if !configured[name] {
+			delete(idx.Registries, name)
+		}
+	}
+}
+
+// Find searches the registries: the index for the synced ones, and the registries
+// themselves (through their cache) for the rest, or for all of them when refresh is
+// set. The servers passing filter are ranked like Rank. Registries that couldn't be
+// read are reported in errs; the results hold the others'.
+func (idx *Index) Find(registries []config.Registry, query string, filter registry.Filter, limit int, refresh bool) (results []Result, errs []error) {
+	var matches map[string][]registry.ServerData
+	var servers []registry.ServerData
+	for _, reg := range registries {
+		if !refresh && idx.Synced(reg) != nil {
+			if matches == nil {
+				matches = idx.search(query)
+			}
+			servers = append(servers, filter.Apply(matches[reg.Name])...)
+			continue
+		}
+		fetched, err := registry.FetchServers(reg, refresh)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("registry %s: %w", reg.Name, err))
+			continue
+		}
+		servers = append(servers, filter.Apply(fetched)...)
+	}
+	return Rank(servers, query, limit), errs
+}
+
+// Lookup finds a server by ID or name like registry.Lookup, reading synced registries
+// from the index. It returns nil if no registry lists it.
+func (idx *Index) Lookup(registries []config.Registry, serverID string) *registry.ServerData {
+	for _, reg := range registries {
+		var servers []registry.ServerData
+		if state := idx.Synced(reg); state != nil {
+			servers = state.Servers
+		} else {
+			fetched, err := registry.FetchServers(reg, false)
+			if err != nil {
+				continue
+			}
+			servers = fetched
+		}
+		for i := range servers {
+			if servers[i].ID == serverID || servers[i].Name == serverID {
+				server := servers[i]
+				return &server
+			}
+		}
+	}
+	return nil
+}
+
+// ToolCounts returns how many tools each of the named servers exposes, for those a
+// registry lists with tools, reading the index and the registries' cache. Nothing is
+// fetched, so servers not found there are left out.
+func (idx *Index) ToolCounts(registries []config.Registry, names []string) map[string]int {
+	counts := make(map[string]int)
+	for _, reg := range registries {
+		var servers []registry.ServerData
+		if state := idx.Synced(reg); state != nil {
+			servers = state.Servers
+		} else if cached, ok := registry.CachedServers(reg); ok {
+			servers = cached
+		}
+		for _, s := range servers {
+			if len(s.Tools) == 0 {
+				continue
+			}
+			for _, name := range names {
+				if _, found := counts[name]; !found && (s.ID == name || s.Name == name) {
+					counts[name] = len(s.Tools)
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// search returns, by registry, the indexed servers in which every query term matches a
+// token the way Rank matches one: exactly, as a prefix or substring, or within a typo
+// or two. An empty query matches every server.
+func (idx *Index) search(query string) map[string][]registry.ServerData {
+	var matched []bool
+	for _, term := range tokenize(query) {
+		docs := make([]bool, len(idx.docs))
+		for token, postings := range idx.Terms {
+			if !strings.Contains(token, term) && editDistance(term, token, maxTypos(term)) == 0 {
+				continue
+			}
+			for _, doc := range postings {
+				docs[doc] = true
+			}
+		}
+		if matched == nil {
+			matched = docs
+			continue
+		}
+		for doc := range matched {
+			matched[doc] = matched[doc] && docs[doc]
+		}
+	}
+
+	found := make(map[string][]registry.ServerData)
+	for doc, ref := range idx.docs {
+		if matched == nil || matched[doc] {
+			found[ref.registry] = append(found[ref.registry], idx.Registries[ref.registry].Servers[ref.server])
+		}
+	}
+	return found
+}
+
+// numberDocs numbers the servers through the registries in name order.
+func (idx *Index) numberDocs() {
+	names := make([]string, 0, len(idx.Registries))
+	for name := range idx.Registries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	idx.docs = idx.docs[:0]
+	for _, name := range names {
+		for i := range idx.Registries[name].Servers {
+			idx.docs = append(idx.docs, docRef{registry: name, server: i})
+		}
+	}
+}
+
+// rebuild renumbers the documents and rebuilds the inverted index.
+func (idx *Index) rebuild() {
+	idx.numberDocs()
+	idx.Terms = make(map[string][]int)
+	for doc, ref := range idx.docs {
+		s := idx.Registries[ref.registry].Servers[ref.server]
+		text := []string{s.Name, s.ID, s.Description}
+		for _, tool := range s.Tools {
+			text = append(text, tool.Name)
+		}
+		for _, token := range tokenize(strings.Join(text, " ")) {
+			// Documents are visited in order, so a repeat is always the last posting
+			if postings := idx.Terms[token]; len(postings) == 0 || postings[len(postings)-1] != doc {
+				idx.Terms[token] = append(postings, doc)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package search
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+)
+
+// TestIndexSearch verifies that the inverted index finds what Rank matches and
+// survives a save and reopen.
+func TestIndexSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	idx, err := OpenIndex(path)
+	if err != nil {
+		t.Fatalf("OpenIndex failed: %v", err)
+	}
+	byRegistry := make(map[string][]registry.ServerData)
+	for _, s := range rankServers {
+		byRegistry[s.Registry] = append(byRegistry[s.Registry], s)
+	}
+	var regs []config.Registry
+	for name, servers := range byRegistry {
+		url := "https://" + name + ".example.com"
+		idx.Registries[name] = &RegistryState{URL: url, Servers: servers, SyncedAt: time.Now()}
+		regs = append(regs, config.Registry{Name: name, URL: url})
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i].Name < regs[j].Name })
+	if err := idx.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if idx, err = OpenIndex(path); err != nil {
+		t.Fatalf("OpenIndex failed: %v", err)
+	}
+
+	for _, query := range []string{"postgres", "postgers", "wether", "forecast", "query", "postgres tables", "postgres nothing", "gti", ""} {
+		results, errs := idx.Find(regs, query, registry.Filter{}, 0, false)
+		if len(errs) > 0 {
+			t.Fatalf("Find(%q) errors: %v", query, errs)
+		}
+		got, want := rankedIDs(results), rankedIDs(Rank(rankServers, query, 0))
+		if len(got) != 0 || len(want) != 0 {
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Find(%q) = %v, want %v", query, got, want)
+			}
+		}
+	}
+
+	if s := idx.Lookup(regs, "weather"); s == nil || s.Registry != "glama" || len(s.Tools) != 1 {
+		t.Errorf("Lookup(weather) = %+v", s)
+	}
+	// A registry whose URL changed isn't read from the index
+	if idx.Synced(config.Registry{Name: "glama", URL: "https://other.example.com"}) != nil {
+		t.Error("Synced() accepted a registry with another URL")
+	}
+	// Nor one that was given keys: its servers weren't checked against them
+	if idx.Synced(config.Registry{Name: "glama", URL: "https://glama.example.com", PublicKeys: []string{"key"}}) != nil {
+		t.Error("Synced() accepted a registry whose public keys changed")
+	}
+}
+
+// officialEntry is a server.json listing entry for the official registry.
+func officialEntry(name, description, status string) string {
+	return fmt.Sprintf(`{"server": {"name": %q, "description": %q}, "_meta": {"io.modelcontextprotocol.registry/official": {"isLatest": true, "status": %q}}}`,
+		name, description, status)
+}
+
+// TestIndexSync verifies a full sync, an interrupted and resumed one, and incremental
+// syncs with updated_since that add, update and remove servers.
+func TestIndexSync(t *testing.T) {
+	var (
+		failPage2 bool
+		gone      bool
+		updates   string
+		requests  []string
+	)
+	// The registry's clock runs well behind the client's
+	registryNow := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		requests = append(requests, r.URL.RequestURI())
+		w.Header().Set("Date", registryNow.Format(http.TimeFormat))
+		switch {
+		case q.Get("updated_since") != "" && gone:
+			http.Error(w, "list every server", http.StatusGone)
+		case q.Get("updated_since") != "":
+			_, _ = w.Write([]byte(`{"servers": [` + updates + `], "metadata": {}}`))
+		case q.Get("cursor") == "":
+			_, _ = w.Write([]byte(`{"servers": [` + officialEntry("acme/files", "Files", "active") + `], "metadata": {"nextCursor": "p2"}}`))
+		case q.Get("cursor") == "p2" && failPage2:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case q.Get("cursor") == "p2":
+			_, _ = w.Write([]byte(`{"servers": [` + officialEntry("acme/weather", "Weather", "active") + `], "metadata": {}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	reg := config.Registry{Name: "mcp", URL: srv.URL + "/v0/servers", Type: registry.TypeOfficial}
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	sync := func(full bool) SyncStats {
+		t.Helper()
+		idx, err := OpenIndex(path)
+		if err != nil {
+			t.Fatalf("OpenIndex failed: %v", err)
+		}
+		stats, syncErr := idx.Sync(reg, full)
+		if err := idx.Save(); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		if syncErr != nil && !failPage2 {
+			t.Fatalf("Sync failed: %v", syncErr)
+		}
+		return stats
+	}
+	indexed := func() []string {
+		t.Helper()
+		idx, err := OpenIndex(path)
+		if err != nil {
+			t.Fatalf("OpenIndex failed: %v", err)
+		}
+		results, _ := idx.Find([]config.Registry{reg}, "", registry.Filter{}, 0, false)
+		return rankedIDs(results)
+	}
+
+	// Interrupted on page 2: nothing is searchable yet, and the next sync resumes there
+	failPage2 = true
+	sync(false)
+	if idx, _ := OpenIndex(path); idx.Synced(reg) != nil {
+		t.Fatal("registry counted as synced after an interrupted sync")
+	}
+	failPage2 = false
+	requests = nil
+	if stats := sync(false); !stats.Resumed || stats.Pages != 1 || stats.Added != 2 {
+		t.Errorf("resumed sync = %+v (requests %v)", stats, requests)
+	}
+	if got := indexed(); !reflect.DeepEqual(got, []string{"acme/files", "acme/weather"}) {
+		t.Fatalf("indexed = %v", got)
+	}
+
+	// Incremental: one changed, one new, one deleted
+	updates = strings.Join([]string{
+		officialEntry("acme/files", "Files and folders", "active"),
+		officialEntry("acme/git", "Git", "active"),
+		officialEntry("acme/weather", "Weather", "deleted"),
+	}, ",")
+	requests = nil
+	stats := sync(false)
+	if !stats.Incremental || stats.Added != 1 || stats.Updated != 1 || stats.Removed != 1 {
+		t.Errorf("incremental sync = %+v", stats)
+	}
+	since := url.QueryEscape(registryNow.Add(-updatedSinceMargin).Format(time.RFC3339))
+	if len(requests) != 1 || !strings.Contains(requests[0], "updated_since="+since) {
+		t.Errorf("incremental requests = %v, want updated_since from the registry's clock", requests)
+	}
+	if got := indexed(); !reflect.DeepEqual(got, []string{"acme/files", "acme/git"}) {
+		t.Errorf("indexed = %v", got)
+	}
+
+	// 410 Gone: the registry can't tell what changed, so everything is listed
+	gone = true
+	if stats := sync(false); stats.Incremental || stats.Pages != 2 || stats.Added != 1 || stats.Removed != 1 {
+		t.Errorf("sync after 410 = %+v", stats)
+	}
+	gone = false
+	sync(false)
+
+	// A full sync replaces the catalog with the listing
+	if stats := sync(true); stats.Incremental || stats.Added != 1 || stats.Updated != 1 || stats.Removed != 1 {
+		t.Errorf("full sync = %+v", stats)
+	}
+	if got := indexed(); !reflect.DeepEqual(got, []string{"acme/files", "acme/weather"}) {
+		t.Errorf("indexed = %v", got)
+	}
+}
@@ -7,13 +7,22 @@ import (
 	"github.com/tuannvm/mcpenetes/internal/registry"
 )
 
+// InstallResult explains what AddServerToMCPConfig wrote.
+type InstallResult struct {
+	// Resolution is how the command was chosen; nil when a config was given
+	Resolution *registry.Resolution
+	// Secrets maps the env keys whose values went to the secret store to the store's name
+	Secrets map[string]string
+}
+
 // AddServerToMCPConfig adds a server to the mcp.json configuration.
-// It attempts to construct a basic configuration.
-// If configOverride is provided, it uses that instead of the default.
-func AddServerToMCPConfig(serverID string, serverData *registry.ServerData, configOverride *config.MCPServer) error {
+// The command is chosen by a registry.Resolver, unless configOverride is provided.
+// env holds the values entered for the server's environment variables; they are checked
+// against the registry's schema, and secret-looking ones are kept out of mcp.json.
+func AddServerToMCPConfig(serverID string, serverData *registry.ServerData, configOverride *config.MCPServer, env map[string]string) (*InstallResult, error) {
 	mcpCfg, err := config.LoadMCPConfig()
 	if err != nil {
-		return fmt.Errorf("failed to load mcp config: %w", err)
+		return nil, fmt.Errorf("failed to load mcp config: %w", err)
 	}
 
 	// Check if already exists?
@@ -26,22 +35,32 @@ func AddServerToMCPConfig(serverID string, serverData *registry.ServerData, conf
 
 	if configOverride == nil {
 		if _, exists := mcpCfg.MCPServers[serverID]; exists {
-			return fmt.Errorf("server '%s' already exists in mcp.json", serverID)
+			return nil, fmt.Errorf("server '%s' already exists in mcp.json", serverID)
 		}
 	}
 
 	var newServer config.MCPServer
+	result := &InstallResult{}
 
 	if configOverride != nil {
 		newServer = *configOverride
 	} else {
-		// Construct default server config
-		newServer = config.MCPServer{
-			Command: "npx",
-			Args:    []string{"-y", serverID}, // Optimistic guess
-			Env:     make(map[string]string),
-			Disabled: false,
+		// Chosen from the registry's packages and remotes, or the server's repository
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
 		}
+		res := registry.ResolverFor(cfg.Registries, serverData).Resolve(serverID, serverData)
+		result.Resolution = &res
+		newServer = res.Server
+	}
+
+	var schema *registry.EnvSchema
+	if serverData != nil {
+		schema = serverData.EnvSchema
+	}
+	if result.Secrets, err = applyEnv(serverID, &newServer, schema, env); err != nil {
+		return nil, err
 	}
 
 	// Add to config
@@ -49,8 +68,8 @@ func AddServerToMCPConfig(serverID string, serverData *registry.ServerData, conf
 
 	// Save
 	if err := config.SaveMCPConfig(mcpCfg); err != nil {
-		return fmt.Errorf("failed to save mcp config: %w", err)
+		return nil, fmt.Errorf("failed to save mcp config: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
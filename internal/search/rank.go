@@ -0,0 +1,236 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tuannvm/mcpenetes/internal/registry"
+)
+
+// Field weights: a query term found in the name counts most, then in a tool name,
+// then in the description.
+const (
+	nameWeight        = 3.0
+	toolWeight        = 2.0
+	descriptionWeight = 1.0
+)
+
+// Result is a ranked search hit. Servers listed by several registries are one Result.
+type Result struct {
+	Server registry.ServerData
+	Score  float64
+	// Registries names every registry listing the server, Server's first
+	Registries []string
+	// Duplicates are the same server as listed by the other registries
+	Duplicates []registry.ServerData
+}
+
+// Rank scores servers against a query and returns the matches, best first, at most
+// limit of them (0 means all). Each query term has to match the name, a tool name or
+// the description, exactly, as a prefix or substring, or within a typo or two. Servers
+// from different registries that share a repository are grouped into one Result.
+// An empty query matches every server, in registry order.
+func Rank(servers []registry.ServerData, query string, limit int) []Result {
+	terms := tokenize(query)
+
+	var results []Result
+	for _, group := range groupDuplicates(servers) {
+		best, bestScore := -1, 0.0
+		for i, s := range group {
+			score, ok := scoreServer(s, query, terms)
+			if ok && (best < 0 || score > bestScore) {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		result := Result{Server: group[best], Score: bestScore, Registries: []string{group[best].Registry}}
+		for i, s := range group {
+			if i != best {
+				result.Duplicates = append(result.Duplicates, s)
+				result.Registries = append(result.Registries, s.Registry)
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// groupDuplicates groups servers by repository, keeping the order they were listed in.
+// A registry can list several servers from one repository (a monorepo), so a group
+// takes at most one server per registry.
+func groupDuplicates(servers []registry.ServerData) [][]registry.ServerData {
+	var groups [][]registry.ServerData
+	byRepo := make(map[string][]int)
+
+	for _, s := range servers {
+		repo := normalizeRepository(s.RepositoryURL)
+		placed := false
+		if repo != "" {
+			for _, gi := range byRepo[repo] {
+				if !listedBy(groups[gi], s.Registry) {
+					groups[gi] = append(groups[gi], s)
+					placed = true
+					break
+				}
+			}
+		}
+		if !placed {
+			groups = append(groups, []registry.ServerData{s})
+			if repo != "" {
+				byRepo[repo] = append(byRepo[repo], len(groups)-1)
+			}
+		}
+	}
+	return groups
+}
+
+func listedBy(group []registry.ServerData, registryName string) bool {
+	for _, s := range group {
+		if s.Registry == registryName {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRepository reduces a repository URL to host and path, so the http(s), www.,
+// .git and trailing slash variants of one repository compare equal.
+func normalizeRepository(repositoryURL string) string {
+	repo := strings.ToLower(strings.TrimSpace(repositoryURL))
+	repo = strings.TrimPrefix(strings.TrimPrefix(repo, "https://"), "http://")
+	repo = strings.TrimPrefix(repo, "www.")
+	repo = strings.TrimSuffix(strings.TrimSuffix(repo, "/"), ".git")
+	return repo
+}
+
+// scoreServer returns how well a server matches, and false if some term matches nothing.
+func scoreServer(s registry.ServerData, query string, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, true
+	}
+
+	nameTokens := tokenize(s.Name + " " + s.ID)
+	descTokens := tokenize(s.Description)
+	var toolTokens []string
+	for _, tool := range s.Tools {
+		toolTokens = append(toolTokens, tokenize(tool.Name)...)
+	}
+
+	total := 0.0
+	for _, term := range terms {
+		score := max(
+			nameWeight*matchTokens(term, nameTokens),
+			toolWeight*matchTokens(term, toolTokens),
+			descriptionWeight*matchTokens(term, descTokens),
+		)
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+
+	// Whole-name matches beat names that merely contain every term
+	name := strings.ToLower(s.Name)
+	q := strings.ToLower(strings.TrimSpace(query))
+	switch {
+	case name == q || strings.ToLower(s.ID) == q:
+		total += 2 * nameWeight
+	case strings.HasPrefix(name, q):
+		total += nameWeight
+	}
+	return total, true
+}
+
+// matchTokens scores the best match of a term among tokens: 1 for an exact token,
+// 0.8 for a prefix, 0.6 for a substring and less for typos, down to 0 for no match.
+func matchTokens(term string, tokens []string) float64 {
+	best := 0.0
+	for _, token := range tokens {
+		var score float64
+		switch {
+		case token == term:
+			return 1
+		case strings.HasPrefix(token, term):
+			score = 0.8
+		case strings.Contains(token, term):
+			score = 0.6
+		default:
+			if d := editDistance(term, token, maxTypos(term)); d > 0 {
+				score = 0.5 - 0.15*float64(d-1)
+			}
+		}
+		best = max(best, score)
+	}
+	return best
+}
+
+// maxTypos is how many edits a term tolerates: none for short terms, where a typo
+// would match too much, and up to two for long ones.
+func maxTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the optimal string alignment distance between a and b (edits,
+// with swapped neighbours counting once), or 0 if it is more than limit or a == b.
+func editDistance(a, b string, limit int) int {
+	if limit == 0 {
+		return 0
+	}
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return 0
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return 0
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if d := prev[len(rb)]; d <= limit {
+		return d
+	}
+	return 0
+}
+
+// tokenize lowercases text and splits it into words and numbers.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
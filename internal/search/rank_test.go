@@ -0,0 +1,87 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tuannvm/mcpenetes/internal/registry"
+)
+
+var rankServers = []registry.ServerData{
+	{ID: "acme/postgres", Name: "postgres", Description: "Query PostgreSQL databases", RepositoryURL: "https://github.com/acme/postgres", Registry: "glama",
+		Tools: []registry.Tool{{Name: "query"}, {Name: "list_tables"}}},
+	{ID: "io.github.acme/postgres", Name: "io.github.acme/postgres", Description: "PostgreSQL", RepositoryURL: "http://www.github.com/Acme/postgres.git/", Registry: "mcp"},
+	{ID: "sqlite", Name: "sqlite", Description: "SQLite databases, works like postgres", Registry: "glama", Tools: []registry.Tool{{Name: "read_query"}}},
+	{ID: "fetch", Name: "fetch", Description: "Fetch web pages", RepositoryURL: "https://github.com/acme/servers", Registry: "glama"},
+	{ID: "git", Name: "git", Description: "Git repositories", RepositoryURL: "https://github.com/acme/servers", Registry: "glama"},
+	{ID: "weather", Name: "weather", Description: "Forecasts", Registry: "glama", Tools: []registry.Tool{{Name: "get_forecast"}}},
+}
+
+func rankedIDs(results []Result) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Server.ID
+	}
+	return ids
+}
+
+// TestRank verifies weighting, typo tolerance, limits and grouping of duplicates.
+func TestRank(t *testing.T) {
+	tests := []struct {
+		query string
+		limit int
+		want  []string
+	}{
+		// Name beats description
+		{query: "postgres", want: []string{"acme/postgres", "sqlite"}},
+		// Typos: swapped letters, a missing letter
+		{query: "postgers", want: []string{"acme/postgres", "sqlite"}},
+		{query: "wether", want: []string{"weather"}},
+		// Tool names count, above descriptions
+		{query: "forecast", want: []string{"weather"}},
+		{query: "query", want: []string{"acme/postgres", "sqlite"}},
+		// Every term has to match something
+		{query: "postgres tables", want: []string{"acme/postgres"}},
+		{query: "postgres nothing", want: nil},
+		// Short terms aren't typo tolerant
+		{query: "gti", want: nil},
+		{query: "", limit: 3, want: []string{"acme/postgres", "sqlite", "fetch"}},
+	}
+	for _, tt := range tests {
+		got := rankedIDs(Rank(rankServers, tt.query, tt.limit))
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Rank(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	// The mcp registry lists the same repository; one registry's monorepo servers stay apart
+	results := Rank(rankServers, "", 0)
+	if got := rankedIDs(results); !reflect.DeepEqual(got, []string{"acme/postgres", "sqlite", "fetch", "git", "weather"}) {
+		t.Fatalf("Rank() = %v", got)
+	}
+	if !reflect.DeepEqual(results[0].Registries, []string{"glama", "mcp"}) || len(results[0].Duplicates) != 1 {
+		t.Errorf("postgres result = %+v", results[0])
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"postgres", "postgers", 2, 1}, // swapped neighbours
+		{"weather", "wether", 1, 1},
+		{"github", "gitlab", 2, 2},
+		{"github", "gitlab", 1, 0},
+		{"same", "same", 1, 0},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
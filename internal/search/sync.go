@@ -0,0 +1,210 @@
+package search
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/registry"
+)
+
+// SyncStats counts what a sync changed in the index.
+type SyncStats struct {
+	Added, Updated, Removed int
+	// Pages is the number of listing pages fetched
+	Pages int
+	// Incremental is set when only the servers changed since the last sync were listed
+	Incremental bool
+	// Resumed is set when the sync picked up where an interrupted one stopped
+	Resumed bool
+}
+
+// updatedSinceMargin is taken off the time the next incremental sync asks for changes
+// since, so updates the registry records a little late, or stamps by a clock behind the
+// one it answered with, aren't missed. Listing a few servers twice is harmless.
+const updatedSinceMargin = 5 * time.Minute
+
+// Sync brings a registry's catalog in the index up to date. Registries that can list
+// the servers changed since a time (registry.UpdatedSource) are synced incrementally
+// once fully listed; the others, or all with full set, are listed in full. A failed
+// sync keeps its progress in the index, so saving it and syncing again resumes where
+// it stopped. The index isn't saved.
+func (idx *Index) Sync(reg config.Registry, full bool) (SyncStats, error) {
+	src, err := registry.NewSource(reg)
+	if err != nil {
+		return SyncStats{}, err
+	}
+
+	state := idx.Registries[reg.Name]
+	// Servers listed under another URL or other keys are listed again
+	if trust := registry.TrustKey(reg); state == nil || state.URL != reg.URL || state.Trust != trust {
+		state = &RegistryState{URL: reg.URL, Trust: trust}
+		idx.Registries[reg.Name] = state
+	}
+	if full {
+		state.UpdatesCursor = ""
+	}
+
+	updated, ok := src.(registry.UpdatedSource)
+	if ok && !full && state.ListCursor == "" && !state.SyncedAt.IsZero() {
+		return idx.syncUpdates(reg, updated, state)
+	}
+	return idx.syncAll(reg, src, state)
+}
+
+// syncAll lists every server, resuming an interrupted listing, and replaces the
+// registry's servers with them.
+func (idx *Index) syncAll(reg config.Registry, src registry.RegistrySource, state *RegistryState) (SyncStats, error) {
+	stats := SyncStats{Resumed: state.ListCursor != ""}
+	if !stats.Resumed {
+		state.Started = time.Time{}
+		state.Pending = nil
+	}
+
+	seen := make(map[string]bool)
+	cursor := state.ListCursor
+	for {
+		page, err := src.ListPage(cursor)
+		if err != nil {
+			state.ListCursor = cursor
+			if cursor == "" {
+				return stats, err
+			}
+			return stats, fmt.Errorf("listing stopped after %d servers, sync again to resume: %w", len(state.Pending), err)
+		}
+		stats.Pages++
+		startedAt(state, page)
+		state.Pending = append(state.Pending, withRegistry(page.Servers, reg.Name)...)
+
+		if page.NextCursor == "" || seen[page.NextCursor] {
+			break
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+		// Keep the progress in case a later page fails
+		state.ListCursor = cursor
+	}
+	if len(state.Pending) == 0 {
+		state.ListCursor = ""
+		return stats, fmt.Errorf("no servers found in response from %s", reg.URL)
+	}
+
+	old := make(map[string]registry.ServerData, len(state.Servers))
+	for _, s := range state.Servers {
+		old[s.ID] = s
+	}
+	for _, s := range state.Pending {
+		prev, ok := old[s.ID]
+		switch {
+		case !ok:
+			stats.Added++
+		case !sameServer(prev, s):
+			stats.Updated++
+		}
+		delete(old, s.ID)
+	}
+	stats.Removed = len(old)
+
+	state.Servers, state.Pending, state.ListCursor = state.Pending, nil, ""
+	state.UpdatedSince, state.SyncedAt = state.Started.Add(-updatedSinceMargin), time.Now()
+	state.Started = time.Time{}
+	return stats, nil
+}
+
+// syncUpdates lists the servers changed since the last sync and merges them in. If the
+// registry answers 410 Gone, it can't tell, and everything is listed instead.
+func (idx *Index) syncUpdates(reg config.Registry, src registry.UpdatedSource, state *RegistryState) (SyncStats, error) {
+	stats := SyncStats{Incremental: true, Resumed: state.UpdatesCursor != ""}
+	if !stats.Resumed {
+		state.Started = time.Time{}
+	}
+
+	seen := make(map[string]bool)
+	cursor := state.UpdatesCursor
+	for {
+		page, err := src.ListUpdatedPage(state.UpdatedSince, cursor)
+		var status *registry.StatusError
+		if err != nil && cursor == "" && errors.As(err, &status) && status.StatusCode == http.StatusGone {
+			return idx.syncAll(reg, src, state)
+		}
+		if err != nil {
+			state.UpdatesCursor = cursor
+			return stats, err
+		}
+		stats.Pages++
+		startedAt(state, page)
+		for _, s := range withRegistry(page.Servers, reg.Name) {
+			switch i := indexOf(state.Servers, s.ID); {
+			case i < 0:
+				state.Servers = append(state.Servers, s)
+				stats.Added++
+			case !sameServer(state.Servers[i], s):
+				state.Servers[i] = s
+				stats.Updated++
+			}
+		}
+		for _, id := range page.Removed {
+			if i := indexOf(state.Servers, id); i >= 0 {
+				state.Servers = append(state.Servers[:i], state.Servers[i+1:]...)
+				stats.Removed++
+			}
+		}
+
+		if page.NextCursor == "" || seen[page.NextCursor] {
+			break
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+		state.UpdatesCursor = cursor
+	}
+
+	state.UpdatesCursor = ""
+	// A registry that gave no time and listed no change leaves the window where it was
+	if since := state.Started.Add(-updatedSinceMargin); since.After(state.UpdatedSince) {
+		state.UpdatedSince = since
+	}
+	state.SyncedAt, state.Started = time.Now(), time.Time{}
+	return stats, nil
+}
+
+// startedAt records when a sync started from its first page, by the registry's clock:
+// the next incremental sync asks the registry for changes since then, and a client
+// clock running ahead of the registry's would skip the changes made in between. A
+// registry that tells no time is assumed to run on the client's clock.
+func startedAt(state *RegistryState, page registry.Page) {
+	if !state.Started.IsZero() {
+		return
+	}
+	state.Started = page.Date
+	if state.Started.IsZero() {
+		state.Started = time.Now()
+	}
+}
+
+func withRegistry(servers []registry.ServerData, name string) []registry.ServerData {
+	for i := range servers {
+		servers[i].Registry = name
+	}
+	return servers
+}
+
+func indexOf(servers []registry.ServerData, id string) int {
+	for i := range servers {
+		if servers[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameServer compares servers as the index stores them, so a server read back from the
+// file equals the same server fetched again.
+func sameServer(a, b registry.ServerData) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
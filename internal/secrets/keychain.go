@@ -0,0 +1,124 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keychain returns the OS keychain if its command-line tool can be used, or nil.
+// macOS has "security"; Linux desktops have "secret-tool" for the Secret Service
+// (GNOME Keyring, KWallet).
+func keychain() Store {
+	switch runtime.GOOS {
+	case "darwin":
+		if path, err := exec.LookPath("security"); err == nil {
+			return &macKeychain{tool: path}
+		}
+	case "linux":
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return nil
+		}
+		if path, err := exec.LookPath("secret-tool"); err == nil {
+			return &secretService{tool: path}
+		}
+	}
+	return nil
+}
+
+// macKeychain stores generic passwords in the login keychain.
+type macKeychain struct {
+	tool string
+}
+
+func (k *macKeychain) Name() string {
+	return "macOS keychain"
+}
+
+func (k *macKeychain) Get(name string) (string, error) {
+	out, err := exec.Command(k.tool, "find-generic-password", "-s", Service, "-a", name, "-w").Output()
+	if err != nil {
+		return "", keychainError(err, 44)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// maxKeychainCommand is the longest command line "security -i" reads.
+const maxKeychainCommand = 4096
+
+func (k *macKeychain) Set(name, value string) error {
+	// The command is read from stdin by "security -i", so the value never shows up
+	// in the process list; -X takes it hex encoded, which needs no quoting
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", quoteArg(Service), quoteArg(name), hex.EncodeToString([]byte(value)))
+	if len(command) > maxKeychainCommand {
+		// Too long for the keychain's command line: Resolve reads the file store too
+		return userFileStore().Set(name, value)
+	}
+	cmd := exec.Command(k.tool, "-i")
+	cmd.Stdin = strings.NewReader(command)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	// "security -i" exits 0 even when the command fails, so read the value back
+	if stored, err := k.Get(name); err != nil || stored != value {
+		return fmt.Errorf("the keychain didn't store the value: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (k *macKeychain) Delete(name string) error {
+	if err := exec.Command(k.tool, "delete-generic-password", "-s", Service, "-a", name).Run(); err != nil {
+		return keychainError(err, 44)
+	}
+	return nil
+}
+
+// secretService stores secrets through the freedesktop Secret Service API.
+type secretService struct {
+	tool string
+}
+
+func (s *secretService) Name() string {
+	return "Secret Service keyring"
+}
+
+func (s *secretService) Get(name string) (string, error) {
+	out, err := exec.Command(s.tool, "lookup", "service", Service, "account", name).Output()
+	if err != nil {
+		return "", keychainError(err, 1)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (s *secretService) Set(name, value string) error {
+	cmd := exec.Command(s.tool, "store", "--label", fmt.Sprintf("%s: %s", Service, name), "service", Service, "account", name)
+	// The value is read from stdin so it never shows up in the process list
+	cmd.Stdin = strings.NewReader(value)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (s *secretService) Delete(name string) error {
+	return exec.Command(s.tool, "clear", "service", Service, "account", name).Run()
+}
+
+// quoteArg single-quotes an argument for the "security -i" command line.
+func quoteArg(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// keychainError maps the tool's "not found" exit code to ErrNotFound.
+func keychainError(err error, notFoundCode int) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == notFoundCode {
+		return ErrNotFound
+	}
+	return err
+}
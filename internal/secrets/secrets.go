@@ -0,0 +1,221 @@
+// Package secrets keeps secret environment values, such as API tokens, out of mcp.json.
+// A value is saved in the OS keychain when one can be used, otherwise in a file only the
+// user can read, and mcp.json holds a "${secret:<server>/<KEY>}" reference to it. The
+// translator reads references back when it writes a client config that needs the value.
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Service labels mcpenetes' entries in the OS keychain
+const Service = "mcpenetes"
+
+// FileName is the fallback store in the config directory
+const FileName = "secrets.json"
+
+// ErrNotFound is returned when a store has no secret under a name
+var ErrNotFound = errors.New("secret not found")
+
+// Store is somewhere secrets can be kept.
+type Store interface {
+	// Name describes the store to users, e.g. "macOS keychain"
+	Name() string
+	Get(name string) (string, error)
+	Set(name, value string) error
+	Delete(name string) error
+}
+
+// Default returns the store new secrets are saved to: the OS keychain when one is
+// available, otherwise the file store. Tests replace it.
+var Default = func() Store {
+	if store := keychain(); store != nil {
+		return store
+	}
+	return userFileStore()
+}
+
+var refPattern = regexp.MustCompile(`^\$\{secret:([^}]+)\}$`)
+
+// Name returns the name an env value of a server is stored under.
+func Name(serverID, envKey string) string {
+	return serverID + "/" + envKey
+}
+
+// Ref returns the mcp.json reference to a stored secret.
+func Ref(name string) string {
+	return "${secret:" + name + "}"
+}
+
+// ParseRef returns the name a reference points at, if value is one.
+func ParseRef(value string) (string, bool) {
+	m := refPattern.FindStringSubmatch(value)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Save stores an env value of a server and returns the reference to write in its place,
+// along with the name of the store used.
+func Save(serverID, envKey, value string) (ref, storeName string, err error) {
+	store := Default()
+	name := Name(serverID, envKey)
+	if err := store.Set(name, value); err != nil {
+		return "", "", fmt.Errorf("failed to save %s to the %s: %w", envKey, store.Name(), err)
+	}
+	return Ref(name), store.Name(), nil
+}
+
+// Resolve returns the secret a reference points at. Other values are returned unchanged.
+// Secrets saved to the file store while no keychain was available are found as well.
+func Resolve(value string) (string, error) {
+	name, ok := ParseRef(value)
+	if !ok {
+		return value, nil
+	}
+	stores := []Store{Default()}
+	if _, isFile := stores[0].(*FileStore); !isFile {
+		stores = append(stores, userFileStore())
+	}
+	for _, store := range stores {
+		secret, err := store.Get(name)
+		if err == nil {
+			return secret, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return "", fmt.Errorf("failed to read %s from the %s: %w", name, store.Name(), err)
+		}
+	}
+	return "", fmt.Errorf("secret %s was not found; reinstall the server to enter it again", name)
+}
+
+// Forget deletes the secrets referenced by a server's env values. Missing ones are ignored.
+func Forget(env map[string]string) error {
+	var errs []error
+	for _, value := range env {
+		name, ok := ParseRef(value)
+		if !ok {
+			continue
+		}
+		for _, store := range []Store{Default(), userFileStore()} {
+			if err := store.Delete(name); err != nil && !errors.Is(err, ErrNotFound) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var secretWords = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "APIKEY", "API_KEY", "ACCESS_KEY", "PRIVATE_KEY", "CREDENTIAL", "AUTH"}
+
+// LooksSecret reports whether an env variable name suggests a secret value,
+// e.g. GITHUB_TOKEN, OPENAI_API_KEY or DB_PASSWORD.
+func LooksSecret(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, word := range secretWords {
+		if strings.Contains(upper, word) {
+			return true
+		}
+	}
+	return strings.HasSuffix(upper, "_KEY") || strings.HasSuffix(upper, "_PAT")
+}
+
+// FileStore keeps secrets in a JSON file only the user can read. It is used when no
+// OS keychain is available.
+type FileStore struct {
+	Path string
+}
+
+func userFileStore() *FileStore {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return &FileStore{Path: filepath.Join(homeDir, ".config", "mcpetes", FileName)}
+}
+
+func (f *FileStore) Name() string {
+	return "secrets file " + f.Path
+}
+
+func (f *FileStore) Get(name string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *FileStore) Set(name, value string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return f.save(secrets)
+}
+
+func (f *FileStore) Delete(name string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, name)
+	return f.save(secrets)
+}
+
+func (f *FileStore) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", f.Path, err)
+	}
+	return secrets, nil
+}
+
+// save writes the file atomically; CreateTemp makes it readable by the user only.
+func (f *FileStore) save(secrets map[string]string) error {
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(f.Path), FileName+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, f.Path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileStore verifies saving, resolving and forgetting secrets in the fallback file.
+func TestFileStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("USERPROFILE", tmpDir)
+	defaultStore := Default
+	Default = func() Store { return userFileStore() }
+	t.Cleanup(func() { Default = defaultStore })
+
+	ref, storeName, err := Save("github", "GITHUB_TOKEN", "ghp_secret")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if ref != "${secret:github/GITHUB_TOKEN}" {
+		t.Errorf("ref = %q", ref)
+	}
+	path := filepath.Join(tmpDir, ".config", "mcpetes", FileName)
+	if storeName != "secrets file "+path {
+		t.Errorf("store = %q", storeName)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected %s to be readable by the user only: %v, %v", path, info, err)
+	}
+
+	if value, err := Resolve(ref); err != nil || value != "ghp_secret" {
+		t.Errorf("Resolve = %q, %v", value, err)
+	}
+	if value, err := Resolve("plain"); err != nil || value != "plain" {
+		t.Errorf("Resolve(plain) = %q, %v", value, err)
+	}
+
+	if err := Forget(map[string]string{"GITHUB_TOKEN": ref, "LOG_LEVEL": "info"}); err != nil {
+		t.Fatalf("Forget failed: %v", err)
+	}
+	if _, err := Resolve(ref); err == nil {
+		t.Error("Expected a forgotten secret to be missing")
+	}
+}
+
+func TestLooksSecret(t *testing.T) {
+	for name, want := range map[string]bool{
+		"GITHUB_PERSONAL_ACCESS_TOKEN": true,
+		"OPENAI_API_KEY":               true,
+		"DB_PASSWORD":                  true,
+		"AZURE_CLIENT_SECRET":          true,
+		"GITLAB_PAT":                   true,
+		"LOG_LEVEL":                    false,
+		"GITHUB_HOST":                  false,
+	} {
+		if got := LooksSecret(name); got != want {
+			t.Errorf("LooksSecret(%s) = %v", name, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/util"
+)
+
+// BackupTimeFormat is the timestamp at the end of a backup's name (before its extension).
+const BackupTimeFormat = "20060102-150405"
+
+// backupName returns the name of a backup of a client taken at t, without extension:
+// <clientName>-<timestamp>, with the client ID escaped by escapeBackupID.
+func backupName(clientName string, t time.Time) string {
+	return escapeBackupID(clientName) + "-" + t.Format(BackupTimeFormat)
+}
+
+// escapeBackupID escapes what file names can't hold on every platform, like the ":" of
+// target IDs such as "vscode:work" or "jetbrains:IntelliJIdea2025.1", as %XX.
+func escapeBackupID(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if r == '%' || r < 0x20 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			fmt.Fprintf(&b, "%%%02X", r)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// BackupClientID returns the client ID a backup name's client part was escaped from.
+func BackupClientID(escaped string) string {
+	id, err := url.PathUnescape(escaped)
+	if err != nil {
+		return escaped
+	}
+	return id
+}
+
+// BackupTargetExt is appended to a backup's name for the file that records its BackupTarget.
+const BackupTargetExt = ".target.json"
+
+// BackupTarget records what a backup was taken of, so that it is restored to the same
+// file whatever the client's scope or project is by then.
+type BackupTarget struct {
+	Client string `json:"client"`
+	// Path is the file or directory backed up, with the client's scope resolved
+	Path string `json:"path"`
+	// Type is the config format of Path
+	Type    string `json:"type"`
+	Scope   string `json:"scope,omitempty"`
+	Project string `json:"project,omitempty"`
+}
+
+// writeBackupTarget records target next to the backup at backupPath.
+func writeBackupTarget(backupPath string, target BackupTarget) error {
+	data, err := json.MarshalIndent(target, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(backupPath+BackupTargetExt, data, 0600)
+}
+
+// ReadBackupTarget returns what the backup at backupPath is a backup of. Backups taken
+// before targets were recorded are assumed to be of clientConf's current target.
+func ReadBackupTarget(backupPath, clientName string, clientConf config.Client) (BackupTarget, error) {
+	data, err := os.ReadFile(backupPath + BackupTargetExt)
+	if err == nil {
+		var target BackupTarget
+		if err := json.Unmarshal(data, &target); err != nil {
+			return BackupTarget{}, fmt.Errorf("failed to read what '%s' is a backup of: %w", backupPath, err)
+		}
+		if target.Client != clientName {
+			return BackupTarget{}, fmt.Errorf("'%s' is a backup of %s, not %s", backupPath, target.Client, clientName)
+		}
+		return target, nil
+	}
+	if !os.IsNotExist(err) {
+		return BackupTarget{}, err
+	}
+
+	resolved, err := resolveScope(clientConf)
+	if err != nil {
+		return BackupTarget{}, fmt.Errorf("invalid scope for %s: %w", clientName, err)
+	}
+	path, err := util.ExpandPath(resolved.ConfigPath)
+	if err != nil {
+		return BackupTarget{}, fmt.Errorf("error expanding client config path: %w", err)
+	}
+	return BackupTarget{Client: clientName, Path: path, Type: resolved.Type, Scope: clientConf.Scope, Project: resolved.Project}, nil
+}
@@ -0,0 +1,144 @@
+package translator
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// Warning describes a server setting that a client could not represent as configured.
+type Warning struct {
+	Client  string `json:"client"`
+	Server  string `json:"server"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w Warning) String() string {
+	if w.Field != "" {
+		return fmt.Sprintf("%s: server '%s': %s: %s", w.Client, w.Server, w.Field, w.Message)
+	}
+	return fmt.Sprintf("%s: server '%s': %s", w.Client, w.Server, w.Message)
+}
+
+// warn records a warning and prints it alongside the rest of the apply output.
+func (t *Translator) warn(w Warning) {
+	t.warnings = append(t.warnings, w)
+	fmt.Printf("  Warning: %s\n", w)
+}
+
+// TakeWarnings returns the warnings collected since the last call and clears them.
+func (t *Translator) TakeWarnings() []Warning {
+	warnings := t.warnings
+	t.warnings = nil
+	return warnings
+}
+
+// adaptServer drops the settings a client doesn't support, recording a warning for each.
+// Denied and hidden tools are merged into Permissions.Deny, ready to be written as the
+// client's "disabledTools". It returns false if the server can't be written to the client at all.
+func (t *Translator) adaptServer(clientName, serverID string, serverConf config.MCPServer, caps client.Capabilities) (config.MCPServer, bool) {
+	if serverConf.URL != "" && !caps.URL {
+		t.warn(Warning{Client: clientName, Server: serverID, Field: "url", Message: "remote servers are not supported, skipped"})
+		return serverConf, false
+	}
+
+	if len(serverConf.Env) > 0 && !caps.Env {
+		t.warn(Warning{Client: clientName, Server: serverID, Field: "env", Message: "environment variables are not supported and were dropped"})
+		serverConf.Env = nil
+		serverConf.SecretEnv = nil
+	}
+	if serverConf.Disabled && !caps.Disabled {
+		t.warn(Warning{Client: clientName, Server: serverID, Field: "disabled", Message: "the client can't disable servers; it will be enabled"})
+		serverConf.Disabled = false
+	}
+	// Fold the permissions block into the flat lists clients understand
+	var blocked []string
+	if perms := serverConf.Permissions; perms != nil {
+		serverConf.AutoApprove = mergeToolLists(serverConf.AutoApprove, perms.AutoApprove)
+		if len(perms.Deny) > 0 && !caps.ToolFilter {
+			t.warn(Warning{Client: clientName, Server: serverID, Field: "permissions.deny", Message: "the client can't block tools; they remain callable"})
+		}
+		if len(perms.Hide) > 0 && !caps.ToolFilter {
+			t.warn(Warning{Client: clientName, Server: serverID, Field: "permissions.hide", Message: "the client can't hide tools; they remain visible"})
+		}
+		if caps.ToolFilter {
+			blocked = mergeToolLists(perms.Deny, perms.Hide)
+		}
+	}
+	serverConf.Permissions = nil
+	if len(blocked) > 0 {
+		serverConf.Permissions = &config.ToolPermissions{Deny: blocked}
+	}
+
+	if len(serverConf.AutoApprove) > 0 && !caps.AutoApprove {
+		t.warn(Warning{Client: clientName, Server: serverID, Field: "autoApprove", Message: "auto-approval is not supported; tools will ask for confirmation"})
+		serverConf.AutoApprove = nil
+	}
+
+	if caps.ServerNameChars != "" {
+		if invalid, err := regexp.Compile("[^" + caps.ServerNameChars + "]"); err == nil && invalid.MatchString(serverID) {
+			t.warn(Warning{Client: clientName, Server: serverID, Message: fmt.Sprintf("name contains characters outside [%s], which the client may reject", caps.ServerNameChars)})
+		}
+	}
+
+	return serverConf, true
+}
+
+// TargetCapabilities returns what a target can represent: its client's capabilities,
+// narrowed to what its config format can express. TranslateAndApply drops the rest.
+func TargetCapabilities(clientName string, clientConf config.Client) client.Capabilities {
+	return capabilitiesFor(clientName, client.ConfigFormatEnum(clientConf.Type))
+}
+
+// capabilitiesFor returns a client's capabilities, narrowed to what its config format can
+// express: formats with their own server schema have no place for tool permissions.
+func capabilitiesFor(clientName string, format client.ConfigFormatEnum) client.Capabilities {
+	caps := client.LookupCapabilities(clientName)
+	switch format {
+	case client.FormatContinue, client.FormatContinueBlocks, client.FormatYAML, client.FormatTOML, client.FormatJetBrainsXML:
+		caps.AutoApprove = false
+		caps.ToolFilter = false
+	}
+	return caps
+}
+
+// mergeToolLists returns the tools of all lists, without duplicates, in first-seen order.
+func mergeToolLists(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, tool := range list {
+			if tool != "" && !seen[tool] {
+				seen[tool] = true
+				merged = append(merged, tool)
+			}
+		}
+	}
+	return merged
+}
+
+// CheckClientLimits warns when the servers have more tools than a client exposes, since
+// tools beyond its limit are silently cut off. toolCounts holds the number of tools of
+// the servers whose count is known, by name; the others aren't counted.
+func (t *Translator) CheckClientLimits(clientName string, toolCounts map[string]int) {
+	caps := client.LookupCapabilities(clientName)
+	if caps.MaxTools == 0 {
+		return
+	}
+	total := 0
+	for name, server := range t.MCPConfig.MCPServers {
+		if !server.Disabled {
+			total += toolCounts[name]
+		}
+	}
+	if total > caps.MaxTools {
+		t.warn(Warning{
+			Client:  clientName,
+			Server:  "*",
+			Message: fmt.Sprintf("the servers have at least %d tools, but the client only exposes %d", total, caps.MaxTools),
+		})
+	}
+}
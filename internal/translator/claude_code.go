@@ -0,0 +1,215 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tailscale/hujson"
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// resolveScope maps a scoped client onto the file it actually manages.
+// For Claude Code, the "repo" scope is a plain {"mcpServers": {...}} file at <project>/.mcp.json;
+// the "user" and "project" scopes stay in ~/.claude.json. Other clients are returned unchanged.
+func resolveScope(clientConf config.Client) (config.Client, error) {
+	if client.ConfigFormatEnum(clientConf.Type) != client.FormatClaudeCode {
+		return clientConf, nil
+	}
+
+	switch client.ScopeEnum(clientConf.Scope) {
+	case "", client.ScopeUser:
+		return clientConf, nil
+	case client.ScopeProject, client.ScopeRepo:
+		if clientConf.Project == "" {
+			return clientConf, fmt.Errorf("scope '%s' requires a project directory", clientConf.Scope)
+		}
+		project, err := filepath.Abs(clientConf.Project)
+		if err != nil {
+			return clientConf, fmt.Errorf("failed to resolve project directory '%s': %w", clientConf.Project, err)
+		}
+		clientConf.Project = project
+
+		if client.ScopeEnum(clientConf.Scope) == client.ScopeRepo {
+			return config.Client{
+				ConfigPath: filepath.Join(project, ".mcp.json"),
+				Type:       string(client.FormatSimpleJSON),
+			}, nil
+		}
+		return clientConf, nil
+	default:
+		return clientConf, fmt.Errorf("unknown scope '%s' (expected user, project or repo)", clientConf.Scope)
+	}
+}
+
+// claudeCodeServersPointer returns the JSON pointer of the "mcpServers" object for the
+// client's scope, along with the pointers of its ancestors that may need creating.
+func claudeCodeServersPointer(clientConf config.Client) (ptr string, parents []string) {
+	if client.ScopeEnum(clientConf.Scope) == client.ScopeProject {
+		projectPtr := "/projects/" + escapeJSONPointer(clientConf.Project)
+		return projectPtr + "/mcpServers", []string{"/projects", projectPtr}
+	}
+	return "/mcpServers", nil
+}
+
+// escapeJSONPointer escapes a single reference token for use in a JSON pointer (RFC 6901).
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// readClaudeCodeConfig parses ~/.claude.json into a hujson AST, so that edits can be applied
+// as patches that leave the rest of the (large, frequently rewritten) file untouched.
+func readClaudeCodeConfig(path string) (hujson.Value, os.FileMode, error) {
+	perm := os.FileMode(0600)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return hujson.Value{}, perm, err
+	}
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		data = []byte("{}")
+	}
+
+	root, err := hujson.Parse(data)
+	if err != nil {
+		return hujson.Value{}, perm, fmt.Errorf("failed to parse existing config file (invalid JSON/JSONC): %w", err)
+	}
+	return root, perm, nil
+}
+
+// patchOp is a single JSON Patch (RFC 6902) operation.
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// applyClaudeCodeServer upserts a server into the scope's "mcpServers" object of ~/.claude.json.
+func (t *Translator) applyClaudeCodeServer(clientName, path string, clientConf config.Client, serverID string, serverConf config.MCPServer, caps client.Capabilities) error {
+	root, perm, err := readClaudeCodeConfig(path)
+	if err != nil {
+		return err
+	}
+
+	serversPtr, parents := claudeCodeServersPointer(clientConf)
+
+	var ops []patchOp
+	for _, p := range append(parents, serversPtr) {
+		if root.Find(p) == nil {
+			ops = append(ops, patchOp{Op: "add", Path: p, Value: map[string]interface{}{}})
+		}
+	}
+
+	serverEntry := t.createServerMap(serverConf, caps)
+	if serverConf.URL != "" {
+		serverEntry["type"] = "http"
+	} else {
+		serverEntry["type"] = "stdio"
+	}
+	ops = append(ops, patchOp{Op: "add", Path: serversPtr + "/" + escapeJSONPointer(serverID), Value: serverEntry})
+
+	if err := patchAndWrite(&root, ops, path, perm); err != nil {
+		return fmt.Errorf("failed to update Claude Code config for %s: %w", clientName, err)
+	}
+
+	fmt.Printf("  Successfully wrote config for %s to '%s' (%s)\n", clientName, path, serversPtr)
+	return nil
+}
+
+// removeClaudeCodeServers removes obsolete servers from the scope's "mcpServers" object only.
+func (t *Translator) removeClaudeCodeServers(path string, clientConf config.Client) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	root, perm, err := readClaudeCodeConfig(path)
+	if err != nil {
+		return err
+	}
+
+	serversPtr, _ := claudeCodeServersPointer(clientConf)
+	serversValue := root.Find(serversPtr)
+	if serversValue == nil {
+		return nil
+	}
+
+	standardized, err := hujson.Standardize(serversValue.Pack())
+	if err != nil {
+		return fmt.Errorf("failed to parse '%s' in '%s': %w", serversPtr, path, err)
+	}
+	var servers map[string]interface{}
+	if err := json.Unmarshal(standardized, &servers); err != nil {
+		return nil // Not an object, leave it alone
+	}
+
+	var ops []patchOp
+	for serverID := range servers {
+		if _, exists := t.MCPConfig.MCPServers[serverID]; !exists {
+			ops = append(ops, patchOp{Op: "remove", Path: serversPtr + "/" + escapeJSONPointer(serverID)})
+			fmt.Printf("  Removed obsolete server '%s' from client configuration\n", serverID)
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	return patchAndWrite(&root, ops, path, perm)
+}
+
+// RestoreClaudeCodeServers puts the scope's "mcpServers" object from a backup of
+// ~/.claude.json back into target's file. The rest of the file, which Claude Code keeps
+// rewriting and which holds every other project's state, is left as it is now.
+func RestoreClaudeCodeServers(backupPath string, target BackupTarget) error {
+	backup, _, err := readClaudeCodeConfig(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup '%s': %w", backupPath, err)
+	}
+	root, perm, err := readClaudeCodeConfig(target.Path)
+	if err != nil {
+		return err
+	}
+
+	serversPtr, parents := claudeCodeServersPointer(config.Client{Scope: target.Scope, Project: target.Project})
+	var ops []patchOp
+	switch saved := backup.Find(serversPtr); {
+	case saved != nil:
+		standardized, err := hujson.Standardize(saved.Pack())
+		if err != nil {
+			return fmt.Errorf("failed to parse '%s' in '%s': %w", serversPtr, backupPath, err)
+		}
+		for _, p := range parents {
+			if root.Find(p) == nil {
+				ops = append(ops, patchOp{Op: "add", Path: p, Value: map[string]interface{}{}})
+			}
+		}
+		ops = append(ops, patchOp{Op: "add", Path: serversPtr, Value: json.RawMessage(standardized)})
+	case root.Find(serversPtr) != nil:
+		// The scope had no servers when the backup was taken
+		ops = append(ops, patchOp{Op: "remove", Path: serversPtr})
+	default:
+		return nil
+	}
+	return patchAndWrite(&root, ops, target.Path, perm)
+}
+
+// patchAndWrite applies ops to root and atomically writes the result back to path.
+func patchAndWrite(root *hujson.Value, ops []patchOp, path string, perm os.FileMode) error {
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to build patch: %w", err)
+	}
+	if err := root.Patch(patch); err != nil {
+		return fmt.Errorf("failed to patch '%s': %w", path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", path, err)
+	}
+	return writeFileAtomic(path, root.Pack(), perm)
+}
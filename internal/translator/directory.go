@@ -0,0 +1,239 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tailscale/hujson"
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// continueBlock mirrors a Continue "mcpServers" block file (schema v1).
+type continueBlock struct {
+	Name       string                `yaml:"name"`
+	Version    string                `yaml:"version"`
+	Schema     string                `yaml:"schema"`
+	MCPServers []continueBlockServer `yaml:"mcpServers"`
+}
+
+type continueBlockServer struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type,omitempty"`
+	Command string            `yaml:"command,omitempty"`
+	Args    []string          `yaml:"args,omitempty"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	URL     string            `yaml:"url,omitempty"`
+}
+
+// directoryFileExt returns the file extension used for per-server files of a directory format.
+func directoryFileExt(format client.ConfigFormatEnum) string {
+	if format == client.FormatContinueBlocks {
+		return ".yaml"
+	}
+	return ".json"
+}
+
+// serverFileName turns a server ID into a safe file name for a directory-backed client.
+func serverFileName(serverID string, format client.ConfigFormatEnum) string {
+	safe := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '-'
+		}
+		return r
+	}, serverID)
+	return safe + directoryFileExt(format)
+}
+
+// serverFileConflict returns the first other server in mcp.json, by ID, whose file in a
+// directory-backed client is serverID's, or "" if there is none. Names are compared
+// ignoring case, as macOS and Windows do.
+func (t *Translator) serverFileConflict(serverID string, format client.ConfigFormatEnum) string {
+	name := serverFileName(serverID, format)
+	ids := make([]string, 0, len(t.MCPConfig.MCPServers))
+	for id := range t.MCPConfig.MCPServers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if id != serverID && strings.EqualFold(serverFileName(id, format), name) {
+			return id
+		}
+	}
+	return ""
+}
+
+// applyDirectoryServer writes a single server to its own file inside a directory-backed client.
+func (t *Translator) applyDirectoryServer(clientName, dirPath string, format client.ConfigFormatEnum, serverID string, serverConf config.MCPServer, caps client.Capabilities) error {
+	// IDs like "a/b" and "a-b" share a file name; writing both would keep only one
+	if other := t.serverFileConflict(serverID, format); other != "" {
+		return fmt.Errorf("servers '%s' and '%s' would both be written to '%s' for client %s; rename one of them in mcp.json", serverID, other, serverFileName(serverID, format), clientName)
+	}
+
+	var outputData []byte
+	var err error
+
+	switch format {
+	case client.FormatContinueBlocks:
+		server := continueBlockServer{Name: serverID}
+		if serverConf.URL != "" {
+			server.Type = "sse"
+			server.URL = serverConf.URL
+		} else {
+			server.Type = "stdio"
+			server.Command = serverConf.Command
+			server.Args = serverConf.Args
+			server.Env = serverConf.Env
+		}
+
+		block := continueBlock{
+			Name:       serverID,
+			Version:    "0.0.1",
+			Schema:     "v1",
+			MCPServers: []continueBlockServer{server},
+		}
+
+		outputData, err = yaml.Marshal(block)
+		if err != nil {
+			return fmt.Errorf("failed to marshal Continue block for %s: %w", serverID, err)
+		}
+
+	case client.FormatDirectoryJSON:
+		fileConfig := map[string]interface{}{
+			"mcpServers": map[string]interface{}{
+				serverID: t.createServerMap(serverConf, caps),
+			},
+		}
+
+		outputData, err = json.MarshalIndent(fileConfig, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal server file for %s: %w", serverID, err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported directory format '%s' for client %s", format, clientName)
+	}
+
+	if err := os.MkdirAll(dirPath, 0750); err != nil {
+		return fmt.Errorf("failed to create directory '%s' for client %s: %w", dirPath, clientName, err)
+	}
+
+	serverPath := filepath.Join(dirPath, serverFileName(serverID, format))
+	if err := writeFileAtomic(serverPath, outputData, 0644); err != nil {
+		return fmt.Errorf("failed to write server file '%s' for client %s: %w", serverPath, clientName, err)
+	}
+
+	fmt.Printf("  Successfully wrote config for %s to '%s'\n", clientName, serverPath)
+	return nil
+}
+
+// removeDirectoryServers deletes per-server files whose servers no longer exist in the MCPConfig.
+// Files that can't be parsed are left untouched.
+func (t *Translator) removeDirectoryServers(dirPath string, format client.ConfigFormatEnum) error {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // Directory doesn't exist, nothing to remove
+		}
+		return fmt.Errorf("failed to read client config directory '%s': %w", dirPath, err)
+	}
+
+	ext := directoryFileExt(format)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+			continue
+		}
+
+		filePath := filepath.Join(dirPath, entry.Name())
+		serverIDs, err := readDirectoryServerIDs(filePath, format)
+		if err != nil || len(serverIDs) == 0 {
+			continue
+		}
+
+		obsolete := true
+		for _, id := range serverIDs {
+			if _, exists := t.MCPConfig.MCPServers[id]; exists {
+				obsolete = false
+				break
+			}
+		}
+		if !obsolete {
+			continue
+		}
+
+		if err := os.Remove(filePath); err != nil {
+			return fmt.Errorf("failed to remove obsolete server file '%s': %w", filePath, err)
+		}
+		fmt.Printf("  Removed obsolete server '%s' from client configuration\n", strings.Join(serverIDs, ", "))
+	}
+
+	return nil
+}
+
+// readDirectoryServerIDs returns the server IDs declared in a per-server file.
+func readDirectoryServerIDs(path string, format client.ConfigFormatEnum) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	switch format {
+	case client.FormatContinueBlocks:
+		var block continueBlock
+		if err := yaml.Unmarshal(data, &block); err != nil {
+			return nil, err
+		}
+		for _, s := range block.MCPServers {
+			ids = append(ids, s.Name)
+		}
+	default:
+		standardized, err := hujson.Standardize(data)
+		if err != nil {
+			return nil, err
+		}
+		var fileConfig struct {
+			MCPServers map[string]interface{} `json:"mcpServers"`
+		}
+		if err := json.Unmarshal(standardized, &fileConfig); err != nil {
+			return nil, err
+		}
+		for id := range fileConfig.MCPServers {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it into place,
+// so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,247 @@
+package translator
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// jetbrainsMCPComponent is the component JetBrains AI Assistant stores MCP servers in,
+// inside the IDE's "options/llm.mcpServers.xml".
+const jetbrainsMCPComponent = "McpApplicationServerCommands"
+
+// jetbrainsApplication mirrors an IDE options file. Components other than the MCP one are
+// kept verbatim.
+type jetbrainsApplication struct {
+	XMLName    xml.Name             `xml:"application"`
+	Components []jetbrainsComponent `xml:"component"`
+}
+
+type jetbrainsComponent struct {
+	Name  string `xml:"name,attr"`
+	Inner string `xml:",innerxml"`
+}
+
+// jetbrainsMCPCommands is the body of the McpApplicationServerCommands component.
+// Unknown children are preserved through Other.
+type jetbrainsMCPCommands struct {
+	Commands []jetbrainsMCPCommand `xml:"commands>McpServerCommand"`
+	Other    []jetbrainsRawElement `xml:",any"`
+}
+
+type jetbrainsMCPCommand struct {
+	Options []jetbrainsOption `xml:"option"`
+}
+
+type jetbrainsOption struct {
+	Name  string        `xml:"name,attr"`
+	Value string        `xml:"value,attr,omitempty"`
+	Map   *jetbrainsMap `xml:"map,omitempty"`
+}
+
+type jetbrainsMap struct {
+	Entries []jetbrainsMapEntry `xml:"entry"`
+}
+
+type jetbrainsMapEntry struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type jetbrainsRawElement struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// name returns the server name of a command, or "" if it has none.
+func (c jetbrainsMCPCommand) name() string {
+	for _, opt := range c.Options {
+		if opt.Name == "name" {
+			return opt.Value
+		}
+	}
+	return ""
+}
+
+// newJetBrainsMCPCommand builds the command entry for a stdio server. Options of an existing
+// entry (e.g. "enabled") that mcpenetes doesn't manage are carried over.
+func newJetBrainsMCPCommand(serverID string, serverConf config.MCPServer, existing *jetbrainsMCPCommand) jetbrainsMCPCommand {
+	managed := map[string]bool{"name": true, "programPath": true, "arguments": true, "envs": true}
+
+	cmd := jetbrainsMCPCommand{}
+	hasEnabled := false
+	if existing != nil {
+		for _, opt := range existing.Options {
+			if !managed[opt.Name] {
+				cmd.Options = append(cmd.Options, opt)
+				hasEnabled = hasEnabled || opt.Name == "enabled"
+			}
+		}
+	}
+	if !hasEnabled {
+		cmd.Options = append(cmd.Options, jetbrainsOption{Name: "enabled", Value: "true"})
+	}
+
+	cmd.Options = append(cmd.Options,
+		jetbrainsOption{Name: "name", Value: serverID},
+		jetbrainsOption{Name: "programPath", Value: serverConf.Command},
+	)
+	if len(serverConf.Args) > 0 {
+		cmd.Options = append(cmd.Options, jetbrainsOption{Name: "arguments", Value: joinJetBrainsArgs(serverConf.Args)})
+	}
+	if len(serverConf.Env) > 0 {
+		keys := make([]string, 0, len(serverConf.Env))
+		for k := range serverConf.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		envMap := &jetbrainsMap{}
+		for _, k := range keys {
+			envMap.Entries = append(envMap.Entries, jetbrainsMapEntry{Key: k, Value: serverConf.Env[k]})
+		}
+		cmd.Options = append(cmd.Options, jetbrainsOption{Name: "envs", Map: envMap})
+	}
+
+	return cmd
+}
+
+// joinJetBrainsArgs joins arguments into the single command-line string the IDE expects,
+// quoting arguments that contain whitespace or quotes.
+func joinJetBrainsArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// readJetBrainsOptions parses an IDE options file and its MCP component. A missing or empty
+// file yields an empty application.
+func readJetBrainsOptions(path string) (*jetbrainsApplication, *jetbrainsMCPCommands, error) {
+	app := &jetbrainsApplication{}
+	commands := &jetbrainsMCPCommands{}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return app, commands, nil
+	}
+
+	if err := xml.Unmarshal(data, app); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse existing config file (invalid XML): %w", err)
+	}
+	for _, c := range app.Components {
+		if c.Name == jetbrainsMCPComponent {
+			if err := xml.Unmarshal([]byte("<component>"+c.Inner+"</component>"), commands); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse %s component: %w", jetbrainsMCPComponent, err)
+			}
+			break
+		}
+	}
+
+	return app, commands, nil
+}
+
+// writeJetBrainsOptions stores commands back into the MCP component and writes the file.
+func writeJetBrainsOptions(path string, app *jetbrainsApplication, commands *jetbrainsMCPCommands) error {
+	inner, err := xml.MarshalIndent(commands, "    ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s component: %w", jetbrainsMCPComponent, err)
+	}
+	// Strip the wrapper element added by MarshalIndent, keeping only its children
+	body := string(inner)
+	body = body[strings.Index(body, ">")+1 : strings.LastIndex(body, "<")]
+
+	replaced := false
+	for i := range app.Components {
+		if app.Components[i].Name == jetbrainsMCPComponent {
+			app.Components[i].Inner = body + "\n  "
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		app.Components = append(app.Components, jetbrainsComponent{Name: jetbrainsMCPComponent, Inner: body + "\n  "})
+	}
+
+	out, err := xml.MarshalIndent(app, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal XML config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for '%s': %w", path, err)
+	}
+	return writeFileAtomic(path, append(out, '\n'), 0644)
+}
+
+// applyJetBrainsServer upserts a server into JetBrains AI Assistant's MCP options file.
+func (t *Translator) applyJetBrainsServer(clientName, path, serverID string, serverConf config.MCPServer) error {
+	if serverConf.Command == "" {
+		fmt.Printf("  Skipping server '%s' for %s: JetBrains AI Assistant only runs stdio servers\n", serverID, clientName)
+		return nil
+	}
+
+	app, commands, err := readJetBrainsOptions(path)
+	if err != nil {
+		return err
+	}
+
+	updated := false
+	for i, c := range commands.Commands {
+		if c.name() == serverID {
+			commands.Commands[i] = newJetBrainsMCPCommand(serverID, serverConf, &c)
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		commands.Commands = append(commands.Commands, newJetBrainsMCPCommand(serverID, serverConf, nil))
+	}
+
+	if err := writeJetBrainsOptions(path, app, commands); err != nil {
+		return fmt.Errorf("failed to write config for %s: %w", clientName, err)
+	}
+
+	fmt.Printf("  Successfully wrote config for %s to '%s'\n", clientName, path)
+	return nil
+}
+
+// removeJetBrainsServers removes commands whose servers no longer exist in the MCPConfig.
+func (t *Translator) removeJetBrainsServers(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	app, commands, err := readJetBrainsOptions(path)
+	if err != nil {
+		return err
+	}
+
+	kept := commands.Commands[:0]
+	for _, c := range commands.Commands {
+		if _, exists := t.MCPConfig.MCPServers[c.name()]; exists || c.name() == "" {
+			kept = append(kept, c)
+			continue
+		}
+		fmt.Printf("  Removed obsolete server '%s' from client configuration\n", c.name())
+	}
+	if len(kept) == len(commands.Commands) {
+		return nil
+	}
+	commands.Commands = kept
+
+	return writeJetBrainsOptions(path, app, commands)
+}
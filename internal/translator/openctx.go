@@ -0,0 +1,139 @@
+package translator
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/tuannvm/mcpenetes/internal/config"
+)
+
+// openCtxMCPProviderURI is the OpenCtx provider that bridges MCP servers into Cody.
+const openCtxMCPProviderURI = "https://openctx.org/npm/@openctx/provider-modelcontextprotocol"
+
+// openCtxManagedKey marks provider settings written by mcpenetes, so that pruning never
+// touches providers the user configured by hand. OpenCtx ignores unknown settings.
+const openCtxManagedKey = "mcpenetes.server"
+
+// openCtxProviderKey returns the "openctx.providers" key for a server. OpenCtx allows one
+// entry per provider URI, so each server gets its own URI by way of a fragment, which is
+// dropped when the provider module is fetched.
+func openCtxProviderKey(serverID string) string {
+	return openCtxMCPProviderURI + "#" + url.PathEscape(serverID)
+}
+
+// openCtxScriptExts are the files OpenCtx's MCP provider can start: it runs the script
+// its file:// URI points at with node.
+var openCtxScriptExts = []string{".js", ".mjs", ".cjs"}
+
+// openCtxUnsupported returns why OpenCtx's MCP provider can't start a server, or "" if it
+// can. It runs node scripts only, so runners like npx, uvx or docker can't be given to it.
+func openCtxUnsupported(serverConf config.MCPServer) string {
+	if serverConf.URL != "" {
+		return ""
+	}
+	command := serverConf.Command
+	if isNodeCommand(command) {
+		if len(serverConf.Args) > 0 && isOpenCtxScript(serverConf.Args[0]) {
+			return ""
+		}
+		return "OpenCtx can only run node with a script file as its first argument"
+	}
+	if isOpenCtxScript(command) {
+		return ""
+	}
+	return fmt.Sprintf("OpenCtx can only start node scripts, not '%s'", filepath.Base(command))
+}
+
+func isNodeCommand(command string) bool {
+	return strings.TrimSuffix(filepath.Base(command), ".exe") == "node"
+}
+
+func isOpenCtxScript(path string) bool {
+	return slices.Contains(openCtxScriptExts, strings.ToLower(filepath.Ext(path)))
+}
+
+// openCtxProviderEntry builds the provider settings for an MCP server OpenCtx can start
+// (see openCtxUnsupported):
+//
+//	"https://openctx.org/npm/@openctx/provider-modelcontextprotocol#git": {
+//	  "mcp.provider.uri": "file:///home/me/mcp-git/index.js",
+//	  "nodeCommand": "node",
+//	  "mcp.provider.args": ["--repo", "."],
+//	  "mcp.provider.env": {"KEY": "value"},
+//	  "mcpenetes.server": "git"
+//	}
+//
+// Remote servers use their URL directly. For node servers the script is the provider URI and
+// node is passed as "nodeCommand".
+func openCtxProviderEntry(serverID string, serverConf config.MCPServer) map[string]interface{} {
+	entry := map[string]interface{}{
+		openCtxManagedKey: serverID,
+	}
+
+	if serverConf.URL != "" {
+		entry["mcp.provider.uri"] = serverConf.URL
+		return entry
+	}
+
+	script := serverConf.Command
+	args := serverConf.Args
+	if isNodeCommand(script) && len(args) > 0 {
+		entry["nodeCommand"] = script
+		script, args = args[0], args[1:]
+	}
+	// file:// URIs must be absolute
+	if abs, err := filepath.Abs(script); err == nil {
+		script = abs
+	}
+
+	entry["mcp.provider.uri"] = (&url.URL{Scheme: "file", Path: filepath.ToSlash(script)}).String()
+	if len(args) > 0 {
+		entry["mcp.provider.args"] = args
+	}
+	if len(serverConf.Env) > 0 {
+		entry["mcp.provider.env"] = serverConf.Env
+	}
+
+	return entry
+}
+
+// applyOpenCtxProvider upserts the provider entry for a server, dropping the raw server map
+// that older versions of mcpenetes wrote under the bare server ID.
+func applyOpenCtxProvider(providers map[string]interface{}, serverID string, serverConf config.MCPServer) {
+	if legacy, ok := providers[serverID].(map[string]interface{}); ok && isRawServerMap(legacy) {
+		delete(providers, serverID)
+	}
+	providers[openCtxProviderKey(serverID)] = openCtxProviderEntry(serverID, serverConf)
+}
+
+// isRawServerMap reports whether v looks like an MCP server map rather than provider settings.
+func isRawServerMap(v map[string]interface{}) bool {
+	_, hasCommand := v["command"]
+	_, hasURL := v["url"]
+	return hasCommand || hasURL
+}
+
+// removeObsoleteProviders removes providers created by mcpenetes whose servers no longer exist
+// in the MCPConfig. Returns true if any provider was removed.
+func (t *Translator) removeObsoleteProviders(providers map[string]interface{}) bool {
+	changed := false
+	for key, value := range providers {
+		settings, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		serverID, managed := settings[openCtxManagedKey].(string)
+		if !managed {
+			continue
+		}
+		if _, exists := t.MCPConfig.MCPServers[serverID]; !exists {
+			delete(providers, key)
+			fmt.Printf("  Removed obsolete server '%s' from client configuration\n", serverID)
+			changed = true
+		}
+	}
+	return changed
+}
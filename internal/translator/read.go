@@ -0,0 +1,281 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/tailscale/hujson"
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/util"
+	"gopkg.in/yaml.v3"
+)
+
+// guessFormat infers a client's config format from its name and file extension when the
+// client entry doesn't set one. It returns "" if the format can't be told.
+func guessFormat(clientName, path string) client.ConfigFormatEnum {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		// Try to guess from client name or default to simple json
+		if strings.Contains(clientName, "claude-desktop") {
+			return client.FormatClaudeDesktop
+		} else if strings.Contains(clientName, "vscode") && filepath.Base(path) == "mcp.json" {
+			return client.FormatVSCodeMCP
+		} else if strings.Contains(clientName, "vscode") {
+			return client.FormatVSCode
+		} else if strings.Contains(strings.ToLower(clientName), "continue") {
+			return client.FormatContinue
+		}
+		return client.FormatSimpleJSON
+	case ".yaml", ".yml":
+		return client.FormatYAML
+	case ".toml":
+		return client.FormatTOML
+	}
+	return ""
+}
+
+// ReadClientServers returns the servers currently in a client's config, keyed by name, in
+// the common mcp.json shape. A missing config file yields no servers.
+func ReadClientServers(clientName string, clientConf config.Client) (map[string]config.MCPServer, error) {
+	clientConf, err := resolveScope(clientConf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope for %s: %w", clientName, err)
+	}
+
+	path, err := util.ExpandPath(clientConf.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand client config path '%s' for %s: %w", clientConf.ConfigPath, clientName, err)
+	}
+
+	format := client.ConfigFormatEnum(clientConf.Type)
+	if format == "" {
+		format = guessFormat(clientName, path)
+	}
+
+	servers := make(map[string]config.MCPServer)
+
+	if client.IsDirectoryFormat(format) {
+		return servers, readDirectoryServers(path, format, servers)
+	}
+
+	if format == client.FormatJetBrainsXML {
+		_, commands, err := readJetBrainsOptions(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commands.Commands {
+			if c.name() != "" {
+				servers[c.name()] = jetbrainsServer(c)
+			}
+		}
+		return servers, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(strings.TrimSpace(string(data))) == 0) {
+		return servers, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client config file '%s': %w", path, err)
+	}
+
+	var root map[string]interface{}
+	switch format {
+	case client.FormatYAML:
+		err = yaml.Unmarshal(data, &root)
+	case client.FormatTOML:
+		err = toml.Unmarshal(data, &root)
+	case "":
+		return nil, fmt.Errorf("unknown config format for client %s", clientName)
+	default:
+		var standardized []byte
+		if standardized, err = hujson.Standardize(data); err == nil {
+			err = json.Unmarshal(standardized, &root)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse client config file '%s': %w", path, err)
+	}
+
+	switch format {
+	case client.FormatClaudeCode:
+		entries := root
+		if client.ScopeEnum(clientConf.Scope) == client.ScopeProject {
+			projects, _ := root["projects"].(map[string]interface{})
+			entries, _ = projects[clientConf.Project].(map[string]interface{})
+		}
+		addServerEntries(servers, entries["mcpServers"])
+
+	case client.FormatVSCode:
+		if clientConf.Key == "openctx.providers" {
+			providers, _ := root["openctx.providers"].(map[string]interface{})
+			for _, p := range providers {
+				if entry, ok := p.(map[string]interface{}); ok {
+					if id, ok := entry[openCtxManagedKey].(string); ok {
+						servers[id] = openCtxServer(entry)
+					}
+				}
+			}
+			break
+		}
+		nested, flat := readLegacyVSCodeServers(root)
+		addServerEntries(servers, flat)
+		addServerEntries(servers, nested)
+
+	case client.FormatVSCodeMCP:
+		addServerEntries(servers, root["servers"])
+
+	case client.FormatContinue:
+		experimental, _ := root["experimental"].(map[string]interface{})
+		list, _ := experimental["modelContextProtocolServers"].([]interface{})
+		for _, item := range list {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, ok := entry["name"].(string); ok {
+				transport, _ := entry["transport"].(map[string]interface{})
+				servers[name] = serverFromEntry(transport)
+			}
+		}
+
+	default:
+		addServerEntries(servers, root["mcpServers"])
+	}
+
+	return servers, nil
+}
+
+// readDirectoryServers adds the servers of every per-server file in a directory-backed client.
+func readDirectoryServers(dirPath string, format client.ConfigFormatEnum, servers map[string]config.MCPServer) error {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read client config directory '%s': %w", dirPath, err)
+	}
+
+	ext := directoryFileExt(format)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dirPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		if format == client.FormatContinueBlocks {
+			var block continueBlock
+			if yaml.Unmarshal(data, &block) != nil {
+				continue
+			}
+			for _, s := range block.MCPServers {
+				servers[s.Name] = config.MCPServer{Command: s.Command, Args: s.Args, Env: s.Env, URL: s.URL}
+			}
+			continue
+		}
+
+		standardized, err := hujson.Standardize(data)
+		if err != nil {
+			continue
+		}
+		var fileConfig map[string]interface{}
+		if json.Unmarshal(standardized, &fileConfig) == nil {
+			addServerEntries(servers, fileConfig["mcpServers"])
+		}
+	}
+	return nil
+}
+
+// addServerEntries adds the entries of a "mcpServers"-style object to servers.
+func addServerEntries(servers map[string]config.MCPServer, entries interface{}) {
+	m, _ := entries.(map[string]interface{})
+	for name, v := range m {
+		if entry, ok := v.(map[string]interface{}); ok {
+			servers[name] = serverFromEntry(entry)
+		}
+	}
+}
+
+// serverFromEntry reads the common server fields from a client's server entry, accepting
+// the client-specific names mcpenetes writes (e.g. Windsurf's "serverUrl").
+func serverFromEntry(entry map[string]interface{}) config.MCPServer {
+	var server config.MCPServer
+	server.Command, _ = entry["command"].(string)
+	server.Args = stringList(entry["args"])
+	if u, ok := entry["url"].(string); ok {
+		server.URL = u
+	} else if u, ok := entry["serverUrl"].(string); ok {
+		server.URL = u
+	}
+	if env, ok := entry["env"].(map[string]interface{}); ok {
+		server.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			server.Env[k] = fmt.Sprint(v)
+		}
+	}
+	server.Disabled, _ = entry["disabled"].(bool)
+	return server
+}
+
+// openCtxServer converts an OpenCtx provider entry written by mcpenetes back into a server.
+func openCtxServer(entry map[string]interface{}) config.MCPServer {
+	uri, _ := entry["mcp.provider.uri"].(string)
+	if !strings.HasPrefix(uri, "file://") {
+		return config.MCPServer{URL: uri}
+	}
+
+	server := config.MCPServer{Command: strings.TrimPrefix(uri, "file://"), Args: stringList(entry["mcp.provider.args"])}
+	if node, ok := entry["nodeCommand"].(string); ok {
+		server.Args = append([]string{server.Command}, server.Args...)
+		server.Command = node
+	}
+	if env, ok := entry["mcp.provider.env"].(map[string]interface{}); ok {
+		server.Env = make(map[string]string, len(env))
+		for k, v := range env {
+			server.Env[k] = fmt.Sprint(v)
+		}
+	}
+	return server
+}
+
+// jetbrainsServer converts a JetBrains AI Assistant command back into a server.
+func jetbrainsServer(c jetbrainsMCPCommand) config.MCPServer {
+	var server config.MCPServer
+	for _, opt := range c.Options {
+		switch opt.Name {
+		case "programPath":
+			server.Command = opt.Value
+		case "arguments":
+			server.Args = strings.Fields(opt.Value)
+		case "enabled":
+			server.Disabled = opt.Value == "false"
+		case "envs":
+			if opt.Map != nil {
+				server.Env = make(map[string]string, len(opt.Map.Entries))
+				for _, e := range opt.Map.Entries {
+					server.Env[e.Key] = e.Value
+				}
+			}
+		}
+	}
+	return server
+}
+
+func stringList(v interface{}) []string {
+	items, _ := v.([]interface{})
+	var list []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,34 @@
+package translator
+
+import (
+	"slices"
+
+	"github.com/tuannvm/mcpenetes/internal/client"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/secrets"
+)
+
+// resolveSecrets replaces references to stored secrets in the server's env with their
+// values, which the client needs to start the server. VS Code prompts for secret env
+// values through "inputs", so they aren't read for it.
+func resolveSecrets(format client.ConfigFormatEnum, serverConf config.MCPServer) (config.MCPServer, error) {
+	prompts := format == client.FormatVSCode || format == client.FormatVSCodeMCP
+
+	// Copy env so the shared MCPConfig map isn't modified
+	env := make(map[string]string, len(serverConf.Env))
+	for key, value := range serverConf.Env {
+		if prompts && slices.Contains(serverConf.SecretEnv, key) {
+			env[key] = value
+			continue
+		}
+		resolved, err := secrets.Resolve(value)
+		if err != nil {
+			return serverConf, err
+		}
+		env[key] = resolved
+	}
+	if serverConf.Env != nil {
+		serverConf.Env = env
+	}
+	return serverConf, nil
+}
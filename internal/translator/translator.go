@@ -22,6 +22,8 @@ import (
 type Translator struct {
 	AppConfig *config.Config
 	MCPConfig *config.MCPConfig
+
+	warnings []Warning
 }
 
 // NewTranslator creates a new Translator instance.
@@ -34,6 +36,12 @@ func NewTranslator(appCfg *config.Config, mcpCfg *config.MCPConfig) *Translator
 
 // BackupClientConfig creates a timestamped backup of a client's configuration file.
 func (t *Translator) BackupClientConfig(clientName string, clientConf config.Client) (string, error) {
+	scope := clientConf.Scope
+	clientConf, err := resolveScope(clientConf)
+	if err != nil {
+		return "", fmt.Errorf("invalid scope for %s: %w", clientName, err)
+	}
+
 	backupDir, err := util.ExpandPath(t.AppConfig.Backups.Path)
 	if err != nil {
 		return "", fmt.Errorf("failed to expand backup path '%s': %w", t.AppConfig.Backups.Path, err)
@@ -58,13 +66,31 @@ func (t *Translator) BackupClientConfig(clientName string, clientConf config.Cli
 		}
 		return "", fmt.Errorf("failed to stat source config file '%s': %w", clientConfigPath, err)
 	}
+
+	// Create timestamped backup filename
+	name := backupName(clientName, time.Now())
+	// The scope is resolved: a repo-scope backup is of the project's .mcp.json
+	target := BackupTarget{Client: clientName, Path: clientConfigPath, Type: clientConf.Type, Scope: scope, Project: clientConf.Project}
+
 	if srcInfo.IsDir() {
-		return "", fmt.Errorf("source config path '%s' is a directory, not a file", clientConfigPath)
+		if !client.IsDirectoryFormat(client.ConfigFormatEnum(clientConf.Type)) {
+			return "", fmt.Errorf("source config path '%s' is a directory, not a file", clientConfigPath)
+		}
+
+		// Directory-backed clients are snapshotted as a whole: <clientName>-<timestamp>/
+		backupDirPath := filepath.Join(backupDir, name)
+		if err := util.CopyDir(clientConfigPath, backupDirPath); err != nil {
+			return "", fmt.Errorf("failed to snapshot config directory '%s': %w", clientConfigPath, err)
+		}
+		if err := writeBackupTarget(backupDirPath, target); err != nil {
+			return "", fmt.Errorf("failed to record what '%s' is a backup of: %w", backupDirPath, err)
+		}
+
+		fmt.Printf("  Backed up '%s' to '%s'\n", clientConfigPath, backupDirPath)
+		return backupDirPath, nil
 	}
 
-	// Create timestamped backup filename
-	timestamp := time.Now().Format("20060102-150405") // YYYYMMDD-HHMMSS
-	backupFileName := fmt.Sprintf("%s-%s%s", clientName, timestamp, filepath.Ext(clientConfigPath))
+	backupFileName := name + filepath.Ext(clientConfigPath)
 	backupFilePath := filepath.Join(backupDir, backupFileName)
 
 	// Open source file
@@ -90,6 +116,9 @@ func (t *Translator) BackupClientConfig(clientName string, clientConf config.Cli
 	if err != nil {
 		return "", fmt.Errorf("failed to copy config to backup file '%s': %w", backupFilePath, err)
 	}
+	if err := writeBackupTarget(backupFilePath, target); err != nil {
+		return "", fmt.Errorf("failed to record what '%s' is a backup of: %w", backupFilePath, err)
+	}
 
 	fmt.Printf("  Backed up '%s' to '%s'\n", clientConfigPath, backupFilePath)
 
@@ -101,6 +130,11 @@ func (t *Translator) BackupClientConfig(clientName string, clientConf config.Cli
 // TranslateAndApply translates the selected MCP config and writes it to the client's path.
 // If serverConf is nil, it will remove the server from the client's configuration.
 func (t *Translator) TranslateAndApply(clientName string, clientConf config.Client, serverConf config.MCPServer) error {
+	clientConf, err := resolveScope(clientConf)
+	if err != nil {
+		return fmt.Errorf("invalid scope for %s: %w", clientName, err)
+	}
+
 	clientConfigPath, err := util.ExpandPath(clientConf.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to expand client config path '%s' for %s: %w", clientConf.ConfigPath, clientName, err)
@@ -138,29 +172,44 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 		}
 	}
 
+	// Drop settings the client can't represent; warnings are collected for the caller
+	caps := capabilitiesFor(clientName, client.ConfigFormatEnum(clientConf.Type))
+	serverConf, ok := t.adaptServer(clientName, serverID, serverConf, caps)
+	if !ok {
+		return nil
+	}
+
 	var outputData []byte
 	formatType := client.ConfigFormatEnum(clientConf.Type)
 
+	// Secrets kept out of mcp.json are only read back for the client that needs them
+	secretFormat := formatType
+	if secretFormat == "" {
+		secretFormat = guessFormat(clientName, clientConfigPath)
+	}
+	serverConf, err = resolveSecrets(secretFormat, serverConf)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets of server %s: %w", serverID, err)
+	}
+
+	// Directory-backed clients get one file per server instead of a shared config file
+	if client.IsDirectoryFormat(formatType) {
+		return t.applyDirectoryServer(clientName, clientConfigPath, formatType, serverID, serverConf, caps)
+	}
+
+	// Claude Code keeps MCP servers inside its much larger ~/.claude.json; only patch the scope's subtree
+	if formatType == client.FormatClaudeCode {
+		return t.applyClaudeCodeServer(clientName, clientConfigPath, clientConf, serverID, serverConf, caps)
+	}
+
+	if formatType == client.FormatJetBrainsXML {
+		return t.applyJetBrainsServer(clientName, clientConfigPath, serverID, serverConf)
+	}
+
 	// Determine format type if not explicitly set
 	if formatType == "" {
-		ext := strings.ToLower(filepath.Ext(clientConfigPath))
-		switch ext {
-		case ".json":
-			// Try to guess from client name or default to simple json
-			if strings.Contains(clientName, "claude-desktop") {
-				formatType = client.FormatClaudeDesktop
-			} else if strings.Contains(clientName, "vscode") {
-				formatType = client.FormatVSCode
-			} else if strings.Contains(strings.ToLower(clientName), "continue") {
-				formatType = client.FormatContinue
-			} else {
-				formatType = client.FormatSimpleJSON
-			}
-		case ".yaml", ".yml":
-			formatType = client.FormatYAML
-		case ".toml":
-			formatType = client.FormatTOML
-		default:
+		formatType = guessFormat(clientName, clientConfigPath)
+		if formatType == "" {
 			return fmt.Errorf("unknown config format for client %s", clientName)
 		}
 	}
@@ -211,7 +260,7 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 			mcpServers = make(map[string]interface{})
 		}
 
-		serverEntry := t.createServerMap(serverConf)
+		serverEntry := t.createServerMap(serverConf, caps)
 		mcpServers[serverID] = serverEntry
 		claudeConfig["mcpServers"] = mcpServers
 
@@ -242,7 +291,7 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 			mcpServers = make(map[string]interface{})
 		}
 
-		serverEntry := t.createServerMap(serverConf)
+		serverEntry := t.createServerMap(serverConf, caps)
 		mcpServers[serverID] = serverEntry
 		configMap["mcpServers"] = mcpServers
 
@@ -270,35 +319,18 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 
 		// For now, supporting specific cases based on key structure
 		if clientConf.Key == "openctx.providers" {
-			// Special handling for OpenCtx providers structure
-			// "openctx.providers": { "https://...": { "mcp.provider.uri": "file:///..." } }
-			// NOTE: This currently only supports the 'modelcontextprotocol' provider type from OpenCtx
-			// and assumes we are configuring it to point to a local file/command?
-			// Actually, OpenCtx MCP provider usually takes a URL or command.
-			// Given the complexity of OpenCtx generic provider, we'll try to map standard MCP config to it if possible,
-			// or simply inject it as a raw object if the user knows what they are doing.
-
-			// For simplicity in this iteration, we will treat it as a map where we inject the server config
-			// BUT OpenCtx providers usually expect a specific schema.
-			// Let's assume for Cody we are injecting into "openctx.providers".
-			// However, Cody/OpenCtx usually expects a map of Provider URLs to Config.
-
-			// If we are just injecting a standard MCP server list, this might not work directly with OpenCtx
-			// unless we are configuring the "mcp" provider specifically.
-
-			// Fallback: If the user explicitly requested this format, we try to inject it as a key.
-			var providers map[string]interface{}
-			if existing, ok := vscodeConfig["openctx.providers"].(map[string]interface{}); ok {
-				providers = existing
-			} else {
+			// Cody reads MCP servers through the OpenCtx MCP provider, one provider entry per server
+			providers, ok := vscodeConfig["openctx.providers"].(map[string]interface{})
+			if !ok {
 				providers = make(map[string]interface{})
 			}
-
-			// We can't easily map a list of MCP servers to OpenCtx providers list without a specific adapter.
-			// But if the requirement is just to support the key:
-			// We will inject the server config under the serverID key in that map.
-			serverEntry := t.createServerMap(serverConf)
-			providers[serverID] = serverEntry
+			if reason := openCtxUnsupported(serverConf); reason != "" {
+				t.warn(Warning{Client: clientName, Server: serverID, Field: "command", Message: reason + ", skipped"})
+				// Drop a provider written for the server before it was checked
+				delete(providers, openCtxProviderKey(serverID))
+			} else {
+				applyOpenCtxProvider(providers, serverID, serverConf)
+			}
 			vscodeConfig["openctx.providers"] = providers
 
 		} else {
@@ -319,12 +351,19 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 				mcpServers = make(map[string]interface{})
 			}
 
-			serverEntry := t.createServerMap(serverConf)
+			serverEntry := t.createServerMap(serverConf, caps)
 			// VSCode format explicitly needs env even if empty, usually
 			if _, ok := serverEntry["env"]; !ok {
 				serverEntry["env"] = make(map[string]string)
 			}
 
+			// Secret env values are prompted for via "mcp.inputs" instead of being inlined
+			inputs, _ := mcpObj["inputs"].([]interface{})
+			inputs = applyVSCodeInputs(serverID, serverEntry, serverConf, inputs)
+			if inputs != nil {
+				mcpObj["inputs"] = inputs
+			}
+
 			mcpServers[serverID] = serverEntry
 			mcpObj["servers"] = mcpServers
 			vscodeConfig["mcp"] = mcpObj
@@ -335,6 +374,40 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 			return fmt.Errorf("failed to marshal VS Code config: %w", err)
 		}
 
+	case client.FormatVSCodeMCP:
+		// Format: {"servers": {"server-id": {"type": "stdio", ...}}, "inputs": [...]}
+		var mcpFile map[string]interface{}
+
+		if err := parseJSONSafe(clientConfigPath, &mcpFile); err != nil {
+			return err
+		}
+
+		if mcpFile == nil {
+			mcpFile = make(map[string]interface{})
+		}
+
+		servers, ok := mcpFile["servers"].(map[string]interface{})
+		if !ok {
+			servers = make(map[string]interface{})
+		}
+
+		serverEntry := vscodeServerEntry(t.createServerMap(serverConf, caps))
+
+		// Secret env values are prompted for via "inputs" instead of being inlined
+		inputs, ok := mcpFile["inputs"].([]interface{})
+		if !ok {
+			inputs = []interface{}{}
+		}
+		mcpFile["inputs"] = applyVSCodeInputs(serverID, serverEntry, serverConf, inputs)
+
+		servers[serverID] = serverEntry
+		mcpFile["servers"] = servers
+
+		outputData, err = json.MarshalIndent(mcpFile, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal VS Code mcp.json: %w", err)
+		}
+
 	case client.FormatContinue:
 		// Continue uses nested structure:
 		// "experimental": { "modelContextProtocolServers": [ { "name": "...", "transport": { ... } } ] }
@@ -434,7 +507,7 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 			mcpServers = make(map[string]interface{})
 		}
 
-		serverEntry := t.createServerMap(serverConf)
+		serverEntry := t.createServerMap(serverConf, caps)
 		mcpServers[serverID] = serverEntry
 		yamlConfig["mcpServers"] = mcpServers
 
@@ -467,7 +540,7 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 			mcpServers = make(map[string]interface{})
 		}
 
-		serverEntry := t.createServerMap(serverConf)
+		serverEntry := t.createServerMap(serverConf, caps)
 		mcpServers[serverID] = serverEntry
 		tomlConfig["mcpServers"] = mcpServers
 
@@ -493,29 +566,41 @@ func (t *Translator) TranslateAndApply(clientName string, clientConf config.Clie
 	}
 
 	fmt.Printf("  Successfully wrote config for %s to '%s'\n", clientName, clientConfigPath)
+
+	// The server now lives in the dedicated mcp.json; drop any copy left under
+	// the legacy "mcp.servers" key in the sibling settings.json
+	if formatType == client.FormatVSCodeMCP {
+		if err := t.migrateLegacyVSCodeServer(clientName, clientConfigPath, serverID); err != nil {
+			fmt.Printf("  Warning: failed to migrate legacy VS Code settings for '%s': %v\n", serverID, err)
+		}
+	}
+
 	return nil
 }
 
-func (t *Translator) createServerMap(serverConf config.MCPServer) map[string]interface{} {
+func (t *Translator) createServerMap(serverConf config.MCPServer, caps client.Capabilities) map[string]interface{} {
 	serverEntry := make(map[string]interface{})
 
 	if serverConf.Command != "" {
-		serverEntry["command"] = serverConf.Command
+		serverEntry[caps.FieldName("command")] = serverConf.Command
 	}
 	if len(serverConf.Args) > 0 {
-		serverEntry["args"] = serverConf.Args
+		serverEntry[caps.FieldName("args")] = serverConf.Args
 	}
 	if len(serverConf.Env) > 0 {
-		serverEntry["env"] = serverConf.Env
+		serverEntry[caps.FieldName("env")] = serverConf.Env
 	}
 	if serverConf.URL != "" {
-		serverEntry["url"] = serverConf.URL
+		serverEntry[caps.FieldName("url")] = serverConf.URL
 	}
 	if serverConf.Disabled {
-		serverEntry["disabled"] = serverConf.Disabled
+		serverEntry[caps.FieldName("disabled")] = serverConf.Disabled
 	}
 	if len(serverConf.AutoApprove) > 0 {
-		serverEntry["autoApprove"] = serverConf.AutoApprove
+		serverEntry[caps.FieldName("autoApprove")] = serverConf.AutoApprove
+	}
+	if serverConf.Permissions != nil && len(serverConf.Permissions.Deny) > 0 {
+		serverEntry[caps.FieldName("disabledTools")] = serverConf.Permissions.Deny
 	}
 
 	return serverEntry
@@ -523,11 +608,28 @@ func (t *Translator) createServerMap(serverConf config.MCPServer) map[string]int
 
 // RemoveClientServers removes servers from client configurations that no longer exist in the main MCP configuration
 func (t *Translator) RemoveClientServers(clientName string, clientConf config.Client) error {
+	clientConf, err := resolveScope(clientConf)
+	if err != nil {
+		return fmt.Errorf("invalid scope for %s: %w", clientName, err)
+	}
+
 	clientConfigPath, err := util.ExpandPath(clientConf.ConfigPath)
 	if err != nil {
 		return fmt.Errorf("failed to expand client config path '%s' for %s: %w", clientConf.ConfigPath, clientName, err)
 	}
 
+	if client.IsDirectoryFormat(client.ConfigFormatEnum(clientConf.Type)) {
+		return t.removeDirectoryServers(clientConfigPath, client.ConfigFormatEnum(clientConf.Type))
+	}
+
+	if client.ConfigFormatEnum(clientConf.Type) == client.FormatClaudeCode {
+		return t.removeClaudeCodeServers(clientConfigPath, clientConf)
+	}
+
+	if client.ConfigFormatEnum(clientConf.Type) == client.FormatJetBrainsXML {
+		return t.removeJetBrainsServers(clientConfigPath)
+	}
+
 	// Check if client config file exists
 	_, err = os.Stat(clientConfigPath)
 	if os.IsNotExist(err) {
@@ -550,26 +652,7 @@ func (t *Translator) RemoveClientServers(clientName string, clientConf config.Cl
 	formatType := client.ConfigFormatEnum(clientConf.Type)
 	// Determine format type if not explicitly set
 	if formatType == "" {
-		ext := strings.ToLower(filepath.Ext(clientConfigPath))
-		switch ext {
-		case ".json":
-			// Try to guess from client name or default to simple json
-			if strings.Contains(clientName, "claude-desktop") {
-				formatType = client.FormatClaudeDesktop
-			} else if strings.Contains(clientName, "vscode") {
-				formatType = client.FormatVSCode
-			} else if strings.Contains(strings.ToLower(clientName), "continue") {
-				formatType = client.FormatContinue
-			} else {
-				formatType = client.FormatSimpleJSON
-			}
-		case ".yaml", ".yml":
-			formatType = client.FormatYAML
-		case ".toml":
-			formatType = client.FormatTOML
-		default:
-			// Fallback/Legacy logic if needed, or return error
-		}
+		formatType = guessFormat(clientName, clientConfigPath)
 	}
 
 	// Helper to parse JSON/JSONC
@@ -613,7 +696,7 @@ func (t *Translator) RemoveClientServers(clientName string, clientConf config.Cl
 
 		if clientConf.Key == "openctx.providers" {
 			if providers, ok := clientConfig["openctx.providers"].(map[string]interface{}); ok {
-				if t.removeObsoleteServers(providers) {
+				if t.removeObsoleteProviders(providers) {
 					changed = true
 					clientConfig["openctx.providers"] = providers
 				}
@@ -626,6 +709,40 @@ func (t *Translator) RemoveClientServers(clientName string, clientConf config.Cl
 						mcpObj["servers"] = servers
 						clientConfig["mcp"] = mcpObj
 					}
+					if inputs, ok := mcpObj["inputs"].([]interface{}); ok {
+						if kept, pruned := pruneVSCodeInputs(servers, inputs); pruned {
+							changed = true
+							mcpObj["inputs"] = kept
+							clientConfig["mcp"] = mcpObj
+						}
+					}
+				}
+			}
+		}
+
+		if changed {
+			outputData, err := json.MarshalIndent(clientConfig, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal updated config: %w", err)
+			}
+			return os.WriteFile(clientConfigPath, outputData, 0644)
+		}
+
+	case client.FormatVSCodeMCP:
+		var clientConfig map[string]interface{}
+		if err := parseJSON(&clientConfig); err != nil {
+			return fmt.Errorf("failed to parse client JSON config file '%s': %w", clientConfigPath, err)
+		}
+
+		if servers, ok := clientConfig["servers"].(map[string]interface{}); ok {
+			if t.removeObsoleteServers(servers) {
+				changed = true
+				clientConfig["servers"] = servers
+			}
+			if inputs, ok := clientConfig["inputs"].([]interface{}); ok {
+				if kept, pruned := pruneVSCodeInputs(servers, inputs); pruned {
+					changed = true
+					clientConfig["inputs"] = kept
 				}
 			}
 		}
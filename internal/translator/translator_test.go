@@ -2,11 +2,15 @@ package translator_test
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/tailscale/hujson"
 	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/secrets"
 	"github.com/tuannvm/mcpenetes/internal/translator"
 )
 
@@ -114,3 +118,838 @@ func TestTranslateAndApply_InvalidJSON(t *testing.T) {
 		t.Logf("Got expected error: %v", err)
 	}
 }
+
+// TestTranslateAndApply_DirectoryFormat verifies that directory-backed clients get one file
+// per server and that files of removed servers are deleted.
+func TestTranslateAndApply_DirectoryFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	clientDir := filepath.Join(tmpDir, "mcpServers")
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"memory": {Command: "npx", Args: []string{"-y", "@modelcontextprotocol/server-memory"}},
+			"remote": {URL: "https://example.com/mcp"},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+	clientConf := config.Client{ConfigPath: clientDir, Type: "continue-blocks"}
+
+	for _, server := range mcpCfg.MCPServers {
+		if err := tr.TranslateAndApply("continue-blocks", clientConf, server); err != nil {
+			t.Fatalf("TranslateAndApply failed: %v", err)
+		}
+	}
+
+	for _, name := range []string{"memory.yaml", "remote.yaml"} {
+		if _, err := os.Stat(filepath.Join(clientDir, name)); err != nil {
+			t.Errorf("Expected server file %s to exist: %v", name, err)
+		}
+	}
+
+	// An unrelated file in the directory must survive pruning
+	if err := os.WriteFile(filepath.Join(clientDir, "notes.txt"), []byte("keep me"), 0644); err != nil {
+		t.Fatalf("Failed to write unrelated file: %v", err)
+	}
+
+	// Drop "remote" from the main config and prune
+	delete(mcpCfg.MCPServers, "remote")
+	if err := tr.RemoveClientServers("continue-blocks", clientConf); err != nil {
+		t.Fatalf("RemoveClientServers failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(clientDir, "remote.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected remote.yaml to be removed, got err=%v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clientDir, "memory.yaml")); err != nil {
+		t.Errorf("Expected memory.yaml to be kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clientDir, "notes.txt")); err != nil {
+		t.Errorf("Expected unrelated file to be kept: %v", err)
+	}
+
+	// IDs that map to the same file name are refused rather than overwriting each other
+	mcpCfg.MCPServers["acme/files"] = config.MCPServer{Command: "files"}
+	mcpCfg.MCPServers["Acme-Files"] = config.MCPServer{Command: "other-files"}
+	for _, id := range []string{"acme/files", "Acme-Files"} {
+		err := tr.TranslateAndApply("continue-blocks", clientConf, mcpCfg.MCPServers[id])
+		if err == nil || !strings.Contains(err.Error(), "would both be written") {
+			t.Errorf("TranslateAndApply(%s) error = %v, want a file name conflict", id, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(clientDir, "acme-files.yaml")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file for conflicting servers, got err=%v", err)
+	}
+}
+
+// TestTranslateAndApply_VSCodeMCP verifies the dedicated mcp.json schema and that a server
+// left under the legacy settings.json "mcp.servers" key is migrated out of it, keeping the
+// file's comments and backing it up first.
+func TestTranslateAndApply_VSCodeMCP(t *testing.T) {
+	tmpDir := t.TempDir()
+	mcpPath := filepath.Join(tmpDir, "mcp.json")
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+
+	legacy := `{
+	// Legacy settings
+	"editor.fontSize": 14,
+	"mcp": {"servers": {"git": {"command": "uvx"}, "manual": {"command": "echo"}},},
+}`
+	if err := os.WriteFile(settingsPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write settings.json: %v", err)
+	}
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"git": {Command: "uvx", Args: []string{"mcp-server-git"}},
+		},
+	}
+	backupDir := filepath.Join(tmpDir, "backups")
+	tr := translator.NewTranslator(&config.Config{Backups: config.BackupConfig{Path: backupDir}}, mcpCfg)
+	clientConf := config.Client{ConfigPath: mcpPath, Type: "vscode-mcp"}
+
+	if err := tr.TranslateAndApply("vscode", clientConf, mcpCfg.MCPServers["git"]); err != nil {
+		t.Fatalf("TranslateAndApply failed: %v", err)
+	}
+
+	var mcpFile map[string]interface{}
+	data, _ := os.ReadFile(mcpPath)
+	if err := json.Unmarshal(data, &mcpFile); err != nil {
+		t.Fatalf("Failed to parse mcp.json: %v", err)
+	}
+	servers := mcpFile["servers"].(map[string]interface{})
+	git, ok := servers["git"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected 'git' under top-level 'servers', got %v", mcpFile)
+	}
+	if git["type"] != "stdio" {
+		t.Errorf("Expected type 'stdio', got %v", git["type"])
+	}
+	if _, ok := mcpFile["inputs"]; !ok {
+		t.Errorf("Expected 'inputs' to be present")
+	}
+
+	data, _ = os.ReadFile(settingsPath)
+	if !strings.Contains(string(data), "// Legacy settings") {
+		t.Errorf("Expected the comment in settings.json to be kept, got %s", data)
+	}
+	var settings map[string]interface{}
+	standardized, err := hujson.Standardize(data)
+	if err != nil {
+		t.Fatalf("Failed to parse settings.json: %v", err)
+	}
+	if err := json.Unmarshal(standardized, &settings); err != nil {
+		t.Fatalf("Failed to parse settings.json: %v", err)
+	}
+	backups, _ := filepath.Glob(filepath.Join(backupDir, "vscode-settings", "vscode-*.json"))
+	if len(backups) != 1 {
+		t.Fatalf("Expected one backup of settings.json, got %v", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != legacy {
+		t.Errorf("Expected the backup to hold the original settings.json, got %s", backup)
+	}
+	legacyServers := settings["mcp"].(map[string]interface{})["servers"].(map[string]interface{})
+	if _, ok := legacyServers["git"]; ok {
+		t.Errorf("Expected 'git' to be migrated out of legacy mcp.servers")
+	}
+	if _, ok := legacyServers["manual"]; !ok {
+		t.Errorf("Expected unmanaged legacy server 'manual' to be kept")
+	}
+	if settings["editor.fontSize"] != float64(14) {
+		t.Errorf("Unrelated setting was lost")
+	}
+}
+
+// TestTranslateAndApply_VSCodeInputs verifies that secret env values become password inputs
+// and that inputs no longer referenced are garbage-collected on prune.
+func TestTranslateAndApply_VSCodeInputs(t *testing.T) {
+	tmpDir := t.TempDir()
+	mcpPath := filepath.Join(tmpDir, "mcp.json")
+
+	github := config.MCPServer{
+		Command:   "npx",
+		Args:      []string{"-y", "@modelcontextprotocol/server-github"},
+		Env:       map[string]string{"GITHUB_PERSONAL_ACCESS_TOKEN": "ghp_secret", "LOG_LEVEL": "info"},
+		SecretEnv: []string{"GITHUB_PERSONAL_ACCESS_TOKEN"},
+	}
+	mcpCfg := &config.MCPConfig{MCPServers: map[string]config.MCPServer{"github": github}}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+	clientConf := config.Client{ConfigPath: mcpPath, Type: "vscode-mcp"}
+
+	if err := tr.TranslateAndApply("vscode", clientConf, github); err != nil {
+		t.Fatalf("TranslateAndApply failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(mcpPath)
+	var mcpFile map[string]interface{}
+	if err := json.Unmarshal(data, &mcpFile); err != nil {
+		t.Fatalf("Failed to parse mcp.json: %v", err)
+	}
+
+	env := mcpFile["servers"].(map[string]interface{})["github"].(map[string]interface{})["env"].(map[string]interface{})
+	if env["GITHUB_PERSONAL_ACCESS_TOKEN"] != "${input:github.GITHUB_PERSONAL_ACCESS_TOKEN}" {
+		t.Errorf("Expected secret to be replaced by an input reference, got %v", env["GITHUB_PERSONAL_ACCESS_TOKEN"])
+	}
+	if env["LOG_LEVEL"] != "info" {
+		t.Errorf("Expected non-secret env value to be kept, got %v", env["LOG_LEVEL"])
+	}
+	if github.Env["GITHUB_PERSONAL_ACCESS_TOKEN"] != "ghp_secret" {
+		t.Errorf("Source MCP config was modified")
+	}
+
+	inputs := mcpFile["inputs"].([]interface{})
+	if len(inputs) != 1 {
+		t.Fatalf("Expected 1 input, got %d", len(inputs))
+	}
+	input := inputs[0].(map[string]interface{})
+	if input["type"] != "promptString" || input["password"] != true {
+		t.Errorf("Expected a password promptString input, got %v", input)
+	}
+
+	// Removing the server should garbage-collect its input
+	delete(mcpCfg.MCPServers, "github")
+	if err := tr.RemoveClientServers("vscode", clientConf); err != nil {
+		t.Fatalf("RemoveClientServers failed: %v", err)
+	}
+
+	data, _ = os.ReadFile(mcpPath)
+	mcpFile = nil
+	if err := json.Unmarshal(data, &mcpFile); err != nil {
+		t.Fatalf("Failed to parse mcp.json: %v", err)
+	}
+	if inputs := mcpFile["inputs"].([]interface{}); len(inputs) != 0 {
+		t.Errorf("Expected unreferenced inputs to be removed, got %v", inputs)
+	}
+}
+
+// TestTranslateAndApply_VSCodeInputIDs verifies that server and env key pairs that would
+// read alike once joined still get inputs of their own.
+func TestTranslateAndApply_VSCodeInputIDs(t *testing.T) {
+	mcpPath := filepath.Join(t.TempDir(), "mcp.json")
+	servers := map[string]config.MCPServer{
+		"a-b":       {Command: "one", Env: map[string]string{"C": "1"}, SecretEnv: []string{"C"}},
+		"a":         {Command: "two", Env: map[string]string{"B_C": "2", "b_c": "3"}, SecretEnv: []string{"B_C", "b_c"}},
+		"acme.io":   {Command: "three", Env: map[string]string{"KEY": "4"}, SecretEnv: []string{"KEY"}},
+		"acme":      {Command: "four", Env: map[string]string{"io.KEY": "5"}, SecretEnv: []string{"io.KEY"}},
+		"weird}id%": {Command: "five", Env: map[string]string{"KEY": "6"}, SecretEnv: []string{"KEY"}},
+	}
+	tr := translator.NewTranslator(&config.Config{}, &config.MCPConfig{MCPServers: servers})
+	clientConf := config.Client{ConfigPath: mcpPath, Type: "vscode-mcp"}
+	for id, server := range servers {
+		if err := tr.TranslateAndApply("vscode", clientConf, server); err != nil {
+			t.Fatalf("TranslateAndApply(%s) failed: %v", id, err)
+		}
+	}
+
+	data, _ := os.ReadFile(mcpPath)
+	var mcpFile struct {
+		Servers map[string]struct {
+			Env map[string]string `json:"env"`
+		} `json:"servers"`
+		Inputs []struct {
+			ID string `json:"id"`
+		} `json:"inputs"`
+	}
+	if err := json.Unmarshal(data, &mcpFile); err != nil {
+		t.Fatalf("Failed to parse mcp.json: %v", err)
+	}
+	if len(mcpFile.Inputs) != 6 {
+		t.Errorf("Expected an input per secret, got %v", mcpFile.Inputs)
+	}
+	refs := make(map[string]string)
+	for id, server := range mcpFile.Servers {
+		for key, value := range server.Env {
+			if other, ok := refs[value]; ok {
+				t.Errorf("%s %s and %s share the input %s", id, key, other, value)
+			}
+			refs[value] = id + " " + key
+			if strings.Count(value, "}") != 1 || !strings.HasSuffix(value, "}") {
+				t.Errorf("%s %s: malformed input reference %s", id, key, value)
+			}
+		}
+	}
+}
+
+// TestTranslateAndApply_StoredSecrets verifies that references to stored secrets are
+// replaced by their values, except where VS Code prompts for them.
+func TestTranslateAndApply_StoredSecrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("USERPROFILE", tmpDir)
+
+	store := &secrets.FileStore{Path: filepath.Join(tmpDir, "secrets.json")}
+	defaultStore := secrets.Default
+	secrets.Default = func() secrets.Store { return store }
+	t.Cleanup(func() { secrets.Default = defaultStore })
+
+	ref, _, err := secrets.Save("github", "GITHUB_TOKEN", "ghp_secret")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	github := config.MCPServer{
+		Command:   "npx",
+		Args:      []string{"-y", "@modelcontextprotocol/server-github"},
+		Env:       map[string]string{"GITHUB_TOKEN": ref, "LOG_LEVEL": "info"},
+		SecretEnv: []string{"GITHUB_TOKEN"},
+	}
+	mcpCfg := &config.MCPConfig{MCPServers: map[string]config.MCPServer{"github": github}}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	readEnv := func(path, serversKey string) map[string]interface{} {
+		var cfg map[string]interface{}
+		data, _ := os.ReadFile(path)
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		return cfg[serversKey].(map[string]interface{})["github"].(map[string]interface{})["env"].(map[string]interface{})
+	}
+
+	clinePath := filepath.Join(tmpDir, "cline.json")
+	if err := tr.TranslateAndApply("cline", config.Client{ConfigPath: clinePath, Type: "simple-json"}, github); err != nil {
+		t.Fatalf("TranslateAndApply failed: %v", err)
+	}
+	if env := readEnv(clinePath, "mcpServers"); env["GITHUB_TOKEN"] != "ghp_secret" || env["LOG_LEVEL"] != "info" {
+		t.Errorf("Expected the stored secret to be written, got %v", env)
+	}
+	if github.Env["GITHUB_TOKEN"] != ref {
+		t.Errorf("Source MCP config was modified")
+	}
+
+	vscodePath := filepath.Join(tmpDir, "mcp.json")
+	if err := tr.TranslateAndApply("vscode", config.Client{ConfigPath: vscodePath, Type: "vscode-mcp"}, github); err != nil {
+		t.Fatalf("TranslateAndApply failed: %v", err)
+	}
+	if env := readEnv(vscodePath, "servers"); env["GITHUB_TOKEN"] != "${input:github.GITHUB_TOKEN}" {
+		t.Errorf("Expected VS Code to prompt for the secret, got %v", env)
+	}
+
+	// A secret that is gone can't be written
+	if err := store.Delete(secrets.Name("github", "GITHUB_TOKEN")); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := tr.TranslateAndApply("cline", config.Client{ConfigPath: clinePath, Type: "simple-json"}, github); err == nil {
+		t.Errorf("Expected an error for a missing secret")
+	}
+}
+
+// TestTranslateAndApply_ClaudeCodeScopes verifies that Claude Code scopes only touch their own
+// "mcpServers" subtree of ~/.claude.json, and that the repo scope writes <project>/.mcp.json.
+func TestTranslateAndApply_ClaudeCodeScopes(t *testing.T) {
+	tmpDir := t.TempDir()
+	claudePath := filepath.Join(tmpDir, ".claude.json")
+	project := filepath.Join(tmpDir, "repo")
+	other := filepath.Join(tmpDir, "other")
+
+	initial := `{
+  "numStartups": 42,
+  "mcpServers": {"manual": {"command": "echo"}},
+  "projects": {
+    "` + other + `": {"allowedTools": ["Bash"], "mcpServers": {"keep": {"command": "true"}}}
+  }
+}`
+	if err := os.WriteFile(claudePath, []byte(initial), 0600); err != nil {
+		t.Fatalf("Failed to write .claude.json: %v", err)
+	}
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"git": {Command: "uvx", Args: []string{"mcp-server-git"}},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	projectConf := config.Client{ConfigPath: claudePath, Type: "claude-code", Scope: "project", Project: project}
+	if err := tr.TranslateAndApply("claude-code", projectConf, mcpCfg.MCPServers["git"]); err != nil {
+		t.Fatalf("TranslateAndApply (project) failed: %v", err)
+	}
+	if err := tr.RemoveClientServers("claude-code", projectConf); err != nil {
+		t.Fatalf("RemoveClientServers (project) failed: %v", err)
+	}
+
+	var result map[string]interface{}
+	data, _ := os.ReadFile(claudePath)
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("Failed to parse .claude.json: %v", err)
+	}
+	if result["numStartups"] != float64(42) {
+		t.Errorf("Unrelated key was lost or changed")
+	}
+	if _, ok := result["mcpServers"].(map[string]interface{})["manual"]; !ok {
+		t.Errorf("User-scope servers must not be touched by the project scope")
+	}
+	projects := result["projects"].(map[string]interface{})
+	otherProject := projects[other].(map[string]interface{})
+	if _, ok := otherProject["mcpServers"].(map[string]interface{})["keep"]; !ok {
+		t.Errorf("Other project's servers must not be touched")
+	}
+	servers := projects[project].(map[string]interface{})["mcpServers"].(map[string]interface{})
+	if git, ok := servers["git"].(map[string]interface{}); !ok || git["type"] != "stdio" {
+		t.Errorf("Expected stdio server 'git' in project scope, got %v", servers)
+	}
+	if info, err := os.Stat(claudePath); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("Expected file mode 0600 to be preserved, got %v", info.Mode().Perm())
+	}
+
+	repoConf := config.Client{ConfigPath: claudePath, Type: "claude-code", Scope: "repo", Project: project}
+	if err := tr.TranslateAndApply("claude-code", repoConf, mcpCfg.MCPServers["git"]); err != nil {
+		t.Fatalf("TranslateAndApply (repo) failed: %v", err)
+	}
+	var repoFile struct {
+		MCPServers map[string]interface{} `json:"mcpServers"`
+	}
+	data, err := os.ReadFile(filepath.Join(project, ".mcp.json"))
+	if err != nil {
+		t.Fatalf("Expected .mcp.json in project: %v", err)
+	}
+	if err := json.Unmarshal(data, &repoFile); err != nil {
+		t.Fatalf("Failed to parse .mcp.json: %v", err)
+	}
+	if _, ok := repoFile.MCPServers["git"]; !ok {
+		t.Errorf("Expected 'git' in .mcp.json, got %v", repoFile.MCPServers)
+	}
+}
+
+// TestTranslateAndApply_OpenCtxProviders verifies that Cody gets one OpenCtx MCP provider entry
+// per server in settings.json, and that only providers created by mcpenetes are pruned.
+func TestTranslateAndApply_OpenCtxProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	settingsPath := filepath.Join(tmpDir, "settings.json")
+	script := filepath.Join(tmpDir, "server", "index.js")
+
+	initial := `{
+	// Cody settings
+	"cody.autocomplete.enabled": true,
+	"openctx.providers": {
+		"https://openctx.org/npm/@openctx/provider-devdocs": {"urls": ["https://devdocs.io/go/"]},
+		"https://openctx.org/npm/@openctx/provider-modelcontextprotocol#old": {"mcp.provider.uri": "file:///old.js", "mcpenetes.server": "old"},
+		"https://openctx.org/npm/@openctx/provider-modelcontextprotocol#fetch": {"mcp.provider.uri": "file:///usr/bin/uvx", "mcpenetes.server": "fetch"},
+		"git": {"command": "uvx"}
+	}
+}`
+	if err := os.WriteFile(settingsPath, []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to write settings.json: %v", err)
+	}
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"git":    {Command: "node", Args: []string{script, "--repo", "."}, Env: map[string]string{"DEBUG": "1"}},
+			"remote": {URL: "https://example.com/mcp"},
+			"fetch":  {Command: "uvx", Args: []string{"mcp-server-fetch"}},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+	clientConf := config.Client{ConfigPath: settingsPath, Type: "vscode", Key: "openctx.providers"}
+
+	for _, id := range []string{"git", "remote", "fetch"} {
+		if err := tr.TranslateAndApply("cody", clientConf, mcpCfg.MCPServers[id]); err != nil {
+			t.Fatalf("TranslateAndApply(%s) failed: %v", id, err)
+		}
+	}
+	// OpenCtx only starts node scripts, so runners like uvx are skipped with a warning
+	if warnings := tr.TakeWarnings(); len(warnings) != 1 || warnings[0].Server != "fetch" || warnings[0].Field != "command" {
+		t.Errorf("warnings = %v, want one for fetch's command", warnings)
+	}
+	if err := tr.RemoveClientServers("cody", clientConf); err != nil {
+		t.Fatalf("RemoveClientServers failed: %v", err)
+	}
+
+	var settings map[string]interface{}
+	data, _ := os.ReadFile(settingsPath)
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatalf("Failed to parse settings.json: %v", err)
+	}
+	if settings["cody.autocomplete.enabled"] != true {
+		t.Errorf("Unrelated setting was lost")
+	}
+
+	providers := settings["openctx.providers"].(map[string]interface{})
+	const provider = "https://openctx.org/npm/@openctx/provider-modelcontextprotocol"
+
+	if _, ok := providers["https://openctx.org/npm/@openctx/provider-devdocs"]; !ok {
+		t.Errorf("Expected user-configured provider to be kept")
+	}
+	if _, ok := providers[provider+"#old"]; ok {
+		t.Errorf("Expected obsolete mcpenetes provider to be pruned")
+	}
+	if _, ok := providers["git"]; ok {
+		t.Errorf("Expected legacy raw server map to be replaced")
+	}
+
+	git, ok := providers[provider+"#git"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected provider entry for 'git', got %v", providers)
+	}
+	if got, want := git["mcp.provider.uri"], "file://"+filepath.ToSlash(script); got != want {
+		t.Errorf("mcp.provider.uri = %v, want %v", got, want)
+	}
+	if git["nodeCommand"] != "node" {
+		t.Errorf("nodeCommand = %v, want node", git["nodeCommand"])
+	}
+	if args, _ := git["mcp.provider.args"].([]interface{}); len(args) != 2 || args[0] != "--repo" {
+		t.Errorf("mcp.provider.args = %v, want [--repo .]", git["mcp.provider.args"])
+	}
+	if env, _ := git["mcp.provider.env"].(map[string]interface{}); env["DEBUG"] != "1" {
+		t.Errorf("mcp.provider.env = %v, want DEBUG=1", git["mcp.provider.env"])
+	}
+
+	remote, ok := providers[provider+"#remote"].(map[string]interface{})
+	if !ok || remote["mcp.provider.uri"] != "https://example.com/mcp" {
+		t.Errorf("Expected remote provider with URL, got %v", providers[provider+"#remote"])
+	}
+	if _, ok := providers[provider+"#fetch"]; ok {
+		t.Errorf("Expected the provider written for uvx to be dropped, got %v", providers[provider+"#fetch"])
+	}
+}
+
+// TestTranslateAndApply_JetBrainsXML verifies that servers are written as McpServerCommand
+// entries, that other components are kept and that obsolete commands are pruned.
+func TestTranslateAndApply_JetBrainsXML(t *testing.T) {
+	tmpDir := t.TempDir()
+	optionsPath := filepath.Join(tmpDir, "llm.mcpServers.xml")
+
+	initial := `<application>
+  <component name="SomethingElse">
+    <option name="keep" value="me" />
+  </component>
+  <component name="McpApplicationServerCommands">
+    <commands>
+      <McpServerCommand>
+        <option name="enabled" value="false" />
+        <option name="name" value="git" />
+        <option name="programPath" value="old" />
+      </McpServerCommand>
+      <McpServerCommand>
+        <option name="name" value="obsolete" />
+        <option name="programPath" value="echo" />
+      </McpServerCommand>
+    </commands>
+  </component>
+</application>`
+	if err := os.WriteFile(optionsPath, []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to write options XML: %v", err)
+	}
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"git":    {Command: "uvx", Args: []string{"mcp-server-git", "--repository", "/my repo"}, Env: map[string]string{"TOKEN": "x"}},
+			"remote": {URL: "https://example.com/mcp"},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+	clientConf := config.Client{ConfigPath: optionsPath, Type: "jetbrains-xml"}
+
+	for _, id := range []string{"git", "remote"} {
+		if err := tr.TranslateAndApply("jetbrains-ai:goland2024.3", clientConf, mcpCfg.MCPServers[id]); err != nil {
+			t.Fatalf("TranslateAndApply(%s) failed: %v", id, err)
+		}
+	}
+	if err := tr.RemoveClientServers("jetbrains-ai:goland2024.3", clientConf); err != nil {
+		t.Fatalf("RemoveClientServers failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(optionsPath)
+	var app struct {
+		Components []struct {
+			Name     string `xml:"name,attr"`
+			Commands []struct {
+				Options []struct {
+					Name    string `xml:"name,attr"`
+					Value   string `xml:"value,attr"`
+					Entries []struct {
+						Key   string `xml:"key,attr"`
+						Value string `xml:"value,attr"`
+					} `xml:"map>entry"`
+				} `xml:"option"`
+			} `xml:"commands>McpServerCommand"`
+		} `xml:"component"`
+	}
+	if err := xml.Unmarshal(data, &app); err != nil {
+		t.Fatalf("Failed to parse result XML: %v\n%s", err, data)
+	}
+	if len(app.Components) != 2 || app.Components[0].Name != "SomethingElse" {
+		t.Fatalf("Expected unrelated component to be kept, got %s", data)
+	}
+
+	commands := app.Components[1].Commands
+	if len(commands) != 1 {
+		t.Fatalf("Expected only 'git' (remote skipped, obsolete pruned), got %s", data)
+	}
+	options := map[string]string{}
+	for _, opt := range commands[0].Options {
+		options[opt.Name] = opt.Value
+		if opt.Name == "envs" && (len(opt.Entries) != 1 || opt.Entries[0].Key != "TOKEN") {
+			t.Errorf("Unexpected envs: %v", opt.Entries)
+		}
+	}
+	if options["programPath"] != "uvx" {
+		t.Errorf("programPath = %q, want uvx", options["programPath"])
+	}
+	if options["arguments"] != `mcp-server-git --repository "/my repo"` {
+		t.Errorf("arguments = %q", options["arguments"])
+	}
+	if options["enabled"] != "false" {
+		t.Errorf("Expected existing 'enabled' option to be kept, got %q", options["enabled"])
+	}
+}
+
+// TestTranslateAndApply_Capabilities verifies that fields are renamed per client, unsupported
+// settings are dropped and every loss is reported as a warning.
+func TestTranslateAndApply_Capabilities(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("USERPROFILE", tmpDir)
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"fs":     {Command: "npx", Args: []string{"server-fs"}, Disabled: true, AutoApprove: []string{"read_file"}},
+			"remote": {URL: "https://example.com/mcp"},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	readServers := func(path string) map[string]interface{} {
+		var cfg struct {
+			MCPServers map[string]interface{} `json:"mcpServers"`
+		}
+		data, _ := os.ReadFile(path)
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		return cfg.MCPServers
+	}
+
+	// Cline calls auto-approved tools "alwaysAllow"
+	clinePath := filepath.Join(tmpDir, "cline.json")
+	if err := tr.TranslateAndApply("cline", config.Client{ConfigPath: clinePath, Type: "simple-json"}, mcpCfg.MCPServers["fs"]); err != nil {
+		t.Fatalf("TranslateAndApply failed: %v", err)
+	}
+	fs := readServers(clinePath)["fs"].(map[string]interface{})
+	if _, ok := fs["alwaysAllow"]; !ok {
+		t.Errorf("Expected 'alwaysAllow' for Cline, got %v", fs)
+	}
+	if _, ok := fs["autoApprove"]; ok {
+		t.Errorf("Did not expect 'autoApprove' for Cline")
+	}
+	if warnings := tr.TakeWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for Cline, got %v", warnings)
+	}
+
+	// Claude Desktop supports neither remote servers, "disabled" nor auto-approval
+	desktopPath := filepath.Join(tmpDir, "claude_desktop_config.json")
+	desktopConf := config.Client{ConfigPath: desktopPath, Type: "claude-desktop"}
+	for _, id := range []string{"fs", "remote"} {
+		if err := tr.TranslateAndApply("claude-desktop", desktopConf, mcpCfg.MCPServers[id]); err != nil {
+			t.Fatalf("TranslateAndApply(%s) failed: %v", id, err)
+		}
+	}
+	servers := readServers(desktopPath)
+	if _, ok := servers["remote"]; ok {
+		t.Errorf("Expected remote server to be skipped for Claude Desktop")
+	}
+	fs = servers["fs"].(map[string]interface{})
+	if _, ok := fs["disabled"]; ok {
+		t.Errorf("Expected 'disabled' to be dropped, got %v", fs)
+	}
+	if _, ok := fs["autoApprove"]; ok {
+		t.Errorf("Expected 'autoApprove' to be dropped, got %v", fs)
+	}
+
+	fields := map[string]bool{}
+	for _, w := range tr.TakeWarnings() {
+		if w.Client != "claude-desktop" {
+			t.Errorf("Unexpected warning client: %v", w)
+		}
+		fields[w.Field] = true
+	}
+	for _, field := range []string{"url", "disabled", "autoApprove"} {
+		if !fields[field] {
+			t.Errorf("Expected a warning for %q, got %v", field, fields)
+		}
+	}
+}
+
+// TestCheckClientLimits verifies that a tool limit is only reported when the known tool
+// counts exceed it.
+func TestCheckClientLimits(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"github": {Command: "github-mcp"},
+			"fs":     {Command: "fs-mcp"},
+			"old":    {Command: "old-mcp", Disabled: true},
+			"docs":   {URL: "https://docs.example.com/mcp"},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	tests := []struct {
+		name   string
+		counts map[string]int
+		want   bool
+	}{
+		{name: "unknown counts", counts: nil},
+		{name: "under the limit", counts: map[string]int{"github": 30, "fs": 10}},
+		{name: "disabled servers aren't counted", counts: map[string]int{"github": 30, "old": 30}},
+		{name: "over the limit", counts: map[string]int{"github": 30, "fs": 11}, want: true},
+	}
+	for _, tt := range tests {
+		// Cursor exposes 40 tools
+		tr.CheckClientLimits("cursor", tt.counts)
+		if warnings := tr.TakeWarnings(); (len(warnings) > 0) != tt.want {
+			t.Errorf("%s: warnings = %v", tt.name, warnings)
+		}
+	}
+	tr.CheckClientLimits("cline", map[string]int{"github": 300})
+	if warnings := tr.TakeWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a client without a limit, got %v", warnings)
+	}
+}
+
+// TestTargetCapabilities verifies that formats with their own schema drop tool permissions.
+func TestTargetCapabilities(t *testing.T) {
+	if caps := translator.TargetCapabilities("cline", config.Client{Type: "simple-json"}); !caps.AutoApprove {
+		t.Errorf("Expected Cline to support auto-approval, got %+v", caps)
+	}
+	if caps := translator.TargetCapabilities("cline", config.Client{Type: "continue"}); caps.AutoApprove || caps.ToolFilter {
+		t.Errorf("Expected the Continue format to drop tool permissions, got %+v", caps)
+	}
+}
+
+// TestTranslateAndApply_ToolPermissions verifies that the permissions block is rendered into
+// each client's native keys and that unenforceable lists are reported.
+func TestTranslateAndApply_ToolPermissions(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv("USERPROFILE", tmpDir)
+
+	server := config.MCPServer{
+		Command:     "npx",
+		Args:        []string{"server-fs"},
+		AutoApprove: []string{"list_directory"},
+		Permissions: &config.ToolPermissions{
+			AutoApprove: []string{"read_file", "list_directory"},
+			Deny:        []string{"delete_file"},
+			Hide:        []string{"move_file"},
+		},
+	}
+	mcpCfg := &config.MCPConfig{MCPServers: map[string]config.MCPServer{"fs": server}}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	apply := func(clientName string) map[string]interface{} {
+		path := filepath.Join(tmpDir, clientName+".json")
+		if err := tr.TranslateAndApply(clientName, config.Client{ConfigPath: path, Type: "simple-json"}, server); err != nil {
+			t.Fatalf("TranslateAndApply(%s) failed: %v", clientName, err)
+		}
+		var cfg struct {
+			MCPServers map[string]map[string]interface{} `json:"mcpServers"`
+		}
+		data, _ := os.ReadFile(path)
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		return cfg.MCPServers["fs"]
+	}
+
+	roo := apply("roo-code")
+	if allow, _ := roo["alwaysAllow"].([]interface{}); len(allow) != 2 {
+		t.Errorf("Expected merged, de-duplicated alwaysAllow for Roo Code, got %v", roo["alwaysAllow"])
+	}
+	if disabled, _ := roo["disabledTools"].([]interface{}); len(disabled) != 2 {
+		t.Errorf("Expected denied and hidden tools in disabledTools, got %v", roo["disabledTools"])
+	}
+	if _, ok := roo["permissions"]; ok {
+		t.Errorf("The permissions block itself must not be written to clients")
+	}
+	if warnings := tr.TakeWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for Roo Code, got %v", warnings)
+	}
+
+	cline := apply("cline")
+	if _, ok := cline["disabledTools"]; ok {
+		t.Errorf("Did not expect disabledTools for Cline")
+	}
+	fields := map[string]bool{}
+	for _, w := range tr.TakeWarnings() {
+		fields[w.Field] = true
+	}
+	if !fields["permissions.deny"] || !fields["permissions.hide"] {
+		t.Errorf("Expected deny and hide warnings for Cline, got %v", fields)
+	}
+}
+
+// TestReadClientServers verifies that servers written by TranslateAndApply read back the same
+// across formats, including client-specific field names and Claude Code scopes.
+func TestReadClientServers(t *testing.T) {
+	tmpDir := t.TempDir()
+	project := filepath.Join(tmpDir, "repo")
+
+	mcpCfg := &config.MCPConfig{
+		MCPServers: map[string]config.MCPServer{
+			"git":    {Command: "uvx", Args: []string{"mcp-server-git"}, Env: map[string]string{"GIT_DIR": "/tmp"}},
+			"remote": {URL: "https://example.com/mcp"},
+		},
+	}
+	tr := translator.NewTranslator(&config.Config{}, mcpCfg)
+
+	clients := map[string]config.Client{
+		"cursor":      {ConfigPath: filepath.Join(tmpDir, "cursor.json"), Type: "simple-json"},
+		"windsurf":    {ConfigPath: filepath.Join(tmpDir, "windsurf.json"), Type: "simple-json"},
+		"vscode":      {ConfigPath: filepath.Join(tmpDir, "Code", "User", "mcp.json"), Type: "vscode-mcp"},
+		"claude-code": {ConfigPath: filepath.Join(tmpDir, ".claude.json"), Type: "claude-code", Scope: "project", Project: project},
+		"goose":       {ConfigPath: filepath.Join(tmpDir, "goose.yaml"), Type: "yaml"},
+		"continue":    {ConfigPath: filepath.Join(tmpDir, "blocks"), Type: "continue-blocks"},
+	}
+	for name, clientConf := range clients {
+		for _, id := range []string{"git", "remote"} {
+			if err := tr.TranslateAndApply(name, clientConf, mcpCfg.MCPServers[id]); err != nil {
+				t.Fatalf("TranslateAndApply(%s, %s) failed: %v", name, id, err)
+			}
+		}
+	}
+
+	for name, clientConf := range clients {
+		servers, err := translator.ReadClientServers(name, clientConf)
+		if err != nil {
+			t.Fatalf("ReadClientServers(%s) failed: %v", name, err)
+		}
+		git := servers["git"]
+		if git.Command != "uvx" || len(git.Args) != 1 || git.Args[0] != "mcp-server-git" {
+			t.Errorf("%s: unexpected git server %+v", name, git)
+		}
+		if git.Env["GIT_DIR"] != "/tmp" {
+			t.Errorf("%s: expected env to read back, got %+v", name, git.Env)
+		}
+		if servers["remote"].URL != "https://example.com/mcp" {
+			t.Errorf("%s: unexpected remote server %+v", name, servers["remote"])
+		}
+	}
+
+	servers, err := translator.ReadClientServers("cursor", config.Client{ConfigPath: filepath.Join(tmpDir, "missing.json"), Type: "simple-json"})
+	if err != nil || len(servers) != 0 {
+		t.Errorf("Expected no servers for a missing file, got %v, %v", servers, err)
+	}
+}
+
+// TestBackupClientConfig_TargetIDs verifies that backups of targets whose IDs hold characters
+// file names can't, like profile and IDE targets, are named with the ID escaped.
+func TestBackupClientConfig_TargetIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "mcp.json")
+	if err := os.WriteFile(configPath, []byte(`{"servers": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tr := translator.NewTranslator(&config.Config{Backups: config.BackupConfig{Path: filepath.Join(tmpDir, "backups")}}, &config.MCPConfig{})
+
+	for _, id := range []string{"vscode:work", "jetbrains:IntelliJIdea2025.1", "odd%3A"} {
+		backupPath, err := tr.BackupClientConfig(id, config.Client{ConfigPath: configPath, Type: "vscode-mcp"})
+		if err != nil {
+			t.Fatalf("BackupClientConfig(%s) failed: %v", id, err)
+		}
+		name := strings.TrimSuffix(filepath.Base(backupPath), ".json")
+		if strings.ContainsAny(name, `<>:"/\|?*`) {
+			t.Errorf("backup of %s is named %q", id, name)
+		}
+		if got := translator.BackupClientID(name[:len(name)-len("-20060102-150405")]); got != id {
+			t.Errorf("BackupClientID(%q) = %q, want %q", name, got, id)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tailscale/hujson"
+	"github.com/tuannvm/mcpenetes/internal/config"
+	"github.com/tuannvm/mcpenetes/internal/util"
+)
+
+// inputRefPattern matches VS Code input references such as "${input:github-token}".
+var inputRefPattern = regexp.MustCompile(`\$\{input:([^}]+)\}`)
+
+// vscodeServerEntry adds the "type" discriminator expected by VS Code's mcp.json schema
+// to a generic server map.
+func vscodeServerEntry(serverEntry map[string]interface{}) map[string]interface{} {
+	if _, ok := serverEntry["url"]; ok {
+		serverEntry["type"] = "http" // VS Code falls back to SSE automatically
+	} else {
+		serverEntry["type"] = "stdio"
+	}
+	return serverEntry
+}
+
+// inputIDEscaper escapes the separator of the parts of an input ID, and the "}" that
+// would end an "${input:...}" reference early.
+var inputIDEscaper = strings.NewReplacer("%", "%25", ".", "%2E", "}", "%7D")
+
+// vscodeInputID builds the input ID used to prompt for a secret env value of a server:
+// "<serverID>.<envKey>", with both parts escaped so that no two pairs share an ID.
+func vscodeInputID(serverID, envKey string) string {
+	return inputIDEscaper.Replace(serverID) + "." + inputIDEscaper.Replace(envKey)
+}
+
+// applyVSCodeInputs replaces the values of the server's secret env keys with
+// "${input:<id>}" references and upserts a password promptString input for each of them.
+// It returns the updated inputs list.
+func applyVSCodeInputs(serverID string, serverEntry map[string]interface{}, serverConf config.MCPServer, inputs []interface{}) []interface{} {
+	if len(serverConf.SecretEnv) == 0 {
+		return inputs
+	}
+
+	// Copy env so the shared MCPConfig map isn't modified
+	env := make(map[string]string, len(serverConf.Env)+len(serverConf.SecretEnv))
+	for k, v := range serverConf.Env {
+		env[k] = v
+	}
+
+	for _, key := range serverConf.SecretEnv {
+		id := vscodeInputID(serverID, key)
+		env[key] = "${input:" + id + "}"
+
+		input := map[string]interface{}{
+			"type":        "promptString",
+			"id":          id,
+			"description": fmt.Sprintf("%s for MCP server '%s'", key, serverID),
+			"password":    true,
+		}
+
+		replaced := false
+		for i, existing := range inputs {
+			if m, ok := existing.(map[string]interface{}); ok && m["id"] == id {
+				inputs[i] = input
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			inputs = append(inputs, input)
+		}
+	}
+
+	serverEntry["env"] = env
+	return inputs
+}
+
+// pruneVSCodeInputs drops inputs that are no longer referenced by any server.
+// It returns the remaining inputs and whether anything was removed.
+func pruneVSCodeInputs(servers map[string]interface{}, inputs []interface{}) ([]interface{}, bool) {
+	if len(inputs) == 0 {
+		return inputs, false
+	}
+
+	referenced := make(map[string]bool)
+	if data, err := json.Marshal(servers); err == nil {
+		for _, match := range inputRefPattern.FindAllStringSubmatch(string(data), -1) {
+			referenced[match[1]] = true
+		}
+	}
+
+	kept := []interface{}{}
+	for _, input := range inputs {
+		m, ok := input.(map[string]interface{})
+		if !ok {
+			kept = append(kept, input)
+			continue
+		}
+		id, _ := m["id"].(string)
+		if referenced[id] {
+			kept = append(kept, input)
+			continue
+		}
+		fmt.Printf("  Removed unreferenced input '%s' from client configuration\n", id)
+	}
+
+	return kept, len(kept) != len(inputs)
+}
+
+// readLegacyVSCodeServers returns the servers map stored under the legacy settings.json keys,
+// either nested ({"mcp": {"servers": {...}}}) or flat ({"mcp.servers": {...}}).
+// Both maps are returned as-is so callers can modify them in place.
+func readLegacyVSCodeServers(settings map[string]interface{}) (nested, flat map[string]interface{}) {
+	if mcpObj, ok := settings["mcp"].(map[string]interface{}); ok {
+		nested, _ = mcpObj["servers"].(map[string]interface{})
+	}
+	flat, _ = settings["mcp.servers"].(map[string]interface{})
+	return nested, flat
+}
+
+// legacySettingsBackupDir is the subdirectory of the backup directory that settings.json
+// is backed up to before a migration. Restore doesn't list it: it isn't a client's config.
+const legacySettingsBackupDir = "vscode-settings"
+
+// migrateLegacyVSCodeServer removes serverID from the legacy "mcp.servers" key of the
+// settings.json that sits next to a dedicated mcp.json, so VS Code doesn't list it twice.
+// Servers mcpenetes doesn't manage are left alone. The file is backed up, then patched
+// in place, keeping its comments and formatting.
+func (t *Translator) migrateLegacyVSCodeServer(clientName, mcpJSONPath, serverID string) error {
+	settingsPath := filepath.Join(filepath.Dir(mcpJSONPath), "settings.json")
+
+	data, err := os.ReadFile(settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil
+	}
+
+	root, err := hujson.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse '%s' (invalid JSON/JSONC): %w", settingsPath, err)
+	}
+	standardized := root.Clone()
+	standardized.Standardize()
+	var settings map[string]interface{}
+	if err := json.Unmarshal(standardized.Pack(), &settings); err != nil {
+		return fmt.Errorf("failed to parse '%s': %w", settingsPath, err)
+	}
+
+	nested, flat := readLegacyVSCodeServers(settings)
+	var ops []patchOp
+	if _, ok := nested[serverID]; ok {
+		switch {
+		case len(nested) > 1:
+			ops = append(ops, patchOp{Op: "remove", Path: "/mcp/servers/" + escapeJSONPointer(serverID)})
+		case len(settings["mcp"].(map[string]interface{})) > 1:
+			ops = append(ops, patchOp{Op: "remove", Path: "/mcp/servers"})
+		default:
+			ops = append(ops, patchOp{Op: "remove", Path: "/mcp"})
+		}
+	}
+	if _, ok := flat[serverID]; ok {
+		if len(flat) > 1 {
+			ops = append(ops, patchOp{Op: "remove", Path: "/mcp.servers/" + escapeJSONPointer(serverID)})
+		} else {
+			ops = append(ops, patchOp{Op: "remove", Path: "/mcp.servers"})
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if err := t.backupLegacySettings(clientName, settingsPath, data); err != nil {
+		return fmt.Errorf("failed to back up '%s': %w", settingsPath, err)
+	}
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(settingsPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := patchAndWrite(&root, ops, settingsPath, perm); err != nil {
+		return err
+	}
+
+	fmt.Printf("  Migrated server '%s' out of legacy 'mcp.servers' in '%s'\n", serverID, settingsPath)
+	return nil
+}
+
+// backupLegacySettings copies settings.json to the backup directory before a migration
+// changes it, as <backup dir>/vscode-settings/<clientName>-<timestamp>.json.
+func (t *Translator) backupLegacySettings(clientName, settingsPath string, data []byte) error {
+	backupDir, err := util.ExpandPath(t.AppConfig.Backups.Path)
+	if err != nil {
+		return fmt.Errorf("failed to expand backup path '%s': %w", t.AppConfig.Backups.Path, err)
+	}
+	if backupDir == "" {
+		return fmt.Errorf("no backup directory is configured")
+	}
+	dir := filepath.Join(backupDir, legacySettingsBackupDir)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	backupPath := filepath.Join(dir, backupName(clientName, time.Now())+".json")
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return err
+	}
+	fmt.Printf("  Backed up '%s' to '%s'\n", settingsPath, backupPath)
+	return nil
+}
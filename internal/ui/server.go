@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"io/fs"
 	"net/http"
+	"strconv"
 	"sync"
 
+	"github.com/tuannvm/mcpenetes/internal/client"
 	"github.com/tuannvm/mcpenetes/internal/config"
 	"github.com/tuannvm/mcpenetes/internal/core"
 	"github.com/tuannvm/mcpenetes/internal/doctor"
@@ -15,6 +17,8 @@ import (
 	"github.com/tuannvm/mcpenetes/internal/registry"
 	"github.com/tuannvm/mcpenetes/internal/registry/manager"
 	"github.com/tuannvm/mcpenetes/internal/search"
+	"github.com/tuannvm/mcpenetes/internal/secrets"
+	"github.com/tuannvm/mcpenetes/internal/translator"
 	"github.com/tuannvm/mcpenetes/internal/util"
 	"github.com/tuannvm/mcpenetes/internal/version"
 )
@@ -50,6 +54,7 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/apply", s.handleApply)
 	mux.HandleFunc("/api/search", s.handleSearch)
 	mux.HandleFunc("/api/install", s.handleInstall)
+	mux.HandleFunc("/api/install/resolve", s.handleResolveInstall)
 	mux.HandleFunc("/api/server/update", s.handleUpdateServer)
 	mux.HandleFunc("/api/server/remove", s.handleRemoveServer)
 	mux.HandleFunc("/api/doctor", s.handleDoctor)
@@ -71,15 +76,39 @@ type ConfigDataResponse struct {
 	Clients    map[string]config.Client `json:"clients"`
 	MCPServers map[string]config.MCPServer `json:"mcpServers"`
 	Registries []config.Registry `json:"registries"`
+	// Capabilities of each client, so the UI can warn about settings a client can't enforce
+	Capabilities map[string]client.Capabilities `json:"capabilities"`
 }
 
 type ApplyRequest struct {
 	ClientNames []string `json:"clients"`
+	// IfRunning is the running-client policy: "warn" (default), "wait" or "skip"
+	IfRunning string `json:"ifRunning,omitempty"`
 }
 
 type InstallRequest struct {
 	ServerID string            `json:"serverId"`
 	Config   *config.MCPServer `json:"config,omitempty"` // Optional override
+	// Env holds the values entered for the server's environment variables
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// SearchResult is a ranked server, with the registries that list it
+type SearchResult struct {
+	registry.ServerData
+	Score      float64  `json:"score"`
+	Registries []string `json:"registries"`
+}
+
+// ResolveResponse is the command the resolver would install, and why
+type ResolveResponse struct {
+	Config  config.MCPServer `json:"config"`
+	Runner  string           `json:"runner"`
+	Version string           `json:"version,omitempty"`
+	Reasons []string         `json:"reasons"`
+	Guessed bool             `json:"guessed"`
+	// Env lists the environment variables to prompt for
+	Env []registry.EnvVariable `json:"env"`
 }
 
 type UpdateServerRequest struct {
@@ -94,6 +123,7 @@ type RemoveServerRequest struct {
 type AddRegistryRequest struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
+	Type string `json:"type,omitempty"`
 }
 
 type RemoveRegistryRequest struct {
@@ -131,19 +161,23 @@ func (s *Server) handleGetData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Detect clients if none configured
-	if len(cfg.Clients) == 0 {
-		detected, err := util.DetectMCPClients()
-		if err == nil {
-			cfg.Clients = detected
-		}
+	// Detect clients if none configured, leaving out disabled and low-confidence ones
+	if clients, _, err := util.ResolveClients(cfg, false); err == nil {
+		cfg.Clients = clients
+	}
+
+	capabilities := make(map[string]client.Capabilities)
+	for name, clientConf := range cfg.Clients {
+		// As applied: formats with their own schema drop some settings
+		capabilities[name] = translator.TargetCapabilities(name, clientConf)
 	}
 
 	resp := ConfigDataResponse{
-		Version:    version.Version,
-		Clients:    cfg.Clients,
-		MCPServers: mcpCfg.MCPServers,
-		Registries: cfg.Registries,
+		Version:      version.Version,
+		Clients:      cfg.Clients,
+		MCPServers:   mcpCfg.MCPServers,
+		Registries:   cfg.Registries,
+		Capabilities: capabilities,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -161,6 +195,11 @@ func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	runningPolicy, err := core.ParseRunningPolicy(req.IfRunning)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -174,7 +213,12 @@ func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if clients, _, err := util.ResolveClients(cfg, false); err == nil {
+		cfg.Clients = clients
+	}
+
 	manager := core.NewManager(cfg, mcpCfg)
+	manager.RunningPolicy = runningPolicy
 	var results []core.ApplyResult
 	var mu sync.Mutex
 
@@ -224,10 +268,14 @@ func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
 	}
 
 	type JSONResult struct {
-		ClientName string `json:"clientName"`
-		Success    bool   `json:"success"`
-		BackupPath string `json:"backupPath"`
-		Error      string `json:"error,omitempty"`
+		ClientName string               `json:"clientName"`
+		Success    bool                 `json:"success"`
+		BackupPath string               `json:"backupPath"`
+		Error      string               `json:"error,omitempty"`
+		Warnings   []translator.Warning `json:"warnings,omitempty"`
+		Running    []string             `json:"running,omitempty"`
+		Skipped    bool                 `json:"skipped,omitempty"`
+		Waited     bool                 `json:"waited,omitempty"`
 	}
 
 	var jsonResults []JSONResult
@@ -236,6 +284,10 @@ func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
 			ClientName: res.ClientName,
 			Success:    res.Success,
 			BackupPath: res.BackupPath,
+			Warnings:   res.Warnings,
+			Running:    res.Running,
+			Skipped:    res.Skipped,
+			Waited:     res.Waited,
 		}
 		if res.Error != nil {
 			jr.Error = res.Error.Error()
@@ -255,27 +307,34 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var allServers []registry.ServerData
-	for _, reg := range cfg.Registries {
-		servers, err := registry.FetchMCPServersWithCache(reg.URL, false)
-		if err == nil {
-			allServers = append(allServers, servers...)
-		}
+	idx, err := search.LoadIndex()
+	if err != nil {
+		http.Error(w, "Failed to load the index", http.StatusInternalServerError)
+		return
 	}
 
-	var filtered []registry.ServerData
-	if query == "" {
-		filtered = allServers
-	} else {
-		for _, s := range allServers {
-			if util.CaseInsensitiveContains(s.Name, query) || util.CaseInsensitiveContains(s.Description, query) {
-				filtered = append(filtered, s)
-			}
-		}
+	// Metadata filters: ?tool=&license=&remoteCapable=true&attribute= (tool and attribute repeat)
+	params := r.URL.Query()
+	filter := registry.Filter{
+		Tools:         params["tool"],
+		License:       params.Get("license"),
+		RemoteCapable: params.Get("remoteCapable") == "true",
+		Attributes:    params["attribute"],
+	}
+	// Ranked like the CLI's search; ?limit= caps the results (0 for all)
+	limit := 100
+	if l, err := strconv.Atoi(params.Get("limit")); err == nil && l >= 0 {
+		limit = l
+	}
+	results := make([]SearchResult, 0)
+	// Synced registries are searched in the local index; unreachable ones are skipped
+	found, _ := idx.Find(cfg.Registries, query, filter, limit, false)
+	for _, res := range found {
+		results = append(results, SearchResult{ServerData: res.Server, Score: res.Score, Registries: res.Registries})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(filtered)
+	json.NewEncoder(w).Encode(results)
 }
 
 func (s *Server) handleInstall(w http.ResponseWriter, r *http.Request) {
@@ -295,8 +354,18 @@ func (s *Server) handleInstall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Pass the optional config override
-	err := search.AddServerToMCPConfig(req.ServerID, nil, req.Config)
+	// Pass the optional config override; the registry entry also gives the env schema
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		http.Error(w, "Failed to load config", http.StatusInternalServerError)
+		return
+	}
+	idx, err := search.LoadIndex()
+	if err != nil {
+		http.Error(w, "Failed to load the index", http.StatusInternalServerError)
+		return
+	}
+	_, err = search.AddServerToMCPConfig(req.ServerID, idx.Lookup(cfg.Registries, req.ServerID), req.Config, req.Env)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to install server: %v", err), http.StatusInternalServerError)
 		return
@@ -306,6 +375,41 @@ func (s *Server) handleInstall(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Server added to configuration"})
 }
 
+func (s *Server) handleResolveInstall(w http.ResponseWriter, r *http.Request) {
+	serverID := r.URL.Query().Get("id")
+	if serverID == "" {
+		http.Error(w, "Server ID is required", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		http.Error(w, "Failed to load config", http.StatusInternalServerError)
+		return
+	}
+	idx, err := search.LoadIndex()
+	if err != nil {
+		http.Error(w, "Failed to load the index", http.StatusInternalServerError)
+		return
+	}
+	data := idx.Lookup(cfg.Registries, serverID)
+	res := registry.ResolverFor(cfg.Registries, data).Resolve(serverID, data)
+	var env []registry.EnvVariable
+	if data != nil {
+		env = data.EnvSchema.Variables()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ResolveResponse{
+		Config:  res.Server,
+		Runner:  res.Runner,
+		Version: res.Version,
+		Reasons: res.Reasons,
+		Guessed: res.Guessed,
+		Env:     env,
+	})
+}
+
 func (s *Server) handleUpdateServer(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -363,7 +467,8 @@ func (s *Server) handleRemoveServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, ok := mcpCfg.MCPServers[req.ServerID]; !ok {
+	server, ok := mcpCfg.MCPServers[req.ServerID]
+	if !ok {
 		http.Error(w, "Server not found", http.StatusNotFound)
 		return
 	}
@@ -375,12 +480,17 @@ func (s *Server) handleRemoveServer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Nothing references the server's stored secrets any more
+	if err := secrets.Forget(server.Env); err != nil {
+		log.Warn("Failed to delete secrets of %s: %v", req.ServerID, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Server removed from configuration"})
 }
 
 func (s *Server) handleDoctor(w http.ResponseWriter, r *http.Request) {
-	results := doctor.RunChecks()
+	results := doctor.RunChecks(false)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
@@ -402,7 +512,7 @@ func (s *Server) handleAddRegistry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := manager.AddRegistry(req.Name, req.URL); err != nil {
+	if err := manager.AddRegistry(req.Name, req.URL, req.Type); err != nil {
 		http.Error(w, fmt.Sprintf("Failed to add registry: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -477,6 +587,10 @@ func (s *Server) handleGetBackups(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if clients, _, err := util.ResolveClients(cfg, true); err == nil {
+		cfg.Clients = clients
+	}
+
 	// We don't strictly need MCPConfig for listing backups, but manager expects it.
 	mcpCfg := &config.MCPConfig{}
 
@@ -513,6 +627,9 @@ func (s *Server) handleRestoreBackup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("Error loading config: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if clients, _, err := util.ResolveClients(cfg, true); err == nil {
+		cfg.Clients = clients
+	}
 	mcpCfg := &config.MCPConfig{} // Not needed for restore
 
 	manager := core.NewManager(cfg, mcpCfg)
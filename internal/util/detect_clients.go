@@ -6,21 +6,66 @@ import (
 )
 
 // DetectMCPClients automatically detects installed MCP-compatible clients
-// and their configuration paths on the user's system
+// and their configuration paths on the user's system. Clients are returned even
+// when err reports problems in clients.yaml.
 func DetectMCPClients() (map[string]config.Client, error) {
 	detected, err := client.DetectClients()
-	if err != nil {
+	if detected == nil {
 		return nil, err
 	}
 
 	result := make(map[string]config.Client)
 	for id, c := range detected {
+		evidence := make([]string, len(c.Evidence))
+		for i, e := range c.Evidence {
+			evidence[i] = e.String()
+		}
 		result[id] = config.Client{
 			ConfigPath: c.ConfigPath,
 			Type:       string(c.ConfigFormat),
 			Key:        c.ConfigKey,
+			Confidence: string(c.Confidence),
+			Evidence:   evidence,
+			Catalog:    c.Catalog,
+		}
+	}
+
+	return result, err
+}
+
+// ResolveClients returns the clients to apply to: those configured in config.yaml, or the
+// detected ones if none are configured. Entries without a config_path only toggle detected
+// clients, so they don't count as configured. Disabled clients are left out.
+//
+// Detected clients with low confidence are returned separately as skipped, unless
+// includeLowConfidence is set. Clients configured in config.yaml are always used.
+func ResolveClients(cfg *config.Config, includeLowConfidence bool) (clients, skipped map[string]config.Client, err error) {
+	clients = make(map[string]config.Client)
+	skipped = make(map[string]config.Client)
+	for name, c := range cfg.Clients {
+		if c.ConfigPath != "" {
+			clients[name] = c
+		}
+	}
+
+	if len(clients) == 0 {
+		detected, detectErr := DetectMCPClients()
+		err = detectErr
+		for name, c := range detected {
+			if c.Confidence == string(client.ConfidenceLow) && !includeLowConfidence {
+				skipped[name] = c
+				continue
+			}
+			clients[name] = c
+		}
+	}
+
+	for name, c := range cfg.Clients {
+		if c.Disabled {
+			delete(clients, name)
+			delete(skipped, name)
 		}
 	}
 
-	return result, nil
+	return clients, skipped, err
 }